@@ -31,6 +31,9 @@ func main() {
 		if err := handlers.EnsureAdminUser(); err != nil {
 			log.Fatalf("Failed to ensure admin user: %v", err)
 		}
+
+		// Expire board timers as they run out
+		handlers.StartBoardTimers()
 	} else {
 		log.Println("⚠️ Skipping Auth/Admin initialization (Smoke Test Mode)")
 	}
@@ -83,46 +86,83 @@ func main() {
 		// Board routes
 		log.Println("Registering board routes...")
 		api.GET("/boards", handlers.ListBoards)
-		api.POST("/boards", handlers.CreateBoard)
+		api.POST("/boards", handlers.AuthMiddleware(), handlers.CreateBoard)
 		api.GET("/boards/:id", handlers.GetBoard)
-		api.PUT("/boards/:id", handlers.UpdateBoard) // Add generic update
-		api.DELETE("/boards/:id", handlers.DeleteBoard)
-		api.POST("/boards/:id/claim", handlers.ClaimBoard)
-		api.POST("/boards/:id/unclaim", handlers.UnclaimBoard)
-		api.POST("/boards/:id/join", handlers.JoinBoard)
-		api.POST("/boards/:id/leave", handlers.LeaveBoard)
+		api.PUT("/boards/:id", handlers.AuthMiddleware(), handlers.UpdateBoard) // Add generic update
+		api.DELETE("/boards/:id", handlers.AuthMiddleware(), handlers.DeleteBoard)
+		api.POST("/boards/:id/claim", handlers.AuthMiddleware(), handlers.ClaimBoard)
+		api.POST("/boards/:id/unclaim", handlers.AuthMiddleware(), handlers.UnclaimBoard)
+		api.POST("/boards/:id/join", handlers.AuthMiddleware(), handlers.JoinBoard)
+		api.POST("/boards/:id/leave", handlers.AuthMiddleware(), handlers.LeaveBoard)
 		api.GET("/boards/:id/participants", handlers.GetBoardParticipants)
+		api.GET("/boards/:id/events", handlers.HandleBoardEvents) // SSE fallback for the WebSocket
 		api.PUT("/boards/:id/teams", handlers.AuthMiddleware(), handlers.UpdateBoardTeams)
 		api.PUT("/boards/:id/status", handlers.AuthMiddleware(), handlers.UpdateBoardStatus)
-
-		// Column routes
-		api.POST("/boards/:id/columns", handlers.CreateColumn)
-		api.PUT("/columns/:id", handlers.UpdateColumn)
-		api.PUT("/columns/:id/position", handlers.UpdateColumnPosition)
-		api.DELETE("/columns/:id", handlers.DeleteColumn)
+		api.PUT("/boards/:id/phase", handlers.AuthMiddleware(), handlers.UpdateBoardPhase)
+		api.POST("/boards/:id/reveal", handlers.AuthMiddleware(), handlers.RevealCards)
+		api.POST("/boards/:id/timer/:action", handlers.AuthMiddleware(), handlers.UpdateBoardTimer)
+		api.GET("/boards/:id/merge-suggestions", handlers.AuthMiddleware(), handlers.GetMergeSuggestions)
+		api.POST("/boards/:id/merge", handlers.AuthMiddleware(), handlers.BulkMergeCards)
+
+		// Label routes (palette managed by board managers, cards tagged by contributors)
+		api.GET("/boards/:id/labels", handlers.ListBoardLabels)
+		api.POST("/boards/:id/labels", handlers.AuthMiddleware(), handlers.CreateBoardLabel)
+		api.PUT("/boards/:id/labels/:labelId", handlers.AuthMiddleware(), handlers.UpdateBoardLabel)
+		api.DELETE("/boards/:id/labels/:labelId", handlers.AuthMiddleware(), handlers.DeleteBoardLabel)
+		api.POST("/cards/:id/labels/:labelId", handlers.AuthMiddleware(), handlers.TagCard)
+		api.DELETE("/cards/:id/labels/:labelId", handlers.AuthMiddleware(), handlers.UntagCard)
+
+		// Column routes (board managers only, enforced by the board policy)
+		api.POST("/boards/:id/columns", handlers.AuthMiddleware(), handlers.CreateColumn)
+		api.PUT("/columns/:id", handlers.AuthMiddleware(), handlers.UpdateColumn)
+		api.PUT("/columns/:id/position", handlers.AuthMiddleware(), handlers.UpdateColumnPosition)
+		api.PUT("/columns/:id/vote-limit", handlers.AuthMiddleware(), handlers.UpdateColumnVoteLimit)
+		api.DELETE("/columns/:id", handlers.AuthMiddleware(), handlers.DeleteColumn)
 
 		// Card routes
-		api.POST("/columns/:columnId/cards", handlers.CreateCard)
-		api.PUT("/cards/:id", handlers.UpdateCard)
-		api.PUT("/cards/:id/move", handlers.MoveCard)
-		api.POST("/cards/:id/merge", handlers.MergeCard)
-		api.POST("/cards/:id/unmerge", handlers.UnmergeCard)
-		api.DELETE("/cards/:id", handlers.DeleteCard)
+		api.POST("/columns/:columnId/cards", handlers.AuthMiddleware(), handlers.CreateCard)
+		api.PUT("/cards/:id", handlers.AuthMiddleware(), handlers.UpdateCard)
+		api.PUT("/cards/:id/move", handlers.AuthMiddleware(), handlers.MoveCard)
+		api.POST("/cards/:id/merge", handlers.AuthMiddleware(), handlers.MergeCard)
+		api.POST("/cards/:id/unmerge", handlers.AuthMiddleware(), handlers.UnmergeCard)
+		api.PUT("/cards/:id/group", handlers.AuthMiddleware(), handlers.SetCardGroup)
+		api.DELETE("/cards/:id", handlers.AuthMiddleware(), handlers.DeleteCard)
+		api.GET("/cards/:id/history", handlers.AuthMiddleware(), handlers.GetCardHistory)
+		api.POST("/cards/:id/history/:revisionId/restore", handlers.AuthMiddleware(), handlers.RestoreCardRevision)
+
+		// Card group routes (groups nest and carry their cards when moved)
+		api.POST("/columns/:columnId/groups", handlers.AuthMiddleware(), handlers.CreateCardGroup)
+		api.PUT("/groups/:id", handlers.AuthMiddleware(), handlers.UpdateCardGroup)
+		api.PUT("/groups/:id/move", handlers.AuthMiddleware(), handlers.MoveCardGroup)
+		api.DELETE("/groups/:id", handlers.AuthMiddleware(), handlers.DeleteCardGroup)
 
 		// Vote routes
-		api.POST("/cards/:id/votes", handlers.AddVote)
+		api.POST("/cards/:id/votes", handlers.AuthMiddleware(), handlers.AddVote)
+		api.GET("/boards/:id/votes/remaining", handlers.AuthMiddleware(), handlers.GetRemainingVotes)
+		api.GET("/boards/:id/votes/results", handlers.AuthMiddleware(), handlers.GetVoteResults)
+		api.PUT("/boards/:id/ballot", handlers.AuthMiddleware(), handlers.SubmitBallot)
+		api.POST("/boards/:id/votes/close", handlers.AuthMiddleware(), handlers.CloseVoting)
 		api.GET("/cards/:id/votes", handlers.GetVotes)
-		api.DELETE("/votes/:id", handlers.DeleteVote)
+		api.DELETE("/votes/:id", handlers.AuthMiddleware(), handlers.DeleteVote)
+
+		// Closing survey routes
+		api.GET("/boards/:id/survey", handlers.AuthMiddleware(), handlers.GetSurvey)
+		api.POST("/boards/:id/survey", handlers.AuthMiddleware(), handlers.AnswerSurvey)
 
 		// Reaction routes
-		api.POST("/cards/:id/reactions", handlers.ToggleReaction)
+		api.POST("/cards/:id/reactions", handlers.AuthMiddleware(), handlers.ToggleReaction)
+
+		// Comment routes
+		api.GET("/cards/:id/comments", handlers.AuthMiddleware(), handlers.ListComments)
+		api.POST("/cards/:id/comments", handlers.AuthMiddleware(), handlers.CreateComment)
+		api.PUT("/cards/:id/comments/:commentId", handlers.AuthMiddleware(), handlers.UpdateComment)
+		api.DELETE("/cards/:id/comments/:commentId", handlers.AuthMiddleware(), handlers.DeleteComment)
 
 		// Global Action Items
 		api.GET("/action-items", handlers.GetGlobalActionItems)
 
 		// Admin Routes
 		api.POST("/admin/login", handlers.AdminLogin)
-		api.POST("/admin/boards/:id/settings", handlers.AdminUpdateBoardSettings)
 		api.GET("/admin/stats", handlers.GetSystemStats)
 
 		// User Routes (Protected)
@@ -149,6 +189,7 @@ func main() {
 			adminGroup.DELETE("/users/:id", handlers.DeleteUser)
 
 			// Board Management
+			adminGroup.POST("/boards/:id/settings", handlers.AdminUpdateBoardSettings)
 			adminGroup.PUT("/boards/:id/status", handlers.UpdateBoardStatus)
 			adminGroup.DELETE("/boards/:id", handlers.DeleteBoard)
 
@@ -174,6 +215,8 @@ func main() {
 			teams.PUT("/:id/members/:userID/role", handlers.UpdateMemberRole)
 			teams.POST("/:id/join", handlers.JoinTeam)
 			teams.POST("/:id/leave", handlers.LeaveTeam)
+			teams.GET("/:id/analytics", handlers.GetTeamAnalytics(database.DB))
+			teams.GET("/:id/themes", handlers.GetTeamThemes(database.DB))
 		}
 	}
 
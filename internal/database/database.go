@@ -7,6 +7,7 @@ import (
 	"github.com/bento-lab-ops/bentro/internal/models"
 
 	"github.com/glebarez/sqlite"
+	"github.com/google/uuid"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 	"gorm.io/gorm/logger"
@@ -25,7 +26,7 @@ func InitDB() error {
 
 	if os.Getenv("DB_DRIVER") == "sqlite" {
 		log.Println("🛠️ Using SQLite Driver (Local Mode)")
-		dialector = sqlite.Open("retro.db")
+		dialector = sqlite.Open(SQLiteDSN("retro.db"))
 	} else {
 		host := getEnv("DB_HOST", "localhost")
 		port := getEnv("DB_PORT", "5432")
@@ -69,18 +70,32 @@ func InitDB() error {
 	return nil
 }
 
+// SQLiteDSN opens a SQLite file so that write transactions take the write
+// lock when they begin and wait for each other, instead of failing with
+// "database is locked". Vote budgets rely on this, see handlers.lockVoter.
+func SQLiteDSN(path string) string {
+	return path + "?_pragma=busy_timeout(10000)&_txlock=immediate"
+}
+
 // PerformMigrations handles schema migration
 func PerformMigrations(db *gorm.DB) error {
 	return db.AutoMigrate(
 		&models.Board{},
 		&models.Column{},
 		&models.Card{},
+		&models.CardGroup{},
 		&models.Vote{},
+		&models.BoardVoter{},
 		&models.Reaction{},
+		&models.CardComment{},
+		&models.CardRevision{},
+		&models.Label{},
+		&models.CardLabel{},
 		&models.User{},
 		&models.Team{},
 		&models.TeamMember{},
 		&models.BoardMember{},
+		&models.SurveyRespondent{},
 	)
 }
 
@@ -100,6 +115,59 @@ func MigrateLegacyData(db *gorm.DB) {
 	} else {
 		log.Println("Migrated legacy team_id data to board_teams table")
 	}
+
+	migrateMergedCards(db)
+}
+
+// migrateMergedCards turns the cards merged into another card, from before card
+// groups existed, into a group titled after the card they were merged into, then
+// drops the merged_with_id column
+func migrateMergedCards(db *gorm.DB) {
+	if !db.Migrator().HasColumn(&models.Card{}, "merged_with_id") {
+		return
+	}
+	var parents []struct {
+		ID       uuid.UUID
+		ColumnID uuid.UUID
+		Content  string
+		Position int
+	}
+	if err := db.Raw(`
+		SELECT id, column_id, content, position FROM cards
+		WHERE deleted_at IS NULL
+		AND id IN (SELECT merged_with_id FROM cards WHERE merged_with_id IS NOT NULL)
+		ORDER BY created_at
+	`).Scan(&parents).Error; err != nil {
+		log.Printf("Warning: Failed to read merged cards: %v", err)
+		return
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		for _, parent := range parents {
+			group := models.CardGroup{ColumnID: parent.ColumnID, Title: parent.Content, Position: parent.Position}
+			if err := tx.Create(&group).Error; err != nil {
+				return err
+			}
+			if err := tx.Exec(`
+				UPDATE cards SET group_id = ?, column_id = ?
+				WHERE (id = ? OR merged_with_id = ?) AND group_id IS NULL
+			`, group.ID, parent.ColumnID, parent.ID, parent.ID).Error; err != nil {
+				return err
+			}
+		}
+		// SQLite cannot drop a column a foreign key still refers to
+		if tx.Migrator().HasConstraint(&models.Card{}, "fk_cards_merged_cards") {
+			if err := tx.Migrator().DropConstraint(&models.Card{}, "fk_cards_merged_cards"); err != nil {
+				return err
+			}
+		}
+		return tx.Migrator().DropColumn(&models.Card{}, "merged_with_id")
+	})
+	if err != nil {
+		log.Printf("Warning: Failed to migrate merged cards to card groups: %v", err)
+	} else {
+		log.Printf("Migrated %d merged card stacks to card groups", len(parents))
+	}
 }
 
 // getEnv gets environment variable with a default value
@@ -6,6 +6,7 @@ import (
 	"testing"
 
 	"github.com/glebarez/sqlite"
+	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 	"gorm.io/gorm"
 )
@@ -121,6 +122,52 @@ func TestMigrateLegacyData_Fail(t *testing.T) {
 	MigrateLegacyData(db)
 }
 
+// legacyCard is a card as stored before card groups existed
+type legacyCard struct {
+	models.Card
+	MergedWithID *uuid.UUID   `gorm:"type:uuid"`
+	MergedCards  []legacyCard `gorm:"foreignKey:MergedWithID;constraint:OnDelete:SET NULL"`
+}
+
+func (legacyCard) TableName() string { return "cards" }
+
+func TestMigrateMergedCards(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(t.TempDir()+"/merged.db"), &gorm.Config{})
+	assert.NoError(t, err)
+	assert.NoError(t, PerformMigrations(db))
+
+	// Cards merged into another card before card groups existed
+	assert.NoError(t, db.AutoMigrate(&legacyCard{}))
+	column := models.Column{BoardID: uuid.New(), Name: "Went wrong"}
+	db.Create(&column)
+	parent := models.Card{ColumnID: column.ID, Content: "Slow deploys", Position: 2}
+	child := models.Card{ColumnID: column.ID, Content: "Deploys take ages"}
+	single := models.Card{ColumnID: column.ID, Content: "Great lunch"}
+	db.Create(&parent)
+	db.Create(&child)
+	db.Create(&single)
+	db.Exec(`UPDATE cards SET merged_with_id = ? WHERE id = ?`, parent.ID, child.ID)
+
+	MigrateLegacyData(db)
+
+	assert.False(t, db.Migrator().HasColumn(&models.Card{}, "merged_with_id"))
+	var groups []models.CardGroup
+	db.Find(&groups)
+	if assert.Len(t, groups, 1) {
+		assert.Equal(t, "Slow deploys", groups[0].Title)
+		assert.Equal(t, column.ID, groups[0].ColumnID)
+		assert.Equal(t, 2, groups[0].Position)
+	}
+	var grouped int64
+	db.Model(&models.Card{}).Where("group_id IS NOT NULL").Count(&grouped)
+	assert.Equal(t, int64(2), grouped)
+
+	// Running it again is a no-op
+	MigrateLegacyData(db)
+	db.Model(&models.CardGroup{}).Count(&grouped)
+	assert.Equal(t, int64(1), grouped)
+}
+
 func cleanupDB() {
 	os.Remove("retro.db")
 }
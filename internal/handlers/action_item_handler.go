@@ -16,19 +16,20 @@ func GetGlobalActionItems(c *gin.Context) {
 	ownerParam := c.Query("owner")
 
 	type ActionItemResult struct {
-		ID             uuid.UUID  `json:"id"`
-		Content        string     `json:"content"`
-		IsActionItem   bool       `json:"is_action_item"`
-		Owner          string     `json:"owner"`
-		DueDate        *time.Time `json:"due_date"`
-		Completed      bool       `json:"completed"`
-		CompletionDate *time.Time `json:"completion_date"`
-		CompletionLink string     `json:"completion_link"`
-		CompletionDesc string     `json:"completion_desc"`
-		CreatedAt      time.Time  `json:"created_at"`
-		BoardName      string     `json:"board_name"`
-		BoardID        uuid.UUID  `json:"board_id"`
-		BoardDeleted   bool       `json:"board_deleted"`
+		ID             uuid.UUID      `json:"id"`
+		Content        string         `json:"content"`
+		IsActionItem   bool           `json:"is_action_item"`
+		Owner          string         `json:"owner"`
+		DueDate        *time.Time     `json:"due_date"`
+		Completed      bool           `json:"completed"`
+		CompletionDate *time.Time     `json:"completion_date"`
+		CompletionLink string         `json:"completion_link"`
+		CompletionDesc string         `json:"completion_desc"`
+		CreatedAt      time.Time      `json:"created_at"`
+		BoardName      string         `json:"board_name"`
+		BoardID        uuid.UUID      `json:"board_id"`
+		BoardDeleted   bool           `json:"board_deleted"`
+		Labels         []models.Label `gorm:"-" json:"labels"`
 	}
 
 	results := []ActionItemResult{}
@@ -55,6 +56,11 @@ func GetGlobalActionItems(c *gin.Context) {
 		query = query.Where("cards.owner = ?", ownerParam)
 	}
 
+	// Labels are scoped to boards and teams, so across boards they also match by name
+	if ids, names := labelFilter(c.QueryArray("label")); len(ids) > 0 || len(names) > 0 {
+		query = query.Where("cards.id IN (?)", labeledCardIDs(ids, names))
+	}
+
 	// Order by due date (nulls last) and then created_at
 	query = query.Order("cards.completed ASC, cards.due_date ASC NULLS LAST, cards.created_at DESC")
 
@@ -63,6 +69,31 @@ func GetGlobalActionItems(c *gin.Context) {
 		return
 	}
 
+	if len(results) > 0 {
+		cardIDs := make([]uuid.UUID, len(results))
+		for i, result := range results {
+			cardIDs[i] = result.ID
+		}
+		var tags []struct {
+			CardID uuid.UUID
+			models.Label
+		}
+		if err := database.DB.Table("card_labels").
+			Select("card_labels.card_id, labels.*").
+			Joins("JOIN labels ON labels.id = card_labels.label_id").
+			Where("card_labels.card_id IN ?", cardIDs).
+			Order("labels.name asc").
+			Scan(&tags).Error; err == nil {
+			labels := make(map[uuid.UUID][]models.Label)
+			for _, tag := range tags {
+				labels[tag.CardID] = append(labels[tag.CardID], tag.Label)
+			}
+			for i := range results {
+				results[i].Labels = labels[results[i].ID]
+			}
+		}
+	}
+
 	c.JSON(http.StatusOK, results)
 }
 
@@ -94,6 +125,7 @@ func AdminUpdateActionItem(c *gin.Context) {
 		return
 	}
 
+	before := cardStateOf(card)
 	if input.Content != "" {
 		card.Content = input.Content
 	}
@@ -123,12 +155,17 @@ func AdminUpdateActionItem(c *gin.Context) {
 		card.CompletionDesc = *input.CompletionDesc
 	}
 
-	if err := database.DB.Save(&card).Error; err != nil {
+	var actor string
+	if user, ok := currentUser(c); ok {
+		actor = displayName(user)
+	}
+	if err := saveCardWithRevision(&card, before, actor, RevisionAdmin, nil); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update action item"})
 		return
 	}
 
-	c.JSON(http.StatusOK, card)
+	board, _ := boardForCard(card.ID)
+	c.JSON(http.StatusOK, presentCard(&board, card, ""))
 }
 
 // AdminDeleteActionItem allows admins to delete an action item
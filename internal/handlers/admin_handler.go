@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
 	"os"
 	"github.com/bento-lab-ops/bentro/internal/database"
@@ -57,6 +58,12 @@ func AdminUpdateBoardSettings(c *gin.Context) {
 		return
 	}
 
+	// The override may skip phase transitions, but not leave the phase model
+	if input.Phase != nil && !IsValidPhase(*input.Phase) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown phase " + *input.Phase})
+		return
+	}
+
 	var board models.Board
 	if err := database.DB.First(&board, boardID).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Board not found"})
@@ -67,16 +74,28 @@ func AdminUpdateBoardSettings(c *gin.Context) {
 	if input.VoteLimit != nil {
 		updates["vote_limit"] = *input.VoteLimit
 	}
-	if input.Phase != nil {
-		updates["phase"] = *input.Phase
-	}
 	if input.BlindVoting != nil {
 		updates["blind_voting"] = *input.BlindVoting
 	}
 
-	if err := database.DB.Model(&board).Updates(updates).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update board settings"})
-		return
+	if len(updates) > 0 {
+		if err := database.DB.Model(&board).Updates(updates).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update board settings"})
+			return
+		}
+	}
+
+	// The phase changes like any other phase change, only without the transition check
+	if input.Phase != nil && *input.Phase != boardPhase(board.Phase) {
+		err := setBoardPhase(&board, *input.Phase, "admin")
+		if errors.Is(err, ErrPhaseConflict) || errors.Is(err, ErrCardsHidden) {
+			c.JSON(http.StatusConflict, gin.H{"error": phaseErrorMessage(err), "phase": boardPhase(board.Phase)})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update board settings"})
+			return
+		}
 	}
 
 	// Notify via WebSocket
@@ -347,49 +347,62 @@ func DeleteUser(c *gin.Context) {
 // AuthMiddleware - standard JWT middleware
 func AuthMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		tokenString, err := c.Cookie("auth_token")
+		user, err := authenticateRequest(c)
 		if err != nil {
-			// Check Authorization header as fallback
-			authHeader := c.GetHeader("Authorization")
-			if len(authHeader) > 7 && authHeader[:7] == "Bearer " {
-				tokenString = authHeader[7:]
-			}
-		}
-
-		if tokenString == "" {
-			fmt.Println("AuthMiddleware: No token found")
+			fmt.Printf("AuthMiddleware: %v\n", err)
 			c.Next()
 			return
 		}
 
-		token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-			}
-			return jwtSecret, nil
-		})
+		fmt.Printf("AuthMiddleware: Setting user_role to: %s for user: %s\n", user.Role, user.Email)
+		c.Set("user", user)
+		c.Set("user_id", user.ID)
+		c.Set("user_role", user.Role)
 
-		if err != nil || !token.Valid {
-			fmt.Printf("AuthMiddleware: Invalid token - err: %v, valid: %v\n", err, token != nil && token.Valid)
-			c.Next()
-			return
+		c.Next()
+	}
+}
+
+// authenticateRequest resolves the user behind the auth_token cookie or the Bearer token.
+// It is shared by AuthMiddleware and the WebSocket handshake so both agree on identity.
+func authenticateRequest(c *gin.Context) (models.User, error) {
+	var user models.User
+
+	tokenString, err := c.Cookie("auth_token")
+	if err != nil {
+		// Check Authorization header as fallback
+		authHeader := c.GetHeader("Authorization")
+		if len(authHeader) > 7 && authHeader[:7] == "Bearer " {
+			tokenString = authHeader[7:]
 		}
+	}
+
+	if tokenString == "" {
+		return user, fmt.Errorf("no token found")
+	}
 
-		if claims, ok := token.Claims.(jwt.MapClaims); ok {
-			userIDStr, ok := claims["user_id"].(string)
-			if ok {
-				var user models.User
-				if err := database.DB.First(&user, "id = ?", userIDStr).Error; err == nil {
-					fmt.Printf("AuthMiddleware: Setting user_role to: %s for user: %s\n", user.Role, user.Email)
-					c.Set("user", user)
-					c.Set("user_id", user.ID)
-					c.Set("user_role", user.Role)
-				} else {
-					fmt.Printf("AuthMiddleware: Failed to find user: %v\n", err)
-				}
-			}
+	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
 		}
+		return jwtSecret, nil
+	})
 
-		c.Next()
+	if err != nil || !token.Valid {
+		return user, fmt.Errorf("invalid token - err: %v, valid: %v", err, token != nil && token.Valid)
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return user, fmt.Errorf("invalid token claims")
+	}
+	userIDStr, ok := claims["user_id"].(string)
+	if !ok {
+		return user, fmt.Errorf("token has no user_id claim")
+	}
+
+	if err := database.DB.First(&user, "id = ?", userIDStr).Error; err != nil {
+		return user, fmt.Errorf("failed to find user: %w", err)
 	}
+	return user, nil
 }
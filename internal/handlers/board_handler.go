@@ -3,6 +3,7 @@ package handlers
 import (
 	"fmt"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/bento-lab-ops/bentro/internal/database"
@@ -16,11 +17,13 @@ import (
 // CreateBoard creates a new retrospective board
 func CreateBoard(c *gin.Context) {
 	var input struct {
-		Name    string   `json:"name" binding:"required"`
-		Columns []string `json:"columns"`
-		Owner   string   `json:"owner"`
-		TeamID  string   `json:"team_id"`  // Legacy: single team
-		TeamIDs []string `json:"team_ids"` // New: multiple teams
+		Name      string   `json:"name" binding:"required"`
+		Columns   []string `json:"columns"`
+		TeamID    string   `json:"team_id"`  // Legacy: single team
+		TeamIDs   []string `json:"team_ids"` // New: multiple teams
+		Anonymity string   `json:"anonymity"`
+		// Voting mode, likes when empty
+		VotingMode string `json:"voting_mode"`
 	}
 
 	if err := c.ShouldBindJSON(&input); err != nil {
@@ -28,11 +31,36 @@ func CreateBoard(c *gin.Context) {
 		return
 	}
 
+	// The signed-in user owns the boards they create
+	user, ok := currentUser(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+		return
+	}
+	owner := displayName(user)
+
+	if input.Anonymity == "" {
+		input.Anonymity = AnonymityAnonymous
+	}
+	if !IsValidAnonymity(input.Anonymity) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid anonymity mode"})
+		return
+	}
+	if input.VotingMode == "" {
+		input.VotingMode = VotingModeLike
+	}
+	if !IsValidVotingMode(input.VotingMode) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid voting mode"})
+		return
+	}
+
 	// Create board
 	board := models.Board{
-		Name:   input.Name,
-		Status: "active",
-		Owner:  input.Owner,
+		Name:       input.Name,
+		Status:     "active",
+		Owner:      owner,
+		Anonymity:  input.Anonymity,
+		VotingMode: input.VotingMode,
 	}
 
 	// Handle Teams (Many-to-Many)
@@ -59,6 +87,18 @@ func CreateBoard(c *gin.Context) {
 		}
 
 		if len(validIDs) > 0 {
+			// Boards are only added to teams the creator belongs to
+			var memberships int64
+			if err := database.DB.Model(&models.TeamMember{}).
+				Where("team_id IN ? AND user_id = ?", validIDs, user.ID).
+				Count(&memberships).Error; err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check team membership"})
+				return
+			}
+			if int(memberships) != len(validIDs) {
+				c.JSON(http.StatusForbidden, gin.H{"error": "You can only add boards to teams you belong to"})
+				return
+			}
 			if err := database.DB.Where("id IN ?", validIDs).Find(&teams).Error; err != nil {
 				fmt.Printf("Warning: Failed to fetch teams for board creation: %v\n", err)
 			}
@@ -81,12 +121,12 @@ func CreateBoard(c *gin.Context) {
 	// Auto-join the creator as a member
 	member := models.BoardMember{
 		BoardID:  board.ID,
-		Username: input.Owner,
+		Username: owner,
 		JoinedAt: time.Now(),
 	}
 	if err := database.DB.Create(&member).Error; err != nil {
 		// Log error but don't fail the request, user can join manually
-		fmt.Printf("Failed to auto-join owner %s to board %s: %v\n", input.Owner, board.ID, err)
+		fmt.Printf("Failed to auto-join owner %s to board %s: %v\n", owner, board.ID, err)
 	}
 
 	// Create default columns if none provided
@@ -115,6 +155,8 @@ func CreateBoard(c *gin.Context) {
 	var newBoard models.Board
 	database.DB.Preload("Columns").First(&newBoard, board.ID)
 
+	BroadcastBoardListChanged(board.ID, "board_created")
+
 	c.JSON(http.StatusCreated, newBoard)
 }
 
@@ -140,6 +182,9 @@ func UpdateBoardStatus(c *gin.Context) {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Board not found"})
 		return
 	}
+	if _, ok := authorizeBoard(c, &board, BoardActionManage); !ok {
+		return
+	}
 
 	board.Status = input.Status
 
@@ -148,6 +193,10 @@ func UpdateBoardStatus(c *gin.Context) {
 	if input.Status == "finished" {
 		// Finish board
 		board.FinishedAt = &now
+		// The closing survey opens the first time a board is finished
+		if board.SurveyOpenedAt == nil {
+			board.SurveyOpenedAt = &now
+		}
 		// Do not overwrite persistent participants with active hub participants
 		// participants := hub.GetBoardParticipants(id.String())
 		// board.Participants = participants
@@ -157,11 +206,18 @@ func UpdateBoardStatus(c *gin.Context) {
 	}
 
 	// Use Select to force update of FinishedAt (even if nil)
-	if err := database.DB.Model(&board).Select("Status", "FinishedAt").Save(&board).Error; err != nil {
+	if err := database.DB.Model(&board).Select("Status", "FinishedAt", "SurveyOpenedAt").Save(&board).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update board status"})
 		return
 	}
 
+	BroadcastBoardSettingsChanged(board)
+	if input.Status == "finished" {
+		BroadcastBoardListChanged(board.ID, "board_finished")
+	} else {
+		BroadcastBoardListChanged(board.ID, "board_reopened")
+	}
+
 	c.JSON(http.StatusOK, board)
 }
 
@@ -177,6 +233,15 @@ func UpdateBoard(c *gin.Context) {
 		Name        string `json:"name"`
 		VoteLimit   *int   `json:"vote_limit"`   // Use pointer to distinguish 0 from nil
 		BlindVoting *bool  `json:"blind_voting"` // Use pointer to distinguish false from nil
+		AllowGuests *bool  `json:"allow_guests"`
+		Anonymity   string `json:"anonymity"`
+		// Shows the card authors of an until_reveal board
+		AuthorsRevealed *bool   `json:"authors_revealed"`
+		PrivateWriting  *bool   `json:"private_writing"`
+		VotingMode      string  `json:"voting_mode"`
+		SurveyQuestion  *string `json:"survey_question"` // Empty asks the default question
+		SurveyScale     *int    `json:"survey_scale"`
+		SurveyComments  *bool   `json:"survey_comments"`
 	}
 
 	if err := c.ShouldBindJSON(&input); err != nil {
@@ -189,6 +254,9 @@ func UpdateBoard(c *gin.Context) {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Board not found"})
 		return
 	}
+	if _, ok := authorizeBoard(c, &board, BoardActionManage); !ok {
+		return
+	}
 
 	// Update fields if present
 	if input.Name != "" {
@@ -200,14 +268,129 @@ func UpdateBoard(c *gin.Context) {
 	if input.BlindVoting != nil {
 		board.BlindVoting = *input.BlindVoting
 	}
+	if input.AllowGuests != nil {
+		board.AllowGuests = *input.AllowGuests
+	}
+	if input.Anonymity != "" && input.Anonymity != board.Anonymity {
+		if !IsValidAnonymity(input.Anonymity) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid anonymity mode"})
+			return
+		}
+		// Cards written on a fully anonymous board were written under a promise of anonymity
+		wasAnonymous := board.Anonymity == "" || board.Anonymity == AnonymityAnonymous
+		if wasAnonymous && input.Anonymity != AnonymityAnonymous && boardHasCards(board.ID) {
+			c.JSON(http.StatusConflict, gin.H{"error": "Cards on this board were written anonymously; their authors cannot be revealed"})
+			return
+		}
+		board.Anonymity = input.Anonymity
+	}
+	if input.AuthorsRevealed != nil {
+		board.AuthorsRevealed = *input.AuthorsRevealed
+	}
+	if input.VotingMode != "" && input.VotingMode != board.VotingMode {
+		if !IsValidVotingMode(input.VotingMode) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid voting mode"})
+			return
+		}
+		// Votes do not convert from one voting method to another
+		if boardHasVotes(board.ID) {
+			c.JSON(http.StatusConflict, gin.H{"error": "Votes were already cast on this board; its voting mode cannot change"})
+			return
+		}
+		board.VotingMode = input.VotingMode
+	}
+	if input.PrivateWriting != nil {
+		// Turning private writing on starts a new round of hidden cards
+		if *input.PrivateWriting && !board.PrivateWriting {
+			board.CardsRevealedAt = nil
+		}
+		board.PrivateWriting = *input.PrivateWriting
+	}
+	if input.SurveyScale != nil && !IsValidSurveyScale(*input.SurveyScale) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Survey scale must be between 2 and 10"})
+		return
+	}
+	// Answers were given to a question on a scale; changing either would mix them up
+	questionChanged := input.SurveyQuestion != nil && strings.TrimSpace(*input.SurveyQuestion) != board.SurveyQuestion
+	scaleChanged := input.SurveyScale != nil && *input.SurveyScale != surveyScale(&board)
+	if (questionChanged || scaleChanged) && board.Survey != nil && board.Survey.Responses > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "The survey was already answered; its question and scale cannot change"})
+		return
+	}
+	if questionChanged {
+		board.SurveyQuestion = strings.TrimSpace(*input.SurveyQuestion)
+	}
+	if scaleChanged {
+		board.SurveyScale = *input.SurveyScale
+	}
+	if input.SurveyComments != nil {
+		board.SurveyComments = *input.SurveyComments
+	}
 
 	if err := database.DB.Save(&board).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update board"})
 		return
 	}
 
-	// Broadcast update
-	BroadcastBoardUpdate(board.ID)
+	BroadcastBoardSettingsChanged(board)
+	BroadcastBoardListChanged(board.ID, "board_updated")
+
+	c.JSON(http.StatusOK, board)
+}
+
+// RevealCards discloses the cards of a private writing board to everyone (board managers only)
+func RevealCards(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid board ID"})
+		return
+	}
+
+	var board models.Board
+	if err := database.DB.First(&board, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Board not found"})
+		return
+	}
+	if _, ok := authorizeBoard(c, &board, BoardActionManage); !ok {
+		return
+	}
+	if !cardsHidden(&board) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Board has no hidden cards"})
+		return
+	}
+
+	// Only the first of concurrent reveals broadcasts the cards
+	now := time.Now()
+	result := database.DB.Model(&models.Board{}).
+		Where("id = ? AND cards_revealed_at IS NULL", board.ID).
+		Update("cards_revealed_at", now)
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reveal cards"})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "Board has no hidden cards"})
+		return
+	}
+	board.CardsRevealedAt = &now
+
+	var cards []models.Card
+	if err := database.DB.
+		Preload("Labels").
+		Joins("JOIN columns ON columns.id = cards.column_id").
+		Where("columns.board_id = ?", board.ID).
+		Find(&cards).Error; err != nil {
+		fmt.Printf("Warning: Failed to load cards of revealed board %s: %v\n", board.ID, err)
+	}
+	BroadcastCardsRevealed(board, cards)
+
+	groups, err := titleRevealedGroups(database.DB, board.ID)
+	if err != nil {
+		fmt.Printf("Warning: Failed to title groups of revealed board %s: %v\n", board.ID, err)
+	}
+	for _, group := range groups {
+		BroadcastGroupChanged(board.ID, group, "updated")
+	}
 
 	c.JSON(http.StatusOK, board)
 }
@@ -224,14 +407,18 @@ func GetBoard(c *gin.Context) {
 	if err := database.DB.
 		Preload("Columns.Cards.Votes").
 		Preload("Columns.Cards.Reactions").
-		Preload("Columns.Cards.MergedCards").
-		Preload("Columns.Cards.MergedCards.Votes").
+		Preload("Columns.Cards.Comments", orderCommentsByCreation).
+		Preload("Columns.Cards.Labels").
+		Preload("Columns.Groups", orderGroupsByPosition).
 		Preload("Members").
 		Preload("Teams").
 		First(&board, id).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Board not found"})
 		return
 	}
+	if labels, err := boardLabels(board.ID); err == nil {
+		board.Labels = labels
+	}
 
 	// Map members to participants
 	participants := make([]models.Participant, len(board.Members))
@@ -243,6 +430,10 @@ func GetBoard(c *gin.Context) {
 		}
 	}
 	board.Participants = participants
+	aggregateGroups(&board)
+	presentBoard(&board, resolveBoardAccess(c, &board).Username)
+	// Filter after presenting, so hidden cards never match on their labels
+	filterBoardByLabels(&board, c.QueryArray("label"))
 
 	c.JSON(http.StatusOK, board)
 }
@@ -342,12 +533,23 @@ func DeleteBoard(c *gin.Context) {
 		return
 	}
 
+	var board models.Board
+	if err := database.DB.First(&board, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Board not found"})
+		return
+	}
+	if _, ok := authorizeBoard(c, &board, BoardActionManage); !ok {
+		return
+	}
+
 	// Perform soft delete
 	if err := database.DB.Delete(&models.Board{}, id).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete board"})
 		return
 	}
 
+	BroadcastBoardListChanged(board.ID, "board_deleted")
+
 	c.JSON(http.StatusOK, gin.H{"message": "Board deleted successfully"})
 }
 
@@ -405,6 +607,19 @@ func ClaimBoard(c *gin.Context) {
 		return
 	}
 
+	// Managers must be signed in, allowed on the board, and claim for themselves
+	access := resolveBoardAccess(c, &board)
+	if access.Has(BoardRoleGuest) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+		return
+	}
+	if _, ok := authorizeBoard(c, &board, BoardActionJoin); !ok {
+		return
+	}
+	if !forbidImpersonation(c, access, input.Owner) {
+		return
+	}
+
 	// Dual Manager Logic
 	if board.Owner != "" {
 		if board.Owner == input.Owner {
@@ -459,6 +674,16 @@ func UnclaimBoard(c *gin.Context) {
 		return
 	}
 
+	// Managers can step down themselves or remove the other manager
+	access := resolveBoardAccess(c, &board)
+	if access.Has(BoardRoleGuest) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+		return
+	}
+	if !forbidImpersonation(c, access, input.User) {
+		return
+	}
+
 	if board.Owner == input.User {
 		// Owner is leaving
 		if board.CoOwner != "" {
@@ -514,6 +739,10 @@ func JoinBoard(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Cannot join a finished board"})
 		return
 	}
+	access, ok := authorizeBoard(c, &board, BoardActionJoin)
+	if !ok {
+		return
+	}
 
 	// Create BoardMember entry
 	// Using Clause(clause.OnConflict{DoNothing: true}) to handle duplicates gracefully (race condition safe)
@@ -524,10 +753,36 @@ func JoinBoard(c *gin.Context) {
 		JoinedAt: time.Now(),
 	}
 
+	// Guests are issued a token binding them to the name they join with
+	if access.Has(BoardRoleGuest) {
+		if isRegisteredName(input.Username) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "You cannot act on behalf of another user"})
+			return
+		}
+		key, err := issueGuestKey(c)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to join board"})
+			return
+		}
+		member.GuestKey = key
+	} else if !forbidImpersonation(c, access, input.Username) {
+		return
+	}
+
 	if err := database.DB.Clauses(clause.OnConflict{DoNothing: true}).Create(&member).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to join board"})
 		return
 	}
+	if member.GuestKey != "" {
+		// Names joined before guest tokens existed go to the first guest claiming them
+		database.DB.Model(&models.BoardMember{}).
+			Where("board_id = ? AND username = ? AND guest_key = ''", id, input.Username).
+			Update("guest_key", member.GuestKey)
+		if !guestHoldsName(id, input.Username, member.GuestKey) {
+			c.JSON(http.StatusConflict, gin.H{"error": "Another guest already joined under this name"})
+			return
+		}
+	}
 
 	// Log for debug
 	fmt.Printf("[JoinBoard] User %s joined board %s.\n", input.Username, id)
@@ -583,6 +838,9 @@ func LeaveBoard(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Cannot leave a finished board"})
 		return
 	}
+	if !forbidImpersonation(c, resolveBoardAccess(c, &board), input.Username) {
+		return
+	}
 
 	// Delete BoardMember
 	if err := database.DB.Where("board_id = ? AND username = ?", id, input.Username).Delete(&models.BoardMember{}).Error; err != nil {
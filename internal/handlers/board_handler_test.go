@@ -32,7 +32,15 @@ func setupBoardTest(t *testing.T) (*gorm.DB, *gin.Engine) {
 		&models.Board{},
 		&models.Column{},
 		&models.Card{},
+		&models.Vote{},
+		&models.BoardVoter{},
+		&models.CardComment{},
+		&models.CardRevision{},
+		&models.Label{},
+		&models.CardLabel{},
+		&models.CardGroup{},
 		&models.Team{},
+		&models.TeamMember{},
 		&models.BoardMember{},
 	)
 	if err != nil {
@@ -43,6 +51,7 @@ func setupBoardTest(t *testing.T) (*gorm.DB, *gin.Engine) {
 
 	gin.SetMode(gin.TestMode)
 	r := gin.Default()
+	r.Use(simulateAuth())
 
 	// Register routes needed for testing
 	r.POST("/boards", CreateBoard)
@@ -67,15 +76,22 @@ func setupBoardTest(t *testing.T) (*gorm.DB, *gin.Engine) {
 func TestCreateBoard(t *testing.T) {
 	db, r := setupBoardTest(t)
 
-	// Happy Path
+	alice := createTestUser(db, "alice", "user")
+	bob := createTestUser(db, "bob", "user")
+
+	// Boards are created by signed-in users, who own them whatever the body says
 	input := map[string]interface{}{
 		"name":  "Retrospective 1",
-		"owner": "alice",
+		"owner": "bob",
 	}
 	body, _ := json.Marshal(input)
 	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("POST", "/boards", bytes.NewBuffer(body)))
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	w = httptest.NewRecorder()
 	req, _ := http.NewRequest("POST", "/boards", bytes.NewBuffer(body))
-	r.ServeHTTP(w, req)
+	r.ServeHTTP(w, asUser(req, alice))
 
 	assert.Equal(t, http.StatusCreated, w.Code)
 
@@ -105,14 +121,20 @@ func TestCreateBoard(t *testing.T) {
 
 	input2 := map[string]interface{}{
 		"name":     "Custom Board",
-		"owner":    "bob",
 		"columns":  []string{"Start", "Stop", "Continue"},
 		"team_ids": []string{team.ID.String()},
 	}
 	body2, _ := json.Marshal(input2)
+
+	// Only members add boards to a team
 	w2 := httptest.NewRecorder()
+	r.ServeHTTP(w2, asUser(httptest.NewRequest("POST", "/boards", bytes.NewBuffer(body2)), bob))
+	assert.Equal(t, http.StatusForbidden, w2.Code)
+
+	db.Create(&models.TeamMember{TeamID: team.ID, UserID: bob.ID})
+	w2 = httptest.NewRecorder()
 	req2, _ := http.NewRequest("POST", "/boards", bytes.NewBuffer(body2))
-	r.ServeHTTP(w2, req2)
+	r.ServeHTTP(w2, asUser(req2, bob))
 
 	assert.Equal(t, http.StatusCreated, w2.Code)
 	var response2 models.Board
@@ -150,7 +172,8 @@ func TestGetBoard(t *testing.T) {
 
 func TestUpdateBoardStatus(t *testing.T) {
 	db, r := setupBoardTest(t)
-	board := models.Board{ID: uuid.New(), Name: "Status Board", Status: "active"}
+	owner := createTestUser(db, "alice", "user")
+	board := models.Board{ID: uuid.New(), Name: "Status Board", Status: "active", Owner: "alice"}
 	db.Create(&board)
 
 	// Finish Board
@@ -158,7 +181,7 @@ func TestUpdateBoardStatus(t *testing.T) {
 	body, _ := json.Marshal(input)
 	w := httptest.NewRecorder()
 	req, _ := http.NewRequest("PUT", "/boards/"+board.ID.String()+"/status", bytes.NewBuffer(body))
-	r.ServeHTTP(w, req)
+	r.ServeHTTP(w, asUser(req, owner))
 
 	assert.Equal(t, http.StatusOK, w.Code)
 	var updated models.Board
@@ -171,7 +194,7 @@ func TestUpdateBoardStatus(t *testing.T) {
 	body2, _ := json.Marshal(input2)
 	w2 := httptest.NewRecorder()
 	req2, _ := http.NewRequest("PUT", "/boards/"+board.ID.String()+"/status", bytes.NewBuffer(body2))
-	r.ServeHTTP(w2, req2)
+	r.ServeHTTP(w2, asUser(req2, owner))
 
 	assert.Equal(t, http.StatusOK, w2.Code)
 	var reopened models.Board
@@ -182,7 +205,8 @@ func TestUpdateBoardStatus(t *testing.T) {
 
 func TestUpdateBoardSettings(t *testing.T) {
 	db, r := setupBoardTest(t)
-	board := models.Board{ID: uuid.New(), Name: "Old Name", VoteLimit: 5, BlindVoting: false}
+	owner := createTestUser(db, "alice", "user")
+	board := models.Board{ID: uuid.New(), Name: "Old Name", VoteLimit: 5, BlindVoting: false, Owner: "alice"}
 	db.Create(&board)
 
 	limit := 10
@@ -195,7 +219,7 @@ func TestUpdateBoardSettings(t *testing.T) {
 	body, _ := json.Marshal(input)
 	w := httptest.NewRecorder()
 	req, _ := http.NewRequest("PUT", "/boards/"+board.ID.String(), bytes.NewBuffer(body))
-	r.ServeHTTP(w, req)
+	r.ServeHTTP(w, asUser(req, owner))
 
 	assert.Equal(t, http.StatusOK, w.Code)
 	var updated models.Board
@@ -203,10 +227,29 @@ func TestUpdateBoardSettings(t *testing.T) {
 	assert.Equal(t, "New Name", updated.Name)
 	assert.Equal(t, 10, updated.VoteLimit)
 	assert.True(t, updated.BlindVoting)
+
+	// The voting mode only changes while nobody voted
+	send := func(input map[string]interface{}) int {
+		body, _ := json.Marshal(input)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, asUser(httptest.NewRequest("PUT", "/boards/"+board.ID.String(), bytes.NewBuffer(body)), owner))
+		return w.Code
+	}
+	assert.Equal(t, http.StatusBadRequest, send(map[string]interface{}{"voting_mode": "approval"}))
+	assert.Equal(t, http.StatusOK, send(map[string]interface{}{"voting_mode": VotingModeDot}))
+	column := models.Column{ID: uuid.New(), BoardID: board.ID, Name: "Col"}
+	db.Create(&column)
+	card := models.Card{ID: uuid.New(), ColumnID: column.ID, Content: "Card"}
+	db.Create(&card)
+	db.Create(&models.Vote{CardID: card.ID, UserName: "alice", VoteType: "like", Weight: 2})
+	assert.Equal(t, http.StatusConflict, send(map[string]interface{}{"voting_mode": VotingModeLike}))
+	db.First(&updated, board.ID)
+	assert.Equal(t, VotingModeDot, updated.VotingMode)
 }
 
 func TestJoinAndLeaveBoard(t *testing.T) {
 	db, r := setupBoardTest(t)
+	user1 := createTestUser(db, "user1", "user")
 	board := models.Board{ID: uuid.New(), Name: "Community Board", Status: "active"}
 	db.Create(&board)
 
@@ -215,7 +258,7 @@ func TestJoinAndLeaveBoard(t *testing.T) {
 	body, _ := json.Marshal(input)
 	w := httptest.NewRecorder()
 	req, _ := http.NewRequest("POST", "/boards/"+board.ID.String()+"/join", bytes.NewBuffer(body))
-	r.ServeHTTP(w, req)
+	r.ServeHTTP(w, asUser(req, user1))
 	assert.Equal(t, http.StatusOK, w.Code)
 
 	var count int64
@@ -227,7 +270,7 @@ func TestJoinAndLeaveBoard(t *testing.T) {
 	leaveBody, _ := json.Marshal(leaveInput)
 	w2 := httptest.NewRecorder()
 	req2, _ := http.NewRequest("POST", "/boards/"+board.ID.String()+"/leave", bytes.NewBuffer(leaveBody))
-	r.ServeHTTP(w2, req2)
+	r.ServeHTTP(w2, asUser(req2, user1))
 	assert.Equal(t, http.StatusOK, w2.Code)
 
 	db.Model(&models.BoardMember{}).Where("board_id = ?", board.ID).Count(&count)
@@ -239,12 +282,14 @@ func TestJoinAndLeaveBoard(t *testing.T) {
 
 	w3 := httptest.NewRecorder()
 	req3, _ := http.NewRequest("POST", "/boards/"+board.ID.String()+"/join", bytes.NewBuffer(body))
-	r.ServeHTTP(w3, req3)
+	r.ServeHTTP(w3, asUser(req3, user1))
 	assert.Equal(t, http.StatusBadRequest, w3.Code)
 }
 
 func TestClaimAndUnclaimBoard(t *testing.T) {
 	db, r := setupBoardTest(t)
+	manager1 := createTestUser(db, "manager1", "user")
+	manager2 := createTestUser(db, "manager2", "user")
 	board := models.Board{ID: uuid.New(), Name: "Orphan Board"}
 	db.Create(&board)
 
@@ -253,7 +298,7 @@ func TestClaimAndUnclaimBoard(t *testing.T) {
 	body, _ := json.Marshal(input)
 	w := httptest.NewRecorder()
 	req, _ := http.NewRequest("POST", "/boards/"+board.ID.String()+"/claim", bytes.NewBuffer(body))
-	r.ServeHTTP(w, req)
+	r.ServeHTTP(w, asUser(req, manager1))
 	assert.Equal(t, http.StatusOK, w.Code)
 
 	var b models.Board
@@ -265,7 +310,7 @@ func TestClaimAndUnclaimBoard(t *testing.T) {
 	body2, _ := json.Marshal(input2)
 	w2 := httptest.NewRecorder()
 	req2, _ := http.NewRequest("POST", "/boards/"+board.ID.String()+"/claim", bytes.NewBuffer(body2))
-	r.ServeHTTP(w2, req2)
+	r.ServeHTTP(w2, asUser(req2, manager2))
 	assert.Equal(t, http.StatusOK, w2.Code)
 
 	db.First(&b, board.ID)
@@ -276,7 +321,7 @@ func TestClaimAndUnclaimBoard(t *testing.T) {
 	body3, _ := json.Marshal(unclaim)
 	w3 := httptest.NewRecorder()
 	req3, _ := http.NewRequest("POST", "/boards/"+board.ID.String()+"/unclaim", bytes.NewBuffer(body3))
-	r.ServeHTTP(w3, req3)
+	r.ServeHTTP(w3, asUser(req3, manager1))
 	assert.Equal(t, http.StatusOK, w3.Code)
 
 	db.First(&b, board.ID)
@@ -287,12 +332,13 @@ func TestClaimAndUnclaimBoard(t *testing.T) {
 
 func TestDeleteBoard(t *testing.T) {
 	db, r := setupBoardTest(t)
-	board := models.Board{ID: uuid.New(), Name: "Temp Board"}
+	owner := createTestUser(db, "alice", "user")
+	board := models.Board{ID: uuid.New(), Name: "Temp Board", Owner: "alice"}
 	db.Create(&board)
 
 	w := httptest.NewRecorder()
 	req, _ := http.NewRequest("DELETE", "/boards/"+board.ID.String(), nil)
-	r.ServeHTTP(w, req)
+	r.ServeHTTP(w, asUser(req, owner))
 
 	assert.Equal(t, http.StatusOK, w.Code)
 
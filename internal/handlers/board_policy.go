@@ -0,0 +1,275 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+
+	"github.com/bento-lab-ops/bentro/internal/database"
+	"github.com/bento-lab-ops/bentro/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// BoardRole is a relationship between a caller and a board
+type BoardRole string
+
+const (
+	BoardRoleGuest       BoardRole = "guest"        // Unauthenticated caller
+	BoardRoleParticipant BoardRole = "participant"  // Joined the board (BoardMember)
+	BoardRoleTeamMember  BoardRole = "team_member"  // Member of one of the board's teams
+	BoardRoleCoOwner     BoardRole = "co_owner"     // Second board manager
+	BoardRoleOwner       BoardRole = "owner"        // Board manager
+	BoardRoleSystemAdmin BoardRole = "system_admin" // User with the admin role
+)
+
+// BoardAction is an operation guarded by the board policy
+type BoardAction int
+
+const (
+	// BoardActionJoin covers becoming a participant of the board
+	BoardActionJoin BoardAction = iota
+	// BoardActionContribute covers cards, votes and reactions
+	BoardActionContribute
+	// BoardActionManage covers board settings, status, teams and columns
+	BoardActionManage
+)
+
+// guestCookie carries the token a guest is issued when joining a board. The
+// board membership stores its hash, tying the guest to the name it joined with.
+const guestCookie = "guest_token"
+
+// BoardAccess is the resolved set of roles a caller holds on a board
+type BoardAccess struct {
+	User     *models.User // nil for guests
+	Username string       // Display name of the authenticated user, empty for guests
+	Roles    map[BoardRole]bool
+	board    *models.Board
+	guestKey string // Hash of the guest token, empty for users and guests without one
+}
+
+// Has reports whether the caller holds the role
+func (a BoardAccess) Has(role BoardRole) bool {
+	return a.Roles[role]
+}
+
+// IsManager reports whether the caller can manage the board (owner, co-owner or system admin)
+func (a BoardAccess) IsManager() bool {
+	return a.Has(BoardRoleOwner) || a.Has(BoardRoleCoOwner) || a.Has(BoardRoleSystemAdmin)
+}
+
+// Can reports whether the caller may perform the action on the board
+func (a BoardAccess) Can(action BoardAction) bool {
+	if a.IsManager() {
+		return true
+	}
+	if a.Has(BoardRoleGuest) {
+		// Guests only take part in boards that explicitly allow them
+		return action != BoardActionManage && a.board.AllowGuests
+	}
+
+	switch action {
+	case BoardActionJoin:
+		// Boards without teams are open to every signed-in user
+		return a.Has(BoardRoleTeamMember) || a.Has(BoardRoleParticipant) || !boardHasTeams(a.board.ID)
+	case BoardActionContribute:
+		return a.Has(BoardRoleTeamMember) || a.Has(BoardRoleParticipant)
+	}
+	return false
+}
+
+// ActsAs reports whether a username supplied in a request body belongs to the caller.
+// Guests act under the name they joined the board with, unless it belongs to a
+// registered user; managers may act on behalf of others.
+func (a BoardAccess) ActsAs(username string) bool {
+	if a.Has(BoardRoleGuest) {
+		return username != "" && !isRegisteredName(username) && guestHoldsName(a.board.ID, username, a.guestKey)
+	}
+	return a.IsManager() || username == a.Username
+}
+
+// CanChangeCard reports whether the caller may edit or delete a card: its author
+// or a board manager. Cards written by guests have no author.
+func (a BoardAccess) CanChangeCard(card *models.Card) bool {
+	return a.IsManager() || (a.Username != "" && card.Author == a.Username)
+}
+
+// isRegisteredName reports whether a name belongs to a registered user, which
+// guests may not go by
+func isRegisteredName(name string) bool {
+	var count int64
+	database.DB.Model(&models.User{}).Where("display_name = ? OR name = ?", name, name).Count(&count)
+	return count > 0
+}
+
+// guestHoldsName reports whether the guest behind the key joined the board under the name
+func guestHoldsName(boardID uuid.UUID, name, key string) bool {
+	if key == "" {
+		return false
+	}
+	var count int64
+	database.DB.Model(&models.BoardMember{}).
+		Where("board_id = ? AND username = ? AND guest_key = ?", boardID, name, key).
+		Count(&count)
+	return count > 0
+}
+
+// guestKey returns the hash of the caller's guest token, empty when it has none
+func guestKey(c *gin.Context) string {
+	token, err := c.Cookie(guestCookie)
+	if err != nil || token == "" {
+		return ""
+	}
+	return hashGuestToken(token)
+}
+
+// issueGuestKey returns the caller's guest key, setting a new guest token cookie
+// when the caller has none yet
+func issueGuestKey(c *gin.Context) (string, error) {
+	if key := guestKey(c); key != "" {
+		return key, nil
+	}
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	token := hex.EncodeToString(buf)
+	c.SetCookie(guestCookie, token, 3600*24*15, "/", "", false, true)
+	return hashGuestToken(token), nil
+}
+
+// hashGuestToken returns the form of a guest token stored on board memberships
+func hashGuestToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// displayName returns the name a user appears under on boards
+func displayName(user models.User) string {
+	if user.DisplayName != "" {
+		return user.DisplayName
+	}
+	return user.Name
+}
+
+// currentUser returns the user set by AuthMiddleware, if any
+func currentUser(c *gin.Context) (models.User, bool) {
+	userObj, exists := c.Get("user")
+	if !exists {
+		return models.User{}, false
+	}
+	user, ok := userObj.(models.User)
+	return user, ok
+}
+
+// resolveBoardAccess computes the caller's roles on a board
+func resolveBoardAccess(c *gin.Context, board *models.Board) BoardAccess {
+	user, ok := currentUser(c)
+	if !ok {
+		access := boardAccessFor(nil, board)
+		access.guestKey = guestKey(c)
+		return access
+	}
+	return boardAccessFor(&user, board)
+}
+
+// boardAccessFor computes a user's roles on a board; a nil user is a guest
+func boardAccessFor(user *models.User, board *models.Board) BoardAccess {
+	access := BoardAccess{Roles: make(map[BoardRole]bool), board: board}
+	if user == nil {
+		access.Roles[BoardRoleGuest] = true
+		return access
+	}
+
+	access.User = user
+	access.Username = displayName(*user)
+
+	if user.Role == "admin" {
+		access.Roles[BoardRoleSystemAdmin] = true
+	}
+	// Owner/CoOwner store usernames; accept either name form as UpdateBoardTeams always did
+	if board.Owner != "" && (board.Owner == user.DisplayName || board.Owner == user.Name) {
+		access.Roles[BoardRoleOwner] = true
+	}
+	if board.CoOwner != "" && (board.CoOwner == user.DisplayName || board.CoOwner == user.Name) {
+		access.Roles[BoardRoleCoOwner] = true
+	}
+
+	var count int64
+	database.DB.Model(&models.BoardMember{}).
+		Where("board_id = ? AND username = ?", board.ID, access.Username).
+		Count(&count)
+	if count > 0 {
+		access.Roles[BoardRoleParticipant] = true
+	}
+
+	count = 0
+	database.DB.Table("team_members").
+		Joins("JOIN board_teams ON board_teams.team_id = team_members.team_id").
+		Where("board_teams.board_id = ? AND team_members.user_id = ?", board.ID, user.ID).
+		Count(&count)
+	if count > 0 {
+		access.Roles[BoardRoleTeamMember] = true
+	}
+
+	return access
+}
+
+// boardHasTeams reports whether a board is linked to any team
+func boardHasTeams(boardID uuid.UUID) bool {
+	var count int64
+	database.DB.Table("board_teams").Where("board_id = ?", boardID).Count(&count)
+	return count > 0
+}
+
+// authorizeBoard checks the caller may perform the action on the board.
+// It writes a 403 response and returns false when the action is denied.
+func authorizeBoard(c *gin.Context, board *models.Board, action BoardAction) (BoardAccess, bool) {
+	access := resolveBoardAccess(c, board)
+	if access.Can(action) {
+		return access, true
+	}
+
+	message := "You do not have access to this board"
+	switch action {
+	case BoardActionContribute:
+		message = "Only board participants can modify this board"
+	case BoardActionManage:
+		message = "Only board managers can perform this action"
+	}
+	c.JSON(http.StatusForbidden, gin.H{"error": message})
+	return access, false
+}
+
+// forbidImpersonation writes a 403 response and returns false when a request body
+// names a user other than the caller
+func forbidImpersonation(c *gin.Context, access BoardAccess, username string) bool {
+	if access.ActsAs(username) {
+		return true
+	}
+	c.JSON(http.StatusForbidden, gin.H{"error": "You cannot act on behalf of another user"})
+	return false
+}
+
+// boardForColumn loads the board that owns a column
+func boardForColumn(columnID uuid.UUID) (models.Board, error) {
+	var board models.Board
+	err := database.DB.
+		Joins("JOIN columns ON columns.board_id = boards.id").
+		Where("columns.id = ?", columnID).
+		First(&board).Error
+	return board, err
+}
+
+// boardForCard loads the board that owns a card
+func boardForCard(cardID uuid.UUID) (models.Board, error) {
+	var board models.Board
+	err := database.DB.
+		Joins("JOIN columns ON columns.board_id = boards.id").
+		Joins("JOIN cards ON cards.column_id = columns.id").
+		Where("cards.id = ?", cardID).
+		First(&board).Error
+	return board, err
+}
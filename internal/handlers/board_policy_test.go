@@ -0,0 +1,317 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/bento-lab-ops/bentro/internal/database"
+	"github.com/bento-lab-ops/bentro/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+// Auth Middleware Simulation: loads the user named by the X-User-ID header
+func simulateAuth() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if userID := c.GetHeader("X-User-ID"); userID != "" {
+			var user models.User
+			if err := database.DB.First(&user, "id = ?", userID).Error; err == nil {
+				c.Set("user", user)
+				c.Set("user_id", user.ID)
+				c.Set("user_role", user.Role)
+			}
+		}
+		c.Next()
+	}
+}
+
+// Helper to create a user that boards refer to by display name
+func createTestUser(db *gorm.DB, displayName, role string) models.User {
+	user := models.User{ID: uuid.New(), DisplayName: displayName, Email: displayName + "@test.com", Role: role}
+	db.Create(&user)
+	return user
+}
+
+// Helper to send a request as a given user
+func asUser(req *http.Request, user models.User) *http.Request {
+	req.Header.Set("X-User-ID", user.ID.String())
+	return req
+}
+
+// Helper to send a request as the guest holding a guest token
+func asGuest(req *http.Request, token *http.Cookie) *http.Request {
+	if token != nil {
+		req.AddCookie(token)
+	}
+	return req
+}
+
+// Helper to read the guest token a response issued
+func issuedGuestToken(w *httptest.ResponseRecorder) *http.Cookie {
+	for _, cookie := range w.Result().Cookies() {
+		if cookie.Name == guestCookie {
+			return cookie
+		}
+	}
+	return nil
+}
+
+func setupPolicyTest(t *testing.T) (*gorm.DB, *gin.Engine) {
+	db, r := setupBoardTest(t)
+	db.AutoMigrate(&models.Vote{}, &models.Reaction{}, &models.TeamMember{})
+
+	r.POST("/boards/:id/columns", CreateColumn)
+	r.DELETE("/columns/:id", DeleteColumn)
+	r.POST("/columns/:columnId/cards", CreateCard)
+	r.DELETE("/cards/:id", DeleteCard)
+	r.POST("/cards/:id/votes", AddVote)
+	r.DELETE("/votes/:id", DeleteVote)
+	return db, r
+}
+
+func TestBoardPolicyManageActions(t *testing.T) {
+	db, r := setupPolicyTest(t)
+	owner := createTestUser(db, "owner", "user")
+	stranger := createTestUser(db, "stranger", "user")
+	admin := createTestUser(db, "root", "admin")
+
+	board := models.Board{ID: uuid.New(), Name: "Guarded", Owner: "owner", Status: "active"}
+	db.Create(&board)
+	col := models.Column{ID: uuid.New(), BoardID: board.ID, Name: "Col"}
+	db.Create(&col)
+
+	body, _ := json.Marshal(map[string]string{"name": "Hijacked"})
+
+	// Guests and strangers cannot manage the board
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("PUT", "/boards/"+board.ID.String(), bytes.NewBuffer(body)))
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, asUser(httptest.NewRequest("DELETE", "/boards/"+board.ID.String(), nil), stranger))
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, asUser(httptest.NewRequest("POST", "/boards/"+board.ID.String()+"/columns", bytes.NewBuffer(body)), stranger))
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, asUser(httptest.NewRequest("DELETE", "/columns/"+col.ID.String(), nil), stranger))
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	// The owner can
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, asUser(httptest.NewRequest("PUT", "/boards/"+board.ID.String(), bytes.NewBuffer(body)), owner))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	// And so can a system admin
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, asUser(httptest.NewRequest("DELETE", "/boards/"+board.ID.String(), nil), admin))
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestBoardPolicyContributeActions(t *testing.T) {
+	db, r := setupPolicyTest(t)
+	r.PUT("/cards/:id", UpdateCard)
+	owner := createTestUser(db, "owner", "user")
+	member := createTestUser(db, "member", "user")
+	participant := createTestUser(db, "participant", "user")
+	stranger := createTestUser(db, "stranger", "user")
+
+	team := models.Team{ID: uuid.New(), Name: "Alpha", OwnerID: member.ID}
+	db.Create(&team)
+	db.Create(&models.TeamMember{TeamID: team.ID, UserID: member.ID, Role: "member"})
+
+	board := models.Board{ID: uuid.New(), Name: "Team Board", Owner: "owner", Status: "active", Phase: "input", Teams: []models.Team{team}}
+	db.Create(&board)
+	db.Create(&models.BoardMember{BoardID: board.ID, Username: "participant"})
+	col := models.Column{ID: uuid.New(), BoardID: board.ID, Name: "Col"}
+	db.Create(&col)
+
+	cardBody, _ := json.Marshal(map[string]string{"content": "Idea"})
+
+	// Team members and participants can add cards
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, asUser(httptest.NewRequest("POST", "/columns/"+col.ID.String()+"/cards", bytes.NewBuffer(cardBody)), member))
+	assert.Equal(t, http.StatusCreated, w.Code)
+	var card models.Card
+	json.Unmarshal(w.Body.Bytes(), &card)
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, asUser(httptest.NewRequest("POST", "/columns/"+col.ID.String()+"/cards", bytes.NewBuffer(cardBody)), participant))
+	assert.Equal(t, http.StatusCreated, w.Code)
+
+	// Outsiders cannot, nor can they join a team board
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, asUser(httptest.NewRequest("POST", "/columns/"+col.ID.String()+"/cards", bytes.NewBuffer(cardBody)), stranger))
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, asUser(httptest.NewRequest("DELETE", "/cards/"+card.ID.String(), nil), stranger))
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	joinBody, _ := json.Marshal(map[string]string{"username": "stranger"})
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, asUser(httptest.NewRequest("POST", "/boards/"+board.ID.String()+"/join", bytes.NewBuffer(joinBody)), stranger))
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	// Cards are edited and deleted by their author or a board manager
+	editBody, _ := json.Marshal(map[string]string{"content": "Rewritten"})
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, asUser(httptest.NewRequest("PUT", "/cards/"+card.ID.String(), bytes.NewBuffer(editBody)), participant))
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, asUser(httptest.NewRequest("DELETE", "/cards/"+card.ID.String(), nil), participant))
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, asUser(httptest.NewRequest("PUT", "/cards/"+card.ID.String(), bytes.NewBuffer(editBody)), member))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	// Whoever owns an action item records its progress, and nothing else
+	db.Model(&card).Updates(map[string]interface{}{"is_action_item": true, "owner": "participant"})
+	doneBody, _ := json.Marshal(map[string]bool{"completed": true})
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, asUser(httptest.NewRequest("PUT", "/cards/"+card.ID.String(), bytes.NewBuffer(doneBody)), participant))
+	assert.Equal(t, http.StatusOK, w.Code)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, asUser(httptest.NewRequest("PUT", "/cards/"+card.ID.String(), bytes.NewBuffer(editBody)), participant))
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	other := models.Card{ID: uuid.New(), ColumnID: col.ID, Content: "Spare", Author: "participant"}
+	db.Create(&other)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, asUser(httptest.NewRequest("DELETE", "/cards/"+other.ID.String(), nil), owner))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	// Contributors cannot manage the board
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, asUser(httptest.NewRequest("DELETE", "/boards/"+board.ID.String(), nil), member))
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	// Votes cannot be cast or removed on behalf of someone else
+	db.Model(&board).Update("phase", "voting")
+	spoofBody, _ := json.Marshal(map[string]string{"user_name": "participant", "vote_type": "like"})
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, asUser(httptest.NewRequest("POST", "/cards/"+card.ID.String()+"/votes", bytes.NewBuffer(spoofBody)), member))
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	vote := models.Vote{ID: uuid.New(), CardID: card.ID, UserName: "participant", VoteType: "like"}
+	db.Create(&vote)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, asUser(httptest.NewRequest("DELETE", "/votes/"+vote.ID.String(), nil), member))
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, asUser(httptest.NewRequest("DELETE", "/votes/"+vote.ID.String(), nil), participant))
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestBoardPolicyGuests(t *testing.T) {
+	db, r := setupPolicyTest(t)
+	r.GET("/boards/:id/votes/remaining", GetRemainingVotes)
+	r.POST("/cards/:id/reactions", ToggleReaction)
+
+	closed := models.Board{ID: uuid.New(), Name: "Closed", Status: "active"}
+	open := models.Board{ID: uuid.New(), Name: "Open", Status: "active", AllowGuests: true}
+	db.Create(&closed)
+	db.Create(&open)
+	closedCol := models.Column{ID: uuid.New(), BoardID: closed.ID, Name: "Col"}
+	openCol := models.Column{ID: uuid.New(), BoardID: open.ID, Name: "Col"}
+	db.Create(&closedCol)
+	db.Create(&openCol)
+
+	cardBody, _ := json.Marshal(map[string]string{"content": "Anonymous idea"})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("POST", "/columns/"+closedCol.ID.String()+"/cards", bytes.NewBuffer(cardBody)))
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("POST", "/columns/"+openCol.ID.String()+"/cards", bytes.NewBuffer(cardBody)))
+	assert.Equal(t, http.StatusCreated, w.Code)
+	var card models.Card
+	json.Unmarshal(w.Body.Bytes(), &card)
+
+	// Guests join under their own name, but not a registered user's
+	createTestUser(db, "alice", "user")
+	joinPath := "/boards/" + open.ID.String() + "/join"
+	aliceBody, _ := json.Marshal(map[string]string{"username": "alice"})
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("POST", joinPath, bytes.NewBuffer(aliceBody)))
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	visitorBody, _ := json.Marshal(map[string]string{"username": "visitor"})
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("POST", joinPath, bytes.NewBuffer(visitorBody)))
+	assert.Equal(t, http.StatusOK, w.Code)
+	visitor := issuedGuestToken(w)
+	assert.NotNil(t, visitor)
+
+	// Joining again keeps the name; another guest cannot take it over
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, asGuest(httptest.NewRequest("POST", joinPath, bytes.NewBuffer(visitorBody)), visitor))
+	assert.Equal(t, http.StatusOK, w.Code)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("POST", joinPath, bytes.NewBuffer(visitorBody)))
+	assert.Equal(t, http.StatusConflict, w.Code)
+	intruder := issuedGuestToken(w)
+	assert.NotNil(t, intruder)
+
+	// Guests vote and react only under the name their token joined with
+	db.Model(&open).Updates(map[string]interface{}{"phase": "voting", "vote_limit": 3})
+	votePath := "/cards/" + card.ID.String() + "/votes"
+	aliceVote, _ := json.Marshal(map[string]string{"user_name": "alice", "vote_type": "like"})
+	visitorVote, _ := json.Marshal(map[string]string{"user_name": "visitor", "vote_type": "like"})
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, asGuest(httptest.NewRequest("POST", votePath, bytes.NewBuffer(aliceVote)), visitor))
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	for _, token := range []*http.Cookie{nil, intruder} {
+		w = httptest.NewRecorder()
+		r.ServeHTTP(w, asGuest(httptest.NewRequest("POST", votePath, bytes.NewBuffer(visitorVote)), token))
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	}
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, asGuest(httptest.NewRequest("POST", votePath, bytes.NewBuffer(visitorVote)), visitor))
+	assert.Equal(t, http.StatusCreated, w.Code)
+
+	reactionPath := "/cards/" + card.ID.String() + "/reactions"
+	reactionBody, _ := json.Marshal(map[string]string{"user_name": "visitor", "reaction_type": "idea"})
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, asGuest(httptest.NewRequest("POST", reactionPath, bytes.NewBuffer(reactionBody)), intruder))
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, asGuest(httptest.NewRequest("POST", reactionPath, bytes.NewBuffer(reactionBody)), visitor))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	// ...and see the budget of that name
+	remainingPath := "/boards/" + open.ID.String() + "/votes/remaining"
+	for query, code := range map[string]int{"": http.StatusBadRequest, "?user_name=alice": http.StatusForbidden} {
+		w = httptest.NewRecorder()
+		r.ServeHTTP(w, asGuest(httptest.NewRequest("GET", remainingPath+query, nil), visitor))
+		assert.Equal(t, code, w.Code, query)
+	}
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, asGuest(httptest.NewRequest("GET", remainingPath+"?user_name=visitor", nil), visitor))
+	assert.Equal(t, http.StatusOK, w.Code)
+	var remaining models.RemainingVotes
+	json.Unmarshal(w.Body.Bytes(), &remaining)
+	assert.Equal(t, models.VoteBudget{Limit: 3, Used: 1, Remaining: 2}, remaining.Board)
+
+	// A guest's card has no author, so only managers change it
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("DELETE", "/cards/"+card.ID.String(), nil))
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	// Guests never manage, even on open boards
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("DELETE", "/boards/"+open.ID.String(), nil))
+	assert.Equal(t, http.StatusForbidden, w.Code)
+}
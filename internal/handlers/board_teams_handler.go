@@ -34,20 +34,13 @@ func UpdateBoardTeams(c *gin.Context) {
 	}
 
 	// Get current user from context (set by AuthMiddleware)
-	userObj, exists := c.Get("user")
-	if !exists {
+	if _, exists := currentUser(c); !exists {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
 		return
 	}
-	user := userObj.(models.User)
 
 	// Check permissions (Owner, Co-Owner, or Admin)
-	isOwner := board.Owner == user.DisplayName || board.Owner == user.Name
-	isCoOwner := board.CoOwner != "" && (board.CoOwner == user.DisplayName || board.CoOwner == user.Name)
-	isAdmin := user.Role == "admin"
-
-	if !isOwner && !isCoOwner && !isAdmin {
-		c.JSON(http.StatusForbidden, gin.H{"error": "Only board managers can update teams"})
+	if _, ok := authorizeBoard(c, &board, BoardActionManage); !ok {
 		return
 	}
 
@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
 	"time"
 
@@ -37,12 +38,25 @@ func CreateCard(c *gin.Context) {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Column not found"})
 		return
 	}
+	var board models.Board
+	if err := database.DB.First(&board, column.BoardID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Board not found"})
+		return
+	}
+	access, ok := authorizeBoard(c, &board, BoardActionContribute)
+	if !ok {
+		return
+	}
+	if !requirePhase(c, &board, PhaseActionAddCard, "Cards cannot be added in the "+boardPhase(board.Phase)+" phase") {
+		return
+	}
 
 	card := models.Card{
 		ID:       uuid.New(),
 		ColumnID: columnID,
 		Content:  input.Content,
 		Position: input.Position,
+		Author:   access.Username,
 		Owner:    input.Owner,
 	}
 
@@ -51,11 +65,13 @@ func CreateCard(c *gin.Context) {
 		return
 	}
 
-	BroadcastBoardUpdate(column.BoardID)
-	c.JSON(http.StatusCreated, card)
+	BroadcastCardCreated(column.BoardID, card)
+	c.JSON(http.StatusCreated, presentCard(&board, card, access.Username))
 }
 
-// UpdateCard updates a card's content and action item details
+// UpdateCard updates a card's content and action item details. Only its author
+// or a board manager may, except that whoever owns an action item can record
+// its progress.
 func UpdateCard(c *gin.Context) {
 	id, err := uuid.Parse(c.Param("id"))
 	if err != nil {
@@ -84,7 +100,23 @@ func UpdateCard(c *gin.Context) {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Card not found"})
 		return
 	}
+	access, board, ok := authorizeCard(c, card.ID)
+	if !ok {
+		return
+	}
+	onlyProgress := input.Content == nil && input.IsActionItem == nil && input.Owner == nil && input.DueDate == nil
+	isItemOwner := card.IsActionItem && card.Owner != "" && card.Owner == access.Username
+	if !access.CanChangeCard(&card) && !(onlyProgress && isItemOwner) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only the author or a board manager can edit this card"})
+		return
+	}
 
+	if input.Content != nil && cardsHidden(&board) && (access.Username == "" || card.Author != access.Username) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only the author can edit a card before the board is revealed"})
+		return
+	}
+
+	before := cardStateOf(card)
 	if input.Content != nil {
 		card.Content = *input.Content
 	}
@@ -110,18 +142,14 @@ func UpdateCard(c *gin.Context) {
 		card.DueDate = input.DueDate
 	}
 
-	if err := database.DB.Save(&card).Error; err != nil {
+	if err := saveCardWithRevision(&card, before, access.Username, RevisionEdit, nil); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update card"})
 		return
 	}
 
-	// Fetch BoardID for broadcast
-	var column models.Column
-	if err := database.DB.First(&column, card.ColumnID).Error; err == nil {
-		BroadcastBoardUpdate(column.BoardID)
-	}
+	BroadcastCardUpdated(board.ID, card)
 
-	c.JSON(http.StatusOK, card)
+	c.JSON(http.StatusOK, presentCard(&board, card, access.Username))
 }
 
 // MoveCard moves a card to another column or position
@@ -147,6 +175,21 @@ func MoveCard(c *gin.Context) {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Card not found"})
 		return
 	}
+	access, board, ok := authorizeCard(c, card.ID)
+	if !ok {
+		return
+	}
+
+	// Cards can only move between columns of the same board
+	if targetBoard, err := boardForColumn(input.ColumnID); err != nil || targetBoard.ID != board.ID {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Target column does not belong to this board"})
+		return
+	}
+	// Groups belong to a column, so a card moving to another column leaves its group
+	leavesGroup := card.GroupID != nil && card.ColumnID != input.ColumnID
+	if leavesGroup && !requirePhase(c, &board, PhaseActionMerge, "Grouped cards cannot change column in the "+boardPhase(board.Phase)+" phase") {
+		return
+	}
 
 	// Reordering Logic
 	// 1. Get all cards in target column, ordered by position
@@ -176,6 +219,7 @@ func MoveCard(c *gin.Context) {
 	reorderedCards = append(reorderedCards[:input.Position], append([]models.Card{card}, reorderedCards[input.Position:]...)...)
 
 	// 4. Submit updates in transaction
+	var pruned []models.CardGroup
 	err = database.DB.Transaction(func(tx *gorm.DB) error {
 		for i, c := range reorderedCards {
 			if c.Position != i || c.ColumnID != input.ColumnID {
@@ -187,7 +231,15 @@ func MoveCard(c *gin.Context) {
 				}
 			}
 		}
-		return nil
+		if !leavesGroup {
+			return nil
+		}
+		if err := tx.Model(&models.Card{}).Where("id = ?", card.ID).Update("group_id", nil).Error; err != nil {
+			return err
+		}
+		var err error
+		pruned, err = pruneEmptyGroups(tx, card.GroupID)
+		return err
 	})
 
 	if err != nil {
@@ -201,8 +253,13 @@ func MoveCard(c *gin.Context) {
 		// Broadcast Granular Move Event
 		BroadcastCardMove(targetColumn.BoardID, card.ID, input.ColumnID, input.Position)
 	}
+	if leavesGroup {
+		card.ColumnID, card.GroupID = input.ColumnID, nil
+		BroadcastCardUpdated(board.ID, card)
+		broadcastPrunedGroups(board.ID, pruned)
+	}
 
-	c.JSON(http.StatusOK, card)
+	c.JSON(http.StatusOK, presentCard(&board, card, access.Username))
 }
 
 // MergeCard merges a card with another card
@@ -235,38 +292,48 @@ func MergeCard(c *gin.Context) {
 		return
 	}
 
-	// Fetch source votes
-	var sourceVotes []models.Vote
-	if err := database.DB.Where("card_id = ?", card.ID).Find(&sourceVotes).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch source votes"})
+	access, board, ok := authorizeCard(c, card.ID)
+	if !ok {
+		return
+	}
+	if targetBoard, err := boardForCard(targetCard.ID); err != nil || targetBoard.ID != board.ID {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Cards must belong to the same board"})
+		return
+	}
+	if !requirePhase(c, &board, PhaseActionMerge, "Cards cannot be merged in the "+boardPhase(board.Phase)+" phase") {
+		return
+	}
+
+	if card.ID == targetCard.ID {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "A card cannot be merged into itself"})
 		return
 	}
 
-	// Transaction to ensure atomicity
+	// The source joins the target's group, which is created if the target has none
+	var group models.CardGroup
+	var pruned []models.CardGroup
 	err = database.DB.Transaction(func(tx *gorm.DB) error {
-		// Set merged relationship on source card
-		card.MergedWithID = &input.TargetCardID
-		if err := tx.Save(&card).Error; err != nil {
-			return err
-		}
-		return nil
+		var err error
+		group, _, pruned, err = mergeIntoGroup(tx, &board, targetCard, []uuid.UUID{card.ID})
+		return err
 	})
-
+	if errors.Is(err, ErrMergeConflict) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Cards were merged by someone else, reload and try again"})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to merge card: " + err.Error()})
 		return
 	}
+	database.DB.First(&card, card.ID)
 
-	// Broadcast
-	var column models.Column
-	if err := database.DB.First(&column, card.ColumnID).Error; err == nil {
-		BroadcastBoardUpdate(column.BoardID)
-	}
+	BroadcastCardMerged(board.ID, card, targetCard.ID, group)
+	broadcastPrunedGroups(board.ID, pruned)
 
-	c.JSON(http.StatusOK, card)
+	c.JSON(http.StatusOK, presentCard(&board, card, access.Username))
 }
 
-// UnmergeCard unmerges a card from its parent
+// UnmergeCard takes a card out of its group, deleting the group if that leaves it empty
 func UnmergeCard(c *gin.Context) {
 	id, err := uuid.Parse(c.Param("id"))
 	if err != nil {
@@ -279,21 +346,34 @@ func UnmergeCard(c *gin.Context) {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Card not found"})
 		return
 	}
+	access, board, ok := authorizeCard(c, card.ID)
+	if !ok {
+		return
+	}
+	if !requirePhase(c, &board, PhaseActionMerge, "Cards cannot be unmerged in the "+boardPhase(board.Phase)+" phase") {
+		return
+	}
 
-	// Clear merged relationship
-	card.MergedWithID = nil
-	if err := database.DB.Model(&card).Select("MergedWithID").Save(&card).Error; err != nil {
+	formerGroup := card.GroupID
+	var pruned []models.CardGroup
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.Card{}).Where("id = ?", card.ID).Update("group_id", nil).Error; err != nil {
+			return err
+		}
+		var err error
+		pruned, err = pruneEmptyGroups(tx, formerGroup)
+		return err
+	})
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to unmerge card"})
 		return
 	}
+	card.GroupID = nil
 
-	// Broadcast
-	var column models.Column
-	if err := database.DB.First(&column, card.ColumnID).Error; err == nil {
-		BroadcastBoardUpdate(column.BoardID)
-	}
+	BroadcastCardUpdated(board.ID, card)
+	broadcastPrunedGroups(board.ID, pruned)
 
-	c.JSON(http.StatusOK, card)
+	c.JSON(http.StatusOK, presentCard(&board, card, access.Username))
 }
 
 // DeleteCard deletes a card
@@ -310,19 +390,45 @@ func DeleteCard(c *gin.Context) {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Card not found"})
 		return
 	}
+	access, board, ok := authorizeCard(c, card.ID)
+	if !ok {
+		return
+	}
+	if !access.CanChangeCard(&card) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only the author or a board manager can delete this card"})
+		return
+	}
 
-	var column models.Column
-	// Best effort to get column for board ID
-	database.DB.First(&column, card.ColumnID)
-
-	if err := database.DB.Delete(&models.Card{}, id).Error; err != nil {
+	var pruned []models.CardGroup
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := deleteCardComments(tx, id); err != nil {
+			return err
+		}
+		if err := tx.Delete(&models.Card{}, id).Error; err != nil {
+			return err
+		}
+		var err error
+		pruned, err = pruneEmptyGroups(tx, card.GroupID)
+		return err
+	})
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete card"})
 		return
 	}
 
-	if column.ID != uuid.Nil {
-		BroadcastBoardUpdate(column.BoardID)
-	}
+	BroadcastCardDeleted(board.ID, card.ID, card.ColumnID)
+	broadcastPrunedGroups(board.ID, pruned)
 
 	c.JSON(http.StatusOK, gin.H{"message": "Card deleted successfully"})
 }
+
+// authorizeCard checks the caller may contribute to the board owning the card
+func authorizeCard(c *gin.Context, cardID uuid.UUID) (BoardAccess, models.Board, bool) {
+	board, err := boardForCard(cardID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Board not found"})
+		return BoardAccess{}, board, false
+	}
+	access, ok := authorizeBoard(c, &board, BoardActionContribute)
+	return access, board, ok
+}
@@ -0,0 +1,97 @@
+package handlers
+
+import (
+	"github.com/bento-lab-ops/bentro/internal/database"
+	"github.com/bento-lab-ops/bentro/internal/models"
+
+	"github.com/google/uuid"
+)
+
+// Card authorship modes of a board
+const (
+	AnonymityAnonymous   = "anonymous"    // Authors are never shown
+	AnonymityUntilReveal = "until_reveal" // Authors are shown once a manager reveals them
+	AnonymityNamed       = "named"        // Authors are always shown
+)
+
+// IsValidAnonymity reports whether the mode is a card authorship mode
+func IsValidAnonymity(mode string) bool {
+	switch mode {
+	case AnonymityAnonymous, AnonymityUntilReveal, AnonymityNamed:
+		return true
+	}
+	return false
+}
+
+// authorsVisible reports whether the authors of a board's cards may leave the
+// server. Boards without a mode are anonymous.
+func authorsVisible(board *models.Board) bool {
+	switch board.Anonymity {
+	case AnonymityNamed:
+		return true
+	case AnonymityUntilReveal:
+		return board.AuthorsRevealed
+	}
+	return false
+}
+
+// cardsHidden reports whether a board in private writing mode still hides card
+// content from everyone but the card's author
+func cardsHidden(board *models.Board) bool {
+	return board.PrivateWriting && board.CardsRevealedAt == nil
+}
+
+// presentCard returns a copy of the card as the board shows it to a viewer:
+// without authors on anonymous boards, and without the content of other
+// people's cards until a private writing board is revealed. An empty viewer
+// sees only what the whole board may see.
+func presentCard(board *models.Board, card models.Card, viewer string) models.Card {
+	if cardsHidden(board) && (viewer == "" || card.Author != viewer) {
+		card.Content = ""
+		card.Hidden = true
+		card.Comments = nil
+		card.Labels = nil
+	}
+	if !authorsVisible(board) {
+		card.Author = ""
+	}
+	if len(card.Comments) > 0 {
+		comments := make([]models.CardComment, len(card.Comments))
+		for i, comment := range card.Comments {
+			comments[i] = presentComment(board, comment, viewer)
+		}
+		card.Comments = comments
+	}
+	return card
+}
+
+// presentBoard applies presentCard to the cards loaded with a board
+func presentBoard(board *models.Board, viewer string) {
+	for i := range board.Columns {
+		for j, card := range board.Columns[i].Cards {
+			board.Columns[i].Cards[j] = presentCard(board, card, viewer)
+		}
+	}
+}
+
+// presentBroadcastCard returns the card as the whole board may see it, for
+// broadcasts that only know the board ID. Authors and content stay hidden if the
+// board cannot be read.
+func presentBroadcastCard(boardID uuid.UUID, card models.Card) models.Card {
+	var board models.Board
+	if err := database.DB.Select("id", "anonymity", "authors_revealed", "private_writing", "cards_revealed_at").
+		First(&board, "id = ?", boardID).Error; err != nil {
+		board = models.Board{PrivateWriting: true}
+	}
+	return presentCard(&board, card, "")
+}
+
+// boardHasCards reports whether any card was written on the board
+func boardHasCards(boardID uuid.UUID) bool {
+	var count int64
+	database.DB.Model(&models.Card{}).
+		Joins("JOIN columns ON columns.id = cards.column_id").
+		Where("columns.board_id = ?", boardID).
+		Count(&count)
+	return count > 0
+}
@@ -0,0 +1,184 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/bento-lab-ops/bentro/internal/models"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func TestCardAuthorAnonymity(t *testing.T) {
+	db, r := setupPolicyTest(t)
+	owner := createTestUser(db, "facilitator", "user")
+	participant := createTestUser(db, "attendee", "user")
+
+	newBoard := func(anonymity string) (models.Board, models.Column) {
+		board := models.Board{ID: uuid.New(), Name: "Retro", Owner: "facilitator", Status: "active", Anonymity: anonymity}
+		db.Create(&board)
+		db.Create(&models.BoardMember{BoardID: board.ID, Username: "attendee"})
+		col := models.Column{ID: uuid.New(), BoardID: board.ID, Name: "Col"}
+		db.Create(&col)
+		return board, col
+	}
+	addCard := func(col models.Column) map[string]interface{} {
+		body, _ := json.Marshal(map[string]string{"content": "Idea"})
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, asUser(httptest.NewRequest("POST", "/columns/"+col.ID.String()+"/cards", bytes.NewBuffer(body)), participant))
+		assert.Equal(t, http.StatusCreated, w.Code)
+		var card map[string]interface{}
+		json.Unmarshal(w.Body.Bytes(), &card)
+		return card
+	}
+	boardAuthors := func(board models.Board) []string {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, asUser(httptest.NewRequest("GET", "/boards/"+board.ID.String(), nil), owner))
+		var loaded models.Board
+		json.Unmarshal(w.Body.Bytes(), &loaded)
+		var authors []string
+		for _, col := range loaded.Columns {
+			for _, card := range col.Cards {
+				authors = append(authors, card.Author)
+			}
+		}
+		return authors
+	}
+	updateBoard := func(board models.Board, settings map[string]interface{}) int {
+		body, _ := json.Marshal(settings)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, asUser(httptest.NewRequest("PUT", "/boards/"+board.ID.String(), bytes.NewBuffer(body)), owner))
+		return w.Code
+	}
+
+	// The creator is recorded, but not sent while the board hides authors
+	board, col := newBoard(AnonymityUntilReveal)
+	card := addCard(col)
+	assert.NotContains(t, card, "author")
+	var stored models.Card
+	db.First(&stored, "id = ?", card["id"])
+	assert.Equal(t, "attendee", stored.Author)
+	assert.Equal(t, []string{""}, boardAuthors(board))
+
+	// Managers reveal the authors of until_reveal boards
+	assert.Equal(t, http.StatusOK, updateBoard(board, map[string]interface{}{"authors_revealed": true}))
+	assert.Equal(t, []string{"attendee"}, boardAuthors(board))
+
+	// Named boards always show authors
+	named, namedCol := newBoard(AnonymityNamed)
+	assert.Equal(t, "attendee", addCard(namedCol)["author"])
+	assert.Equal(t, []string{"attendee"}, boardAuthors(named))
+
+	// Fully anonymous boards never reveal cards that were written anonymously
+	anonymous, anonymousCol := newBoard(AnonymityAnonymous)
+	assert.Equal(t, http.StatusOK, updateBoard(anonymous, map[string]interface{}{"authors_revealed": true}))
+	addCard(anonymousCol)
+	assert.Equal(t, []string{""}, boardAuthors(anonymous))
+	assert.Equal(t, http.StatusConflict, updateBoard(anonymous, map[string]interface{}{"anonymity": AnonymityNamed}))
+	assert.Equal(t, http.StatusBadRequest, updateBoard(named, map[string]interface{}{"anonymity": "masked"}))
+}
+
+func TestPresentBroadcastCard(t *testing.T) {
+	db, _ := setupPolicyTest(t)
+
+	anonymous := models.Board{ID: uuid.New(), Name: "Anonymous", Anonymity: AnonymityAnonymous}
+	db.Create(&anonymous)
+	named := models.Board{ID: uuid.New(), Name: "Named", Anonymity: AnonymityNamed}
+	db.Create(&named)
+
+	card := models.Card{Content: "Parent", Author: "alice", Comments: []models.CardComment{{Body: "Reply", Author: "bob"}}}
+
+	hidden := presentBroadcastCard(anonymous.ID, card)
+	assert.Empty(t, hidden.Author)
+	assert.Empty(t, hidden.Comments[0].Author)
+	assert.Equal(t, "bob", card.Comments[0].Author, "the caller's card is left untouched")
+
+	assert.Equal(t, "alice", presentBroadcastCard(named.ID, card).Author)
+	assert.Empty(t, presentBroadcastCard(uuid.New(), card).Author, "authors stay hidden when the board is unknown")
+}
+
+func TestPrivateWriting(t *testing.T) {
+	db, r := setupPolicyTest(t)
+	r.PUT("/cards/:id", UpdateCard)
+	r.POST("/boards/:id/reveal", RevealCards)
+	owner := createTestUser(db, "facilitator", "user")
+	alice := createTestUser(db, "alice", "user")
+	bob := createTestUser(db, "bob", "user")
+
+	board := models.Board{ID: uuid.New(), Name: "Private", Owner: "facilitator", Status: "active", PrivateWriting: true}
+	db.Create(&board)
+	db.Create(&models.BoardMember{BoardID: board.ID, Username: "alice"})
+	db.Create(&models.BoardMember{BoardID: board.ID, Username: "bob"})
+	col := models.Column{ID: uuid.New(), BoardID: board.ID, Name: "Col"}
+	db.Create(&col)
+
+	addCard := func(user models.User, content string) models.Card {
+		body, _ := json.Marshal(map[string]string{"content": content})
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, asUser(httptest.NewRequest("POST", "/columns/"+col.ID.String()+"/cards", bytes.NewBuffer(body)), user))
+		assert.Equal(t, http.StatusCreated, w.Code)
+		var card models.Card
+		json.Unmarshal(w.Body.Bytes(), &card)
+		return card
+	}
+	contents := func(user models.User) map[string]string {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, asUser(httptest.NewRequest("GET", "/boards/"+board.ID.String(), nil), user))
+		var loaded models.Board
+		json.Unmarshal(w.Body.Bytes(), &loaded)
+		seen := make(map[string]string)
+		for _, col := range loaded.Columns {
+			for _, card := range col.Cards {
+				seen[card.ID.String()] = card.Content
+				if card.Content == "" {
+					assert.True(t, card.Hidden)
+				}
+			}
+		}
+		return seen
+	}
+	reveal := func(user models.User) int {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, asUser(httptest.NewRequest("POST", "/boards/"+board.ID.String()+"/reveal", nil), user))
+		return w.Code
+	}
+
+	// Authors see their own cards; everyone else, managers included, sees them hidden
+	aliceCard := addCard(alice, "Alice's idea")
+	assert.Equal(t, "Alice's idea", aliceCard.Content)
+	bobCard := addCard(bob, "Bob's idea")
+
+	assert.Equal(t, map[string]string{aliceCard.ID.String(): "Alice's idea", bobCard.ID.String(): ""}, contents(alice))
+	assert.Equal(t, map[string]string{aliceCard.ID.String(): "", bobCard.ID.String(): ""}, contents(owner))
+	assert.Empty(t, presentBroadcastCard(board.ID, models.Card{Content: "Alice's idea", Author: "alice"}).Content)
+
+	// Other people's hidden cards cannot be rewritten
+	body, _ := json.Marshal(map[string]string{"content": "Changed"})
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, asUser(httptest.NewRequest("PUT", "/cards/"+aliceCard.ID.String(), bytes.NewBuffer(body)), bob))
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	// Cards merged while hidden leave their group untitled until the reveal
+	var group models.CardGroup
+	assert.NoError(t, db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		group, _, _, err = mergeIntoGroup(tx, &board, aliceCard, []uuid.UUID{bobCard.ID})
+		return err
+	}))
+	assert.Empty(t, group.Title)
+
+	// Only managers reveal, and only once
+	assert.Equal(t, http.StatusForbidden, reveal(alice))
+	assert.Equal(t, http.StatusOK, reveal(owner))
+	assert.Equal(t, http.StatusConflict, reveal(owner))
+	assert.Equal(t, map[string]string{aliceCard.ID.String(): "Alice's idea", bobCard.ID.String(): "Bob's idea"}, contents(bob))
+	var titled models.CardGroup
+	db.First(&titled, group.ID)
+	assert.Equal(t, "Alice's idea", titled.Title)
+	assert.Nil(t, titled.TitleCardID)
+}
@@ -33,7 +33,16 @@ func setupColumnCardTest(t *testing.T) (*gorm.DB, *gin.Engine) {
 		&models.Column{},
 		&models.Card{},
 		&models.Vote{},
+		&models.BoardVoter{},
 		&models.Reaction{},
+		&models.CardComment{},
+		&models.CardRevision{},
+		&models.Label{},
+		&models.CardLabel{},
+		&models.CardGroup{},
+		&models.Team{},
+		&models.TeamMember{},
+		&models.BoardMember{},
 	)
 	if err != nil {
 		panic(err)
@@ -42,6 +51,7 @@ func setupColumnCardTest(t *testing.T) (*gorm.DB, *gin.Engine) {
 	database.DB = db
 	gin.SetMode(gin.TestMode)
 	r := gin.Default()
+	r.Use(simulateAuth())
 
 	// Column Routes
 	r.POST("/boards/:id/columns", CreateColumn)
@@ -62,7 +72,8 @@ func setupColumnCardTest(t *testing.T) (*gorm.DB, *gin.Engine) {
 
 func TestColumnCRUD(t *testing.T) {
 	db, r := setupColumnCardTest(t)
-	board := models.Board{ID: uuid.New(), Name: "Test Board"}
+	owner := createTestUser(db, "owner", "user")
+	board := models.Board{ID: uuid.New(), Name: "Test Board", Owner: "owner"}
 	db.Create(&board)
 
 	// Create Column
@@ -70,7 +81,7 @@ func TestColumnCRUD(t *testing.T) {
 	body, _ := json.Marshal(input)
 	w := httptest.NewRecorder()
 	req, _ := http.NewRequest("POST", "/boards/"+board.ID.String()+"/columns", bytes.NewBuffer(body))
-	r.ServeHTTP(w, req)
+	r.ServeHTTP(w, asUser(req, owner))
 	assert.Equal(t, http.StatusCreated, w.Code)
 	var col models.Column
 	json.Unmarshal(w.Body.Bytes(), &col)
@@ -82,7 +93,7 @@ func TestColumnCRUD(t *testing.T) {
 	body2, _ := json.Marshal(updateInput)
 	w2 := httptest.NewRecorder()
 	req2, _ := http.NewRequest("PUT", "/columns/"+col.ID.String(), bytes.NewBuffer(body2))
-	r.ServeHTTP(w2, req2)
+	r.ServeHTTP(w2, asUser(req2, owner))
 	assert.Equal(t, http.StatusOK, w2.Code)
 
 	var updatedCol models.Column
@@ -94,7 +105,7 @@ func TestColumnCRUD(t *testing.T) {
 	body3, _ := json.Marshal(posInput)
 	w3 := httptest.NewRecorder()
 	req3, _ := http.NewRequest("PUT", "/columns/"+col.ID.String()+"/position", bytes.NewBuffer(body3))
-	r.ServeHTTP(w3, req3)
+	r.ServeHTTP(w3, asUser(req3, owner))
 	assert.Equal(t, http.StatusOK, w3.Code)
 
 	db.First(&updatedCol, col.ID)
@@ -105,7 +116,7 @@ func TestColumnCRUD(t *testing.T) {
 	bodyAuto, _ := json.Marshal(inputAuto)
 	wAuto := httptest.NewRecorder()
 	reqAuto, _ := http.NewRequest("POST", "/boards/"+board.ID.String()+"/columns", bytes.NewBuffer(bodyAuto))
-	r.ServeHTTP(wAuto, reqAuto)
+	r.ServeHTTP(wAuto, asUser(reqAuto, owner))
 
 	var autoCol models.Column
 	json.Unmarshal(wAuto.Body.Bytes(), &autoCol)
@@ -115,7 +126,7 @@ func TestColumnCRUD(t *testing.T) {
 	// Delete Column
 	w4 := httptest.NewRecorder()
 	req4, _ := http.NewRequest("DELETE", "/columns/"+col.ID.String(), nil)
-	r.ServeHTTP(w4, req4)
+	r.ServeHTTP(w4, asUser(req4, owner))
 	assert.Equal(t, http.StatusOK, w4.Code)
 
 	var count int64
@@ -125,7 +136,8 @@ func TestColumnCRUD(t *testing.T) {
 
 func TestCardCRUD(t *testing.T) {
 	db, r := setupColumnCardTest(t)
-	board := models.Board{ID: uuid.New(), Name: "Test Board"}
+	owner := createTestUser(db, "owner", "user")
+	board := models.Board{ID: uuid.New(), Name: "Test Board", Owner: "owner"}
 	db.Create(&board)
 	col := models.Column{ID: uuid.New(), BoardID: board.ID, Name: "Backlog"}
 	db.Create(&col)
@@ -139,7 +151,7 @@ func TestCardCRUD(t *testing.T) {
 	body, _ := json.Marshal(input)
 	w := httptest.NewRecorder()
 	req, _ := http.NewRequest("POST", "/columns/"+col.ID.String()+"/cards", bytes.NewBuffer(body))
-	r.ServeHTTP(w, req)
+	r.ServeHTTP(w, asUser(req, owner))
 	assert.Equal(t, http.StatusCreated, w.Code)
 
 	var card models.Card
@@ -160,7 +172,7 @@ func TestCardCRUD(t *testing.T) {
 	body2, _ := json.Marshal(updateInput)
 	w2 := httptest.NewRecorder()
 	req2, _ := http.NewRequest("PUT", "/cards/"+card.ID.String(), bytes.NewBuffer(body2))
-	r.ServeHTTP(w2, req2)
+	r.ServeHTTP(w2, asUser(req2, owner))
 	assert.Equal(t, http.StatusOK, w2.Code)
 
 	var updatedCard models.Card
@@ -172,7 +184,7 @@ func TestCardCRUD(t *testing.T) {
 	// Delete Card
 	w3 := httptest.NewRecorder()
 	req3, _ := http.NewRequest("DELETE", "/cards/"+card.ID.String(), nil)
-	r.ServeHTTP(w3, req3)
+	r.ServeHTTP(w3, asUser(req3, owner))
 	assert.Equal(t, http.StatusOK, w3.Code)
 
 	var count int64
@@ -182,8 +194,11 @@ func TestCardCRUD(t *testing.T) {
 
 func TestMoveCard(t *testing.T) {
 	db, r := setupColumnCardTest(t)
-	col1 := models.Column{ID: uuid.New(), Name: "Col1"}
-	col2 := models.Column{ID: uuid.New(), Name: "Col2"}
+	owner := createTestUser(db, "owner", "user")
+	board := models.Board{ID: uuid.New(), Name: "Move Board", Owner: "owner"}
+	db.Create(&board)
+	col1 := models.Column{ID: uuid.New(), BoardID: board.ID, Name: "Col1"}
+	col2 := models.Column{ID: uuid.New(), BoardID: board.ID, Name: "Col2"}
 	db.Create(&col1)
 	db.Create(&col2)
 
@@ -198,18 +213,22 @@ func TestMoveCard(t *testing.T) {
 	body, _ := json.Marshal(input)
 	w := httptest.NewRecorder()
 	req, _ := http.NewRequest("PUT", "/cards/"+card.ID.String()+"/move", bytes.NewBuffer(body))
-	r.ServeHTTP(w, req)
+	r.ServeHTTP(w, asUser(req, owner))
 	assert.Equal(t, http.StatusOK, w.Code)
 
 	var movedCard models.Card
 	db.First(&movedCard, card.ID)
 	assert.Equal(t, col2.ID, movedCard.ColumnID)
-	assert.Equal(t, 2, movedCard.Position)
+	// Col2 is empty, so position 2 lands at the end of the column
+	assert.Equal(t, 0, movedCard.Position)
 }
 
 func TestMergeAndUnmergeCard(t *testing.T) {
 	db, r := setupColumnCardTest(t)
-	col := models.Column{ID: uuid.New(), Name: "Col1"}
+	owner := createTestUser(db, "owner", "user")
+	board := models.Board{ID: uuid.New(), Name: "Merge Board", Owner: "owner"}
+	db.Create(&board)
+	col := models.Column{ID: uuid.New(), BoardID: board.ID, Name: "Col1"}
 	db.Create(&col)
 
 	parent := models.Card{ID: uuid.New(), ColumnID: col.ID, Content: "Parent"}
@@ -222,22 +241,40 @@ func TestMergeAndUnmergeCard(t *testing.T) {
 	body, _ := json.Marshal(input)
 	w := httptest.NewRecorder()
 	req, _ := http.NewRequest("POST", "/cards/"+child.ID.String()+"/merge", bytes.NewBuffer(body))
-	r.ServeHTTP(w, req)
+	r.ServeHTTP(w, asUser(req, owner))
 	assert.Equal(t, http.StatusOK, w.Code)
 
-	var mergedChild models.Card
+	// Both cards end up in a group titled after the parent
+	var mergedChild, mergedParent models.Card
 	db.First(&mergedChild, child.ID)
-	assert.Equal(t, parent.ID, *mergedChild.MergedWithID)
+	db.First(&mergedParent, parent.ID)
+	if assert.NotNil(t, mergedChild.GroupID) {
+		assert.Equal(t, mergedChild.GroupID, mergedParent.GroupID)
+		var group models.CardGroup
+		db.First(&group, *mergedChild.GroupID)
+		assert.Equal(t, "Parent", group.Title)
+	}
 
 	// Unmerge
 	w2 := httptest.NewRecorder()
 	req2, _ := http.NewRequest("POST", "/cards/"+child.ID.String()+"/unmerge", nil)
-	r.ServeHTTP(w2, req2)
+	r.ServeHTTP(w2, asUser(req2, owner))
 	assert.Equal(t, http.StatusOK, w2.Code)
 
 	var unmergedChild models.Card
 	db.First(&unmergedChild, child.ID)
-	assert.Nil(t, unmergedChild.MergedWithID)
+	assert.Nil(t, unmergedChild.GroupID)
+
+	// The group outlives the child, and goes away with its last card
+	var groups int64
+	db.Model(&models.CardGroup{}).Count(&groups)
+	assert.Equal(t, int64(1), groups)
+	w3 := httptest.NewRecorder()
+	req3, _ := http.NewRequest("POST", "/cards/"+parent.ID.String()+"/unmerge", nil)
+	r.ServeHTTP(w3, asUser(req3, owner))
+	assert.Equal(t, http.StatusOK, w3.Code)
+	db.Model(&models.CardGroup{}).Count(&groups)
+	assert.Equal(t, int64(0), groups)
 }
 
 func TestSadPaths(t *testing.T) {
@@ -27,6 +27,15 @@ func CreateColumn(c *gin.Context) {
 		return
 	}
 
+	var board models.Board
+	if err := database.DB.First(&board, boardID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Board not found"})
+		return
+	}
+	if _, ok := authorizeBoard(c, &board, BoardActionManage); !ok {
+		return
+	}
+
 	// Calculate position: if not provided (0), append to end
 	if input.Position == 0 {
 		var maxPos int
@@ -51,6 +60,7 @@ func CreateColumn(c *gin.Context) {
 		return
 	}
 
+	BroadcastBoardUpdate(boardID)
 	c.JSON(http.StatusCreated, column)
 }
 
@@ -76,6 +86,9 @@ func UpdateColumn(c *gin.Context) {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Column not found"})
 		return
 	}
+	if !authorizeColumn(c, column.ID) {
+		return
+	}
 
 	column.Name = input.Name
 	if err := database.DB.Save(&column).Error; err != nil {
@@ -83,6 +96,7 @@ func UpdateColumn(c *gin.Context) {
 		return
 	}
 
+	BroadcastColumnRenamed(column.BoardID, column.ID, column.Name)
 	c.JSON(http.StatusOK, column)
 }
 
@@ -108,6 +122,9 @@ func UpdateColumnPosition(c *gin.Context) {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Column not found"})
 		return
 	}
+	if !authorizeColumn(c, column.ID) {
+		return
+	}
 
 	column.Position = input.Position
 	if err := database.DB.Save(&column).Error; err != nil {
@@ -115,6 +132,7 @@ func UpdateColumnPosition(c *gin.Context) {
 		return
 	}
 
+	BroadcastColumnReordered(column.BoardID, column.ID, column.Position)
 	c.JSON(http.StatusOK, column)
 }
 
@@ -126,10 +144,31 @@ func DeleteColumn(c *gin.Context) {
 		return
 	}
 
+	var column models.Column
+	if err := database.DB.First(&column, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Column not found"})
+		return
+	}
+	if !authorizeColumn(c, column.ID) {
+		return
+	}
+
 	if err := database.DB.Delete(&models.Column{}, id).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete column"})
 		return
 	}
 
+	BroadcastBoardUpdate(column.BoardID)
 	c.JSON(http.StatusOK, gin.H{"message": "Column deleted successfully"})
 }
+
+// authorizeColumn checks the caller manages the board owning the column
+func authorizeColumn(c *gin.Context, columnID uuid.UUID) bool {
+	board, err := boardForColumn(columnID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Board not found"})
+		return false
+	}
+	_, ok := authorizeBoard(c, &board, BoardActionManage)
+	return ok
+}
@@ -0,0 +1,235 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/bento-lab-ops/bentro/internal/database"
+	"github.com/bento-lab-ops/bentro/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Longest comment body, in characters
+const maxCommentLength = 2000
+
+// presentComment returns the comment as the board shows it to a viewer
+func presentComment(board *models.Board, comment models.CardComment, viewer string) models.CardComment {
+	comment.Mine = viewer != "" && comment.Author == viewer
+	if !authorsVisible(board) {
+		comment.Author = ""
+	}
+	return comment
+}
+
+// commentBody validates and trims a comment body, responding with 400 if it is unusable
+func commentBody(c *gin.Context, body string) (string, bool) {
+	body = strings.TrimSpace(body)
+	if body == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Comment body is required"})
+		return "", false
+	}
+	if len([]rune(body)) > maxCommentLength {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Comment body is too long"})
+		return "", false
+	}
+	return body, true
+}
+
+// authorizeComments checks the caller may take part in a card's discussion.
+// Comments open once a private writing board is revealed, so they never leak hidden cards.
+func authorizeComments(c *gin.Context, cardID uuid.UUID) (BoardAccess, models.Board, bool) {
+	access, board, ok := authorizeCard(c, cardID)
+	if !ok {
+		return access, board, false
+	}
+	if cardsHidden(&board) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Comments open once the cards are revealed"})
+		return access, board, false
+	}
+	return access, board, true
+}
+
+// findComment loads a comment of a card, responding with 404 if there is none
+func findComment(c *gin.Context, cardID uuid.UUID) (models.CardComment, bool) {
+	var comment models.CardComment
+	commentID, err := uuid.Parse(c.Param("commentId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid comment ID"})
+		return comment, false
+	}
+	if err := database.DB.Where("id = ? AND card_id = ?", commentID, cardID).First(&comment).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Comment not found"})
+		return comment, false
+	}
+	return comment, true
+}
+
+// ListComments returns a card's comments, oldest first
+func ListComments(c *gin.Context) {
+	cardID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid card ID"})
+		return
+	}
+	access, board, ok := authorizeComments(c, cardID)
+	if !ok {
+		return
+	}
+
+	var comments []models.CardComment
+	if err := database.DB.Where("card_id = ?", cardID).Order("created_at asc").Find(&comments).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch comments"})
+		return
+	}
+	for i, comment := range comments {
+		comments[i] = presentComment(&board, comment, access.Username)
+	}
+
+	c.JSON(http.StatusOK, comments)
+}
+
+// CreateComment adds a comment, or a reply to another comment, to a card
+func CreateComment(c *gin.Context) {
+	cardID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid card ID"})
+		return
+	}
+
+	var input struct {
+		Body     string     `json:"body" binding:"required"`
+		ParentID *uuid.UUID `json:"parent_id"`
+	}
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	body, ok := commentBody(c, input.Body)
+	if !ok {
+		return
+	}
+
+	access, board, ok := authorizeComments(c, cardID)
+	if !ok {
+		return
+	}
+	// Comments carry the author, so guests without an account cannot write them
+	if access.Username == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Sign in to comment"})
+		return
+	}
+	if input.ParentID != nil {
+		var parent models.CardComment
+		if err := database.DB.Where("id = ? AND card_id = ?", *input.ParentID, cardID).First(&parent).Error; err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Parent comment not found on this card"})
+			return
+		}
+	}
+
+	comment := models.CardComment{
+		CardID:   cardID,
+		ParentID: input.ParentID,
+		Author:   access.Username,
+		Body:     body,
+	}
+	if err := database.DB.Create(&comment).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create comment"})
+		return
+	}
+
+	BroadcastCommentCreated(board, comment)
+	c.JSON(http.StatusCreated, presentComment(&board, comment, access.Username))
+}
+
+// UpdateComment edits a comment (its author only)
+func UpdateComment(c *gin.Context) {
+	cardID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid card ID"})
+		return
+	}
+
+	var input struct {
+		Body string `json:"body" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	body, ok := commentBody(c, input.Body)
+	if !ok {
+		return
+	}
+
+	access, board, ok := authorizeComments(c, cardID)
+	if !ok {
+		return
+	}
+	comment, ok := findComment(c, cardID)
+	if !ok {
+		return
+	}
+	if access.Username == "" || comment.Author != access.Username {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only the author can edit a comment"})
+		return
+	}
+
+	now := time.Now()
+	comment.Body = body
+	comment.EditedAt = &now
+	if err := database.DB.Save(&comment).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update comment"})
+		return
+	}
+
+	BroadcastCommentUpdated(board, comment)
+	c.JSON(http.StatusOK, presentComment(&board, comment, access.Username))
+}
+
+// DeleteComment deletes a comment (its author or a board manager).
+// Replies stay in the thread.
+func DeleteComment(c *gin.Context) {
+	cardID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid card ID"})
+		return
+	}
+
+	access, board, ok := authorizeComments(c, cardID)
+	if !ok {
+		return
+	}
+	comment, ok := findComment(c, cardID)
+	if !ok {
+		return
+	}
+	if !access.IsManager() && (access.Username == "" || comment.Author != access.Username) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only the author or a board manager can delete a comment"})
+		return
+	}
+
+	if err := database.DB.Delete(&comment).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete comment"})
+		return
+	}
+
+	BroadcastCommentDeleted(board.ID, cardID, comment.ID)
+	c.JSON(http.StatusOK, gin.H{"message": "Comment deleted successfully"})
+}
+
+// orderCommentsByCreation preloads comments oldest first
+func orderCommentsByCreation(db *gorm.DB) *gorm.DB {
+	return db.Order("created_at asc")
+}
+
+// deleteCardComments deletes the comments of a deleted card. Comments of grouped
+// cards stay with their card, so ungrouping brings the discussion back with it.
+func deleteCardComments(tx *gorm.DB, cardID uuid.UUID) error {
+	return tx.Where("card_id = ?", cardID).Delete(&models.CardComment{}).Error
+}
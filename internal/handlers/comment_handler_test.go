@@ -0,0 +1,138 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/bento-lab-ops/bentro/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func setupCommentTest(t *testing.T) (*gorm.DB, *gin.Engine) {
+	db, r := setupPolicyTest(t)
+	r.GET("/cards/:id/comments", ListComments)
+	r.POST("/cards/:id/comments", CreateComment)
+	r.PUT("/cards/:id/comments/:commentId", UpdateComment)
+	r.DELETE("/cards/:id/comments/:commentId", DeleteComment)
+	return db, r
+}
+
+func TestCardComments(t *testing.T) {
+	db, r := setupCommentTest(t)
+	owner := createTestUser(db, "facilitator", "user")
+	alice := createTestUser(db, "alice", "user")
+	bob := createTestUser(db, "bob", "user")
+
+	board := models.Board{ID: uuid.New(), Name: "Async", Owner: "facilitator", Status: "active", Anonymity: AnonymityNamed}
+	db.Create(&board)
+	db.Create(&models.BoardMember{BoardID: board.ID, Username: "alice"})
+	db.Create(&models.BoardMember{BoardID: board.ID, Username: "bob"})
+	col := models.Column{ID: uuid.New(), BoardID: board.ID, Name: "Col"}
+	db.Create(&col)
+	card := models.Card{ID: uuid.New(), ColumnID: col.ID, Content: "Deploys are slow"}
+	db.Create(&card)
+	other := models.Card{ID: uuid.New(), ColumnID: col.ID, Content: "Standups run long"}
+	db.Create(&other)
+
+	send := func(user models.User, method, path string, body interface{}) *httptest.ResponseRecorder {
+		var payload []byte
+		if body != nil {
+			payload, _ = json.Marshal(body)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, asUser(httptest.NewRequest(method, path, bytes.NewBuffer(payload)), user))
+		return w
+	}
+	commentsPath := "/cards/" + card.ID.String() + "/comments"
+
+	// Comments and replies are written by the authenticated user
+	w := send(alice, "POST", commentsPath, map[string]string{"body": "  Our pipeline runs every test twice  "})
+	assert.Equal(t, http.StatusCreated, w.Code)
+	var comment models.CardComment
+	json.Unmarshal(w.Body.Bytes(), &comment)
+	assert.Equal(t, "Our pipeline runs every test twice", comment.Body)
+	assert.Equal(t, "alice", comment.Author)
+	assert.True(t, comment.Mine)
+
+	w = send(bob, "POST", commentsPath, map[string]interface{}{"body": "Let's fix that", "parent_id": comment.ID})
+	assert.Equal(t, http.StatusCreated, w.Code)
+	var reply models.CardComment
+	json.Unmarshal(w.Body.Bytes(), &reply)
+	assert.Equal(t, comment.ID, *reply.ParentID)
+
+	assert.Equal(t, http.StatusBadRequest, send(bob, "POST", commentsPath, map[string]string{"body": "   "}).Code)
+	assert.Equal(t, http.StatusBadRequest, send(bob, "POST", "/cards/"+other.ID.String()+"/comments",
+		map[string]interface{}{"body": "Wrong thread", "parent_id": comment.ID}).Code)
+
+	// Only the author edits a comment
+	commentPath := commentsPath + "/" + comment.ID.String()
+	assert.Equal(t, http.StatusForbidden, send(bob, "PUT", commentPath, map[string]string{"body": "Rewritten"}).Code)
+	w = send(alice, "PUT", commentPath, map[string]string{"body": "Our pipeline runs the tests twice"})
+	assert.Equal(t, http.StatusOK, w.Code)
+	json.Unmarshal(w.Body.Bytes(), &comment)
+	assert.NotNil(t, comment.EditedAt)
+
+	w = send(bob, "GET", commentsPath, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	var listed []models.CardComment
+	json.Unmarshal(w.Body.Bytes(), &listed)
+	if assert.Len(t, listed, 2) {
+		assert.Equal(t, "Our pipeline runs the tests twice", listed[0].Body)
+		assert.False(t, listed[0].Mine)
+		assert.True(t, listed[1].Mine)
+	}
+
+	// Authors and managers delete comments; replies stay
+	assert.Equal(t, http.StatusForbidden, send(bob, "DELETE", commentPath, nil).Code)
+	assert.Equal(t, http.StatusOK, send(owner, "DELETE", commentPath, nil).Code)
+	var remaining int64
+	db.Model(&models.CardComment{}).Where("card_id = ?", card.ID).Count(&remaining)
+	assert.Equal(t, int64(1), remaining)
+
+	// The board carries the threads of its cards
+	w = send(owner, "GET", "/boards/"+board.ID.String(), nil)
+	var loaded models.Board
+	json.Unmarshal(w.Body.Bytes(), &loaded)
+	for _, loadedCard := range loaded.Columns[0].Cards {
+		if loadedCard.ID == card.ID && assert.Len(t, loadedCard.Comments, 1) {
+			assert.Equal(t, "bob", loadedCard.Comments[0].Author)
+		}
+	}
+
+	// Deleting a card deletes its thread
+	assert.Equal(t, http.StatusOK, send(owner, "DELETE", "/cards/"+card.ID.String(), nil).Code)
+	db.Model(&models.CardComment{}).Where("card_id = ?", card.ID).Count(&remaining)
+	assert.Equal(t, int64(0), remaining)
+}
+
+func TestCardCommentsVisibility(t *testing.T) {
+	db, r := setupCommentTest(t)
+	alice := createTestUser(db, "alice", "user")
+
+	board := models.Board{ID: uuid.New(), Name: "Private", Status: "active", PrivateWriting: true}
+	db.Create(&board)
+	db.Create(&models.BoardMember{BoardID: board.ID, Username: "alice"})
+	col := models.Column{ID: uuid.New(), BoardID: board.ID, Name: "Col"}
+	db.Create(&col)
+	card := models.Card{ID: uuid.New(), ColumnID: col.ID, Content: "Secret", Author: "alice"}
+	db.Create(&card)
+
+	// Hidden cards have no discussion yet
+	body, _ := json.Marshal(map[string]string{"body": "Hello"})
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, asUser(httptest.NewRequest("POST", "/cards/"+card.ID.String()+"/comments", bytes.NewBuffer(body)), alice))
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	// Comment authors are hidden like card authors
+	comment := models.CardComment{CardID: card.ID, Author: "alice", Body: "Hello"}
+	presented := presentComment(&board, comment, "alice")
+	assert.Empty(t, presented.Author)
+	assert.True(t, presented.Mine)
+}
@@ -0,0 +1,658 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/bento-lab-ops/bentro/internal/database"
+	"github.com/bento-lab-ops/bentro/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Longest card group title, in characters
+const maxGroupTitleLength = 120
+
+// groupTitle trims a group title and reports whether its length is acceptable
+func groupTitle(title string) (string, bool) {
+	title = strings.TrimSpace(title)
+	return title, title != "" && len([]rune(title)) <= maxGroupTitleLength
+}
+
+// authorizeGroup loads a card group and checks the caller may contribute to its board
+func authorizeGroup(c *gin.Context) (models.CardGroup, BoardAccess, models.Board, bool) {
+	var group models.CardGroup
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid group ID"})
+		return group, BoardAccess{}, models.Board{}, false
+	}
+	if err := database.DB.First(&group, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Group not found"})
+		return group, BoardAccess{}, models.Board{}, false
+	}
+	board, err := boardForColumn(group.ColumnID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Board not found"})
+		return group, BoardAccess{}, board, false
+	}
+	access, ok := authorizeBoard(c, &board, BoardActionContribute)
+	return group, access, board, ok
+}
+
+// orderGroupsByPosition preloads groups in layout order
+func orderGroupsByPosition(db *gorm.DB) *gorm.DB {
+	return db.Order("position asc")
+}
+
+// groupSubtree returns the IDs of a group and of every group nested in it
+func groupSubtree(tx *gorm.DB, groupID uuid.UUID) ([]uuid.UUID, error) {
+	ids := []uuid.UUID{groupID}
+	frontier := []uuid.UUID{groupID}
+	for len(frontier) > 0 {
+		var children []uuid.UUID
+		if err := tx.Model(&models.CardGroup{}).Where("parent_id IN ?", frontier).Pluck("id", &children).Error; err != nil {
+			return nil, err
+		}
+		ids = append(ids, children...)
+		frontier = children
+	}
+	return ids, nil
+}
+
+// groupSiblings returns the groups of a column sharing a parent, in layout
+// order, leaving out the given group
+func groupSiblings(tx *gorm.DB, columnID uuid.UUID, parentID *uuid.UUID, except uuid.UUID) ([]models.CardGroup, error) {
+	query := tx.Where("column_id = ? AND id <> ?", columnID, except)
+	if parentID != nil {
+		query = query.Where("parent_id = ?", *parentID)
+	} else {
+		query = query.Where("parent_id IS NULL")
+	}
+	var siblings []models.CardGroup
+	err := query.Order("position asc").Find(&siblings).Error
+	return siblings, err
+}
+
+// renumberGroups numbers groups from 0 in their order, skipping the position
+// at gap so a group can be placed there. A gap past the end skips nothing.
+func renumberGroups(tx *gorm.DB, groups []models.CardGroup, gap int) error {
+	for i, group := range groups {
+		index := i
+		if i >= gap {
+			index++
+		}
+		if group.Position != index {
+			if err := tx.Model(&models.CardGroup{}).Where("id = ?", group.ID).Update("position", index).Error; err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// renumberCards numbers cards from 0 in their order
+func renumberCards(tx *gorm.DB, cards []models.Card) error {
+	for i, card := range cards {
+		if card.Position != i {
+			if err := tx.Model(&models.Card{}).Where("id = ?", card.ID).Update("position", i).Error; err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// nextGroupPosition returns the position after the last group sharing a parent
+func nextGroupPosition(tx *gorm.DB, columnID uuid.UUID, parentID *uuid.UUID) int {
+	query := tx.Model(&models.CardGroup{}).Where("column_id = ?", columnID)
+	if parentID != nil {
+		query = query.Where("parent_id = ?", *parentID)
+	} else {
+		query = query.Where("parent_id IS NULL")
+	}
+	var maxPos int
+	if err := query.Select("COALESCE(MAX(position), -1)").Scan(&maxPos).Error; err != nil {
+		return 0
+	}
+	return maxPos + 1
+}
+
+// sameGroup reports whether two optional group IDs name the same group, or both none
+func sameGroup(a, b *uuid.UUID) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+// pruneEmptyGroups deletes a group left without cards or subgroups, then its
+// parents if that leaves them empty too. It returns the deleted groups.
+func pruneEmptyGroups(tx *gorm.DB, groupID *uuid.UUID) ([]models.CardGroup, error) {
+	var deleted []models.CardGroup
+	for groupID != nil {
+		var group models.CardGroup
+		if err := tx.First(&group, *groupID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				break
+			}
+			return nil, err
+		}
+		var cards, groups int64
+		if err := tx.Model(&models.Card{}).Where("group_id = ?", group.ID).Count(&cards).Error; err != nil {
+			return nil, err
+		}
+		if err := tx.Model(&models.CardGroup{}).Where("parent_id = ?", group.ID).Count(&groups).Error; err != nil {
+			return nil, err
+		}
+		if cards+groups > 0 {
+			break
+		}
+		if err := tx.Delete(&group).Error; err != nil {
+			return nil, err
+		}
+		deleted = append(deleted, group)
+		groupID = group.ParentID
+	}
+	return deleted, nil
+}
+
+// pruneGroups prunes each of the groups cards were taken out of
+func pruneGroups(tx *gorm.DB, groupIDs []uuid.UUID) ([]models.CardGroup, error) {
+	var deleted []models.CardGroup
+	for _, id := range groupIDs {
+		id := id
+		pruned, err := pruneEmptyGroups(tx, &id)
+		if err != nil {
+			return nil, err
+		}
+		deleted = append(deleted, pruned...)
+	}
+	return deleted, nil
+}
+
+// broadcastPrunedGroups announces the groups pruneEmptyGroups deleted
+func broadcastPrunedGroups(boardID uuid.UUID, groups []models.CardGroup) {
+	for _, group := range groups {
+		BroadcastGroupDeleted(boardID, group.ID, group.ParentID)
+	}
+}
+
+// groupCards moves cards into a group, and into the group's column, pruning
+// the groups they leave empty. Cards already in the group are left alone.
+func groupCards(tx *gorm.DB, group models.CardGroup, cardIDs []uuid.UUID) ([]models.CardGroup, error) {
+	var former []uuid.UUID
+	if err := tx.Model(&models.Card{}).
+		Where("id IN ? AND group_id IS NOT NULL AND group_id <> ?", cardIDs, group.ID).
+		Distinct().Pluck("group_id", &former).Error; err != nil {
+		return nil, err
+	}
+	if err := tx.Model(&models.Card{}).Where("id IN ?", cardIDs).Updates(map[string]interface{}{
+		"group_id":  group.ID,
+		"column_id": group.ColumnID,
+	}).Error; err != nil {
+		return nil, err
+	}
+	return pruneGroups(tx, former)
+}
+
+// mergeIntoGroup merges cards into the group of a target card, creating a group
+// titled after the target if it has none. The title stays empty while the
+// target's content is hidden by private writing, see titleRevealedGroups.
+func mergeIntoGroup(tx *gorm.DB, board *models.Board, target models.Card, cardIDs []uuid.UUID) (models.CardGroup, bool, []models.CardGroup, error) {
+	var group models.CardGroup
+	created := false
+	if target.GroupID != nil {
+		if err := tx.First(&group, *target.GroupID).Error; err != nil {
+			return group, false, nil, err
+		}
+	} else {
+		group = models.CardGroup{ColumnID: target.ColumnID, Position: nextGroupPosition(tx, target.ColumnID, nil)}
+		if cardsHidden(board) {
+			group.TitleCardID = &target.ID
+		} else {
+			group.Title = target.Content
+		}
+		if err := tx.Create(&group).Error; err != nil {
+			return group, false, nil, err
+		}
+		// Someone else may have grouped the target meanwhile
+		result := tx.Model(&models.Card{}).Where("id = ? AND group_id IS NULL", target.ID).Update("group_id", group.ID)
+		if result.Error != nil {
+			return group, false, nil, result.Error
+		}
+		if result.RowsAffected == 0 {
+			return group, false, nil, ErrMergeConflict
+		}
+		created = true
+	}
+	pruned, err := groupCards(tx, group, cardIDs)
+	return group, created, pruned, err
+}
+
+// titleRevealedGroups titles the groups merged while a board's cards were
+// hidden after the card they were merged onto, or after their oldest card if
+// that one is gone. It returns the groups it titled.
+func titleRevealedGroups(tx *gorm.DB, boardID uuid.UUID) ([]models.CardGroup, error) {
+	var groups []models.CardGroup
+	if err := tx.Joins("JOIN columns ON columns.id = card_groups.column_id").
+		Where("columns.board_id = ? AND card_groups.title = ''", boardID).
+		Find(&groups).Error; err != nil {
+		return nil, err
+	}
+	titled := groups[:0]
+	for _, group := range groups {
+		var card models.Card
+		err := gorm.ErrRecordNotFound
+		if group.TitleCardID != nil {
+			err = tx.Where("id = ? AND group_id = ?", *group.TitleCardID, group.ID).First(&card).Error
+		}
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			err = tx.Where("group_id = ?", group.ID).Order("created_at asc, id asc").First(&card).Error
+		}
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		group.Title, group.TitleCardID = card.Content, nil
+		if err := tx.Model(&models.CardGroup{}).Where("id = ?", group.ID).Updates(map[string]interface{}{
+			"title":         group.Title,
+			"title_card_id": nil,
+		}).Error; err != nil {
+			return nil, err
+		}
+		titled = append(titled, group)
+	}
+	return titled, nil
+}
+
+// aggregateGroups fills the card, vote and reaction totals of a loaded board's
+// groups. The cards of nested groups count towards every group above them,
+// and votes are tallied by voter as described in vote_aggregation.go.
+func aggregateGroups(board *models.Board) {
+	for i := range board.Columns {
+		column := &board.Columns[i]
+		index := indexGroups(column.Groups)
+		position := make(map[uuid.UUID]int, len(column.Groups))
+		for j := range column.Groups {
+			position[column.Groups[j].ID] = j
+		}
+		for _, card := range column.Cards {
+			for _, id := range groupChain(index, card.GroupID) {
+				group := &column.Groups[position[id]]
+				group.CardCount++
+				group.ReactionCount += len(card.Reactions)
+			}
+		}
+		tallies := tallyGroupVotes(column.Groups, column.Cards, votingMethodOf(board).Weighted())
+		for j := range column.Groups {
+			group := &column.Groups[j]
+			group.VoteCount = tallies[group.ID].Likes
+			group.DislikeCount = tallies[group.ID].Dislikes
+		}
+	}
+}
+
+// CreateCardGroup creates a named group in a column, optionally nested in
+// another group and holding cards of the column
+func CreateCardGroup(c *gin.Context) {
+	columnID, err := uuid.Parse(c.Param("columnId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid column ID"})
+		return
+	}
+
+	var input struct {
+		Title    string      `json:"title" binding:"required"`
+		ParentID *uuid.UUID  `json:"parent_id"`
+		CardIDs  []uuid.UUID `json:"card_ids"`
+	}
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	title, ok := groupTitle(input.Title)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Title must be between 1 and 120 characters"})
+		return
+	}
+
+	board, err := boardForColumn(columnID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Column not found"})
+		return
+	}
+	if _, ok := authorizeBoard(c, &board, BoardActionContribute); !ok {
+		return
+	}
+	if !requirePhase(c, &board, PhaseActionMerge, "Cards cannot be grouped in the "+boardPhase(board.Phase)+" phase") {
+		return
+	}
+
+	if input.ParentID != nil {
+		var parent models.CardGroup
+		if err := database.DB.Where("id = ? AND column_id = ?", *input.ParentID, columnID).First(&parent).Error; err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Parent group must belong to the same column"})
+			return
+		}
+	}
+	if len(input.CardIDs) > 0 {
+		var count int64
+		database.DB.Model(&models.Card{}).Where("id IN ? AND column_id = ?", input.CardIDs, columnID).Count(&count)
+		if int(count) != len(input.CardIDs) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Cards must belong to the same column"})
+			return
+		}
+	}
+
+	group := models.CardGroup{ColumnID: columnID, ParentID: input.ParentID, Title: title}
+	var pruned []models.CardGroup
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		group.Position = nextGroupPosition(tx, columnID, input.ParentID)
+		if err := tx.Create(&group).Error; err != nil {
+			return err
+		}
+		if len(input.CardIDs) == 0 {
+			return nil
+		}
+		var err error
+		pruned, err = groupCards(tx, group, input.CardIDs)
+		return err
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create group"})
+		return
+	}
+
+	BroadcastGroupChanged(board.ID, group, "created")
+	if len(input.CardIDs) > 0 {
+		var cards []models.Card
+		database.DB.Where("id IN ?", input.CardIDs).Find(&cards)
+		for _, card := range cards {
+			BroadcastCardUpdated(board.ID, card)
+		}
+	}
+	broadcastPrunedGroups(board.ID, pruned)
+
+	c.JSON(http.StatusCreated, group)
+}
+
+// UpdateCardGroup renames a group
+func UpdateCardGroup(c *gin.Context) {
+	group, _, board, ok := authorizeGroup(c)
+	if !ok {
+		return
+	}
+	if !requirePhase(c, &board, PhaseActionMerge, "Cards cannot be grouped in the "+boardPhase(board.Phase)+" phase") {
+		return
+	}
+
+	var input struct {
+		Title string `json:"title" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	title, valid := groupTitle(input.Title)
+	if !valid {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Title must be between 1 and 120 characters"})
+		return
+	}
+
+	group.Title = title
+	if err := database.DB.Model(&group).Update("title", title).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update group"})
+		return
+	}
+
+	BroadcastGroupChanged(board.ID, group, "updated")
+	c.JSON(http.StatusOK, group)
+}
+
+// MoveCardGroup moves a group, with its cards and nested groups, to a position
+// in a column, at the top level or inside another group
+func MoveCardGroup(c *gin.Context) {
+	group, _, board, ok := authorizeGroup(c)
+	if !ok {
+		return
+	}
+	if !requirePhase(c, &board, PhaseActionMerge, "Cards cannot be grouped in the "+boardPhase(board.Phase)+" phase") {
+		return
+	}
+
+	var input struct {
+		ColumnID uuid.UUID  `json:"column_id" binding:"required"`
+		ParentID *uuid.UUID `json:"parent_id"`
+		Position int        `json:"position"`
+	}
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Groups can only move between columns of the same board
+	if targetBoard, err := boardForColumn(input.ColumnID); err != nil || targetBoard.ID != board.ID {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Target column does not belong to this board"})
+		return
+	}
+
+	subtree, err := groupSubtree(database.DB, group.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to move group"})
+		return
+	}
+	if input.ParentID != nil {
+		for _, id := range subtree {
+			if id == *input.ParentID {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "A group cannot be nested in itself"})
+				return
+			}
+		}
+		var parent models.CardGroup
+		if err := database.DB.Where("id = ? AND column_id = ?", *input.ParentID, input.ColumnID).First(&parent).Error; err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Parent group must belong to the target column"})
+			return
+		}
+	}
+
+	formerColumn, formerParent := group.ColumnID, group.ParentID
+	var pruned []models.CardGroup
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		// Siblings at the destination, ordered by position, with the group inserted
+		siblings, err := groupSiblings(tx, input.ColumnID, input.ParentID, group.ID)
+		if err != nil {
+			return err
+		}
+		position := input.Position
+		if position < 0 {
+			position = 0
+		}
+		if position > len(siblings) {
+			position = len(siblings)
+		}
+		if err := renumberGroups(tx, siblings, position); err != nil {
+			return err
+		}
+		// Siblings the group leaves close the gap behind it
+		if formerColumn != input.ColumnID || !sameGroup(formerParent, input.ParentID) {
+			former, err := groupSiblings(tx, formerColumn, formerParent, group.ID)
+			if err != nil {
+				return err
+			}
+			if err := renumberGroups(tx, former, len(former)); err != nil {
+				return err
+			}
+		}
+
+		if err := tx.Model(&models.CardGroup{}).Where("id = ?", group.ID).Updates(map[string]interface{}{
+			"column_id": input.ColumnID,
+			"parent_id": input.ParentID,
+			"position":  position,
+		}).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.CardGroup{}).Where("id IN ?", subtree).Update("column_id", input.ColumnID).Error; err != nil {
+			return err
+		}
+		if formerColumn != input.ColumnID {
+			// The group's cards go after the cards of the target column, and
+			// the cards left behind close up
+			var target, moved, left []models.Card
+			if err := tx.Where("column_id = ?", input.ColumnID).Order("position asc").Find(&target).Error; err != nil {
+				return err
+			}
+			if err := tx.Where("group_id IN ?", subtree).Order("position asc").Find(&moved).Error; err != nil {
+				return err
+			}
+			if err := tx.Model(&models.Card{}).Where("group_id IN ?", subtree).Update("column_id", input.ColumnID).Error; err != nil {
+				return err
+			}
+			if err := renumberCards(tx, append(target, moved...)); err != nil {
+				return err
+			}
+			if err := tx.Where("column_id = ?", formerColumn).Order("position asc").Find(&left).Error; err != nil {
+				return err
+			}
+			if err := renumberCards(tx, left); err != nil {
+				return err
+			}
+		}
+		group.ColumnID, group.ParentID, group.Position = input.ColumnID, input.ParentID, position
+
+		if formerParent != nil && !sameGroup(formerParent, input.ParentID) {
+			pruned, err = pruneEmptyGroups(tx, formerParent)
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to move group"})
+		return
+	}
+
+	BroadcastGroupMoved(board.ID, group)
+	broadcastPrunedGroups(board.ID, pruned)
+
+	c.JSON(http.StatusOK, group)
+}
+
+// DeleteCardGroup dissolves a group: its cards and nested groups move up to the
+// group's parent, or out of any group
+func DeleteCardGroup(c *gin.Context) {
+	group, _, board, ok := authorizeGroup(c)
+	if !ok {
+		return
+	}
+	if !requirePhase(c, &board, PhaseActionMerge, "Cards cannot be grouped in the "+boardPhase(board.Phase)+" phase") {
+		return
+	}
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.Card{}).Where("group_id = ?", group.ID).Update("group_id", group.ParentID).Error; err != nil {
+			return err
+		}
+		// Nested groups move up after the groups already there, which close
+		// the gap the group leaves
+		siblings, err := groupSiblings(tx, group.ColumnID, group.ParentID, group.ID)
+		if err != nil {
+			return err
+		}
+		children, err := groupSiblings(tx, group.ColumnID, &group.ID, group.ID)
+		if err != nil {
+			return err
+		}
+		if err := tx.Model(&models.CardGroup{}).Where("parent_id = ?", group.ID).Update("parent_id", group.ParentID).Error; err != nil {
+			return err
+		}
+		promoted := append(siblings, children...)
+		if err := renumberGroups(tx, promoted, len(promoted)); err != nil {
+			return err
+		}
+		return tx.Delete(&group).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete group"})
+		return
+	}
+
+	BroadcastGroupDeleted(board.ID, group.ID, group.ParentID)
+	c.JSON(http.StatusOK, gin.H{"message": "Group deleted successfully"})
+}
+
+// SetCardGroup moves a card into a group, or out of its group when group_id is
+// null. A card joining a group of another column moves to that column.
+func SetCardGroup(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid card ID"})
+		return
+	}
+
+	var input struct {
+		GroupID *uuid.UUID `json:"group_id"`
+	}
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var card models.Card
+	if err := database.DB.First(&card, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Card not found"})
+		return
+	}
+	access, board, ok := authorizeCard(c, card.ID)
+	if !ok {
+		return
+	}
+	if !requirePhase(c, &board, PhaseActionMerge, "Cards cannot be grouped in the "+boardPhase(board.Phase)+" phase") {
+		return
+	}
+
+	var group models.CardGroup
+	if input.GroupID != nil {
+		if err := database.DB.First(&group, *input.GroupID).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Group not found"})
+			return
+		}
+		if groupBoard, err := boardForColumn(group.ColumnID); err != nil || groupBoard.ID != board.ID {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Group must belong to the same board"})
+			return
+		}
+	}
+
+	formerGroup := card.GroupID
+	var pruned []models.CardGroup
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		if input.GroupID != nil {
+			pruned, err = groupCards(tx, group, []uuid.UUID{card.ID})
+			return err
+		}
+		if err := tx.Model(&models.Card{}).Where("id = ?", card.ID).Update("group_id", nil).Error; err != nil {
+			return err
+		}
+		pruned, err = pruneEmptyGroups(tx, formerGroup)
+		return err
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to group card"})
+		return
+	}
+	database.DB.First(&card, card.ID)
+
+	BroadcastCardUpdated(board.ID, card)
+	broadcastPrunedGroups(board.ID, pruned)
+
+	c.JSON(http.StatusOK, presentCard(&board, card, access.Username))
+}
@@ -0,0 +1,169 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/bento-lab-ops/bentro/internal/models"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCardGroups(t *testing.T) {
+	db, r := setupPolicyTest(t)
+	r.POST("/columns/:columnId/groups", CreateCardGroup)
+	r.PUT("/groups/:id", UpdateCardGroup)
+	r.PUT("/groups/:id/move", MoveCardGroup)
+	r.DELETE("/groups/:id", DeleteCardGroup)
+	r.PUT("/cards/:id/group", SetCardGroup)
+	r.PUT("/cards/:id/move", MoveCard)
+	owner := createTestUser(db, "owner", "user")
+
+	board := models.Board{ID: uuid.New(), Name: "Grouping", Owner: "owner", Status: "active", Phase: PhaseGrouping}
+	db.Create(&board)
+	wrong := models.Column{ID: uuid.New(), BoardID: board.ID, Name: "Went wrong"}
+	ideas := models.Column{ID: uuid.New(), BoardID: board.ID, Name: "Ideas", Position: 1}
+	db.Create(&wrong)
+	db.Create(&ideas)
+	build := models.Card{ID: uuid.New(), ColumnID: wrong.ID, Content: "Builds are slow"}
+	flaky := models.Card{ID: uuid.New(), ColumnID: wrong.ID, Content: "Flaky tests"}
+	runners := models.Card{ID: uuid.New(), ColumnID: wrong.ID, Content: "Not enough CI runners"}
+	cache := models.Card{ID: uuid.New(), ColumnID: ideas.ID, Content: "Cache dependencies"}
+	for _, card := range []*models.Card{&build, &flaky, &runners, &cache} {
+		db.Create(card)
+	}
+	db.Create(&models.Vote{CardID: build.ID, UserName: "alice", VoteType: "like"})
+	db.Create(&models.Vote{CardID: build.ID, UserName: "bob", VoteType: "like"})
+	db.Create(&models.Vote{CardID: runners.ID, UserName: "alice", VoteType: "like"})
+
+	send := func(method, path string, body interface{}) *httptest.ResponseRecorder {
+		var payload []byte
+		if body != nil {
+			payload, _ = json.Marshal(body)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, asUser(httptest.NewRequest(method, path, bytes.NewBuffer(payload)), owner))
+		return w
+	}
+	createGroup := func(body map[string]interface{}) models.CardGroup {
+		w := send("POST", "/columns/"+wrong.ID.String()+"/groups", body)
+		assert.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+		var group models.CardGroup
+		json.Unmarshal(w.Body.Bytes(), &group)
+		return group
+	}
+	groupOf := func(card models.Card) *uuid.UUID {
+		var current models.Card
+		db.First(&current, card.ID)
+		return current.GroupID
+	}
+
+	assert.Equal(t, http.StatusBadRequest, send("POST", "/columns/"+wrong.ID.String()+"/groups", map[string]interface{}{
+		"title": strings.Repeat("x", maxGroupTitleLength+1),
+	}).Code)
+	assert.Equal(t, http.StatusBadRequest, send("POST", "/columns/"+wrong.ID.String()+"/groups", map[string]interface{}{
+		"title": "Tooling", "card_ids": []uuid.UUID{cache.ID},
+	}).Code)
+
+	// A named group, and a group nested in it
+	tooling := createGroup(map[string]interface{}{"title": " Tooling ", "card_ids": []uuid.UUID{build.ID, flaky.ID}})
+	assert.Equal(t, "Tooling", tooling.Title)
+	ci := createGroup(map[string]interface{}{"title": "CI", "parent_id": tooling.ID, "card_ids": []uuid.UUID{runners.ID}})
+	assert.Equal(t, tooling.ID, *ci.ParentID)
+	assert.Equal(t, tooling.ID, *groupOf(build))
+
+	// Totals include the cards of nested groups, and count alice's two likes once
+	w := send("GET", "/boards/"+board.ID.String(), nil)
+	var loaded models.Board
+	json.Unmarshal(w.Body.Bytes(), &loaded)
+	totals := map[uuid.UUID]models.CardGroup{}
+	for _, column := range loaded.Columns {
+		for _, group := range column.Groups {
+			totals[group.ID] = group
+		}
+	}
+	assert.Equal(t, 3, totals[tooling.ID].CardCount)
+	assert.Equal(t, 2, totals[tooling.ID].VoteCount)
+	assert.Equal(t, 1, totals[ci.ID].CardCount)
+	assert.Equal(t, 1, totals[ci.ID].VoteCount)
+
+	w = send("PUT", "/groups/"+tooling.ID.String(), map[string]interface{}{"title": "Dev tooling"})
+	assert.Equal(t, http.StatusOK, w.Code)
+	var renamed models.CardGroup
+	db.First(&renamed, tooling.ID)
+	assert.Equal(t, "Dev tooling", renamed.Title)
+
+	// A group cannot end up inside itself
+	assert.Equal(t, http.StatusBadRequest, send("PUT", "/groups/"+tooling.ID.String()+"/move", map[string]interface{}{
+		"column_id": wrong.ID, "parent_id": ci.ID,
+	}).Code)
+
+	// Moving a group carries its cards and nested groups along, and closes the gap it leaves
+	process := createGroup(map[string]interface{}{"title": "Process"})
+	assert.Equal(t, 1, process.Position)
+	assert.Equal(t, http.StatusOK, send("PUT", "/groups/"+tooling.ID.String()+"/move", map[string]interface{}{
+		"column_id": ideas.ID,
+	}).Code)
+	db.First(&process, process.ID)
+	assert.Equal(t, 0, process.Position)
+	assert.Equal(t, http.StatusOK, send("DELETE", "/groups/"+process.ID.String(), nil).Code)
+	var moved []models.Card
+	db.Where("id IN ?", []uuid.UUID{build.ID, flaky.ID, runners.ID}).Find(&moved)
+	positions := map[int]bool{}
+	for _, card := range moved {
+		assert.Equal(t, ideas.ID, card.ColumnID, card.Content)
+		positions[card.Position] = true
+	}
+	// ...placing the cards after those of the target column
+	assert.Equal(t, map[int]bool{1: true, 2: true, 3: true}, positions)
+	db.First(&cache, cache.ID)
+	assert.Equal(t, 0, cache.Position)
+	var nested models.CardGroup
+	db.First(&nested, ci.ID)
+	assert.Equal(t, ideas.ID, nested.ColumnID)
+
+	// Cards join groups of another column by moving there
+	assert.Equal(t, http.StatusOK, send("PUT", "/cards/"+cache.ID.String()+"/group", map[string]interface{}{"group_id": ci.ID}).Code)
+	assert.Equal(t, ci.ID, *groupOf(cache))
+
+	assert.Equal(t, http.StatusOK, send("PUT", "/cards/"+flaky.ID.String()+"/group", map[string]interface{}{"group_id": nil}).Code)
+	assert.Nil(t, groupOf(flaky))
+
+	// Deleting a group moves its content up a level, after the groups there
+	docs := models.CardGroup{ID: uuid.New(), ColumnID: ideas.ID, Title: "Docs", Position: 1}
+	db.Create(&docs)
+	assert.Equal(t, http.StatusOK, send("DELETE", "/groups/"+tooling.ID.String(), nil).Code)
+	assert.Nil(t, groupOf(build))
+	var promoted models.CardGroup
+	db.First(&promoted, ci.ID)
+	assert.Nil(t, promoted.ParentID)
+	assert.Equal(t, 1, promoted.Position)
+	db.First(&docs, docs.ID)
+	assert.Equal(t, 0, docs.Position)
+	assert.Equal(t, http.StatusOK, send("DELETE", "/groups/"+docs.ID.String(), nil).Code)
+
+	// Taking the last card out of a group deletes the group
+	assert.Equal(t, http.StatusOK, send("PUT", "/cards/"+runners.ID.String()+"/group", map[string]interface{}{"group_id": nil}).Code)
+	assert.Equal(t, http.StatusOK, send("PUT", "/cards/"+cache.ID.String()+"/group", map[string]interface{}{"group_id": nil}).Code)
+	var remaining int64
+	db.Model(&models.CardGroup{}).Count(&remaining)
+	assert.Equal(t, int64(0), remaining)
+
+	// Grouping follows the phase rules
+	db.Model(&board).Update("phase", PhaseVoting)
+	assert.Equal(t, http.StatusConflict, send("PUT", "/cards/"+build.ID.String()+"/group", map[string]interface{}{"group_id": nil}).Code)
+	late := models.CardGroup{ID: uuid.New(), ColumnID: wrong.ID, Title: "Late"}
+	db.Create(&late)
+	assert.Equal(t, http.StatusConflict, send("PUT", "/groups/"+late.ID.String(), map[string]interface{}{"title": "Later"}).Code)
+	assert.Equal(t, http.StatusConflict, send("PUT", "/groups/"+late.ID.String()+"/move", map[string]interface{}{"column_id": ideas.ID}).Code)
+	assert.Equal(t, http.StatusConflict, send("DELETE", "/groups/"+late.ID.String(), nil).Code)
+	db.Model(&build).Updates(map[string]interface{}{"group_id": late.ID, "column_id": wrong.ID})
+	assert.Equal(t, http.StatusConflict, send("PUT", "/cards/"+build.ID.String()+"/move", map[string]interface{}{"column_id": ideas.ID}).Code)
+	assert.Equal(t, http.StatusOK, send("PUT", "/cards/"+build.ID.String()+"/move", map[string]interface{}{"column_id": wrong.ID, "position": 1}).Code)
+	assert.Equal(t, late.ID, *groupOf(build))
+}
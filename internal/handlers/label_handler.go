@@ -0,0 +1,429 @@
+package handlers
+
+import (
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/bento-lab-ops/bentro/internal/database"
+	"github.com/bento-lab-ops/bentro/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Longest label name, in characters
+const maxLabelNameLength = 40
+
+// Colour of labels created without one
+const defaultLabelColor = "#6b7280"
+
+var labelColorPattern = regexp.MustCompile(`^#[0-9a-fA-F]{6}$`)
+
+// boardTeamIDs returns the IDs of the teams a board belongs to
+func boardTeamIDs(boardID uuid.UUID) []uuid.UUID {
+	var teamIDs []uuid.UUID
+	database.DB.Table("board_teams").Where("board_id = ?", boardID).Pluck("team_id", &teamIDs)
+	return teamIDs
+}
+
+// boardLabelsQuery selects the labels available on a board: its own and its teams'
+func boardLabelsQuery(boardID uuid.UUID) *gorm.DB {
+	scope := database.DB.Where("board_id = ?", boardID)
+	if teamIDs := boardTeamIDs(boardID); len(teamIDs) > 0 {
+		scope = scope.Or("team_id IN ?", teamIDs)
+	}
+	// Grouped, so conditions chained by callers apply to both scopes
+	return database.DB.Model(&models.Label{}).Where(scope)
+}
+
+// boardLabels returns the labels available on a board, by name
+func boardLabels(boardID uuid.UUID) ([]models.Label, error) {
+	var labels []models.Label
+	err := boardLabelsQuery(boardID).Order("name asc").Find(&labels).Error
+	return labels, err
+}
+
+// labelBoardIDs returns the boards that show a label
+func labelBoardIDs(label models.Label) []uuid.UUID {
+	if label.BoardID != nil {
+		return []uuid.UUID{*label.BoardID}
+	}
+	var boardIDs []uuid.UUID
+	if label.TeamID != nil {
+		database.DB.Table("board_teams").Where("team_id = ?", *label.TeamID).Pluck("board_id", &boardIDs)
+	}
+	return boardIDs
+}
+
+// labelInput validates and normalises a label's name and colour, responding with 400 if unusable
+func labelInput(c *gin.Context, name, color string) (string, string, bool) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Label name is required"})
+		return "", "", false
+	}
+	if len([]rune(name)) > maxLabelNameLength {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Label name is too long"})
+		return "", "", false
+	}
+	if color == "" {
+		color = defaultLabelColor
+	}
+	if !labelColorPattern.MatchString(color) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Label color must be a #rrggbb hex color"})
+		return "", "", false
+	}
+	return name, strings.ToLower(color), true
+}
+
+// labelNameTaken reports whether the board already shows another label with the name
+func labelNameTaken(boardID uuid.UUID, name string, except uuid.UUID) bool {
+	var count int64
+	boardLabelsQuery(boardID).Where("LOWER(name) = ? AND id <> ?", strings.ToLower(name), except).Count(&count)
+	return count > 0
+}
+
+// findBoardLabel loads a label available on the board, responding with 404 if there is none
+func findBoardLabel(c *gin.Context, boardID uuid.UUID, labelID string) (models.Label, bool) {
+	var label models.Label
+	id, err := uuid.Parse(labelID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid label ID"})
+		return label, false
+	}
+	if err := boardLabelsQuery(boardID).Where("id = ?", id).First(&label).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Label not found"})
+		return label, false
+	}
+	return label, true
+}
+
+// labelFilter splits ?label= query values into label IDs and lower-cased label names
+func labelFilter(values []string) ([]uuid.UUID, []string) {
+	var ids []uuid.UUID
+	var names []string
+	for _, value := range values {
+		value = strings.TrimSpace(value)
+		if value == "" {
+			continue
+		}
+		if id, err := uuid.Parse(value); err == nil {
+			ids = append(ids, id)
+		} else {
+			names = append(names, strings.ToLower(value))
+		}
+	}
+	return ids, names
+}
+
+// hasAnyLabel reports whether a card carries one of the filtered labels
+func hasAnyLabel(card models.Card, ids []uuid.UUID, names []string) bool {
+	for _, label := range card.Labels {
+		for _, id := range ids {
+			if label.ID == id {
+				return true
+			}
+		}
+		for _, name := range names {
+			if strings.ToLower(label.Name) == name {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// filterBoardByLabels keeps the cards of a loaded board that carry one of the
+// labels, and the groups holding them
+func filterBoardByLabels(board *models.Board, values []string) {
+	ids, names := labelFilter(values)
+	if len(ids) == 0 && len(names) == 0 {
+		return
+	}
+	for i := range board.Columns {
+		column := &board.Columns[i]
+		parents := make(map[uuid.UUID]*uuid.UUID, len(column.Groups))
+		for _, group := range column.Groups {
+			parents[group.ID] = group.ParentID
+		}
+		kept := make(map[uuid.UUID]bool)
+		cards := column.Cards[:0]
+		for _, card := range column.Cards {
+			if !hasAnyLabel(card, ids, names) {
+				continue
+			}
+			cards = append(cards, card)
+			for id := card.GroupID; id != nil && !kept[*id]; id = parents[*id] {
+				kept[*id] = true
+			}
+		}
+		column.Cards = cards
+		groups := column.Groups[:0]
+		for _, group := range column.Groups {
+			if kept[group.ID] {
+				groups = append(groups, group)
+			}
+		}
+		column.Groups = groups
+	}
+}
+
+// labeledCardIDs selects the IDs of cards carrying one of the labels, for filtering card queries
+func labeledCardIDs(ids []uuid.UUID, names []string) *gorm.DB {
+	query := database.DB.Table("card_labels").
+		Select("card_labels.card_id").
+		Joins("JOIN labels ON labels.id = card_labels.label_id")
+	switch {
+	case len(ids) > 0 && len(names) > 0:
+		query = query.Where("labels.id IN ? OR LOWER(labels.name) IN ?", ids, names)
+	case len(ids) > 0:
+		query = query.Where("labels.id IN ?", ids)
+	default:
+		query = query.Where("LOWER(labels.name) IN ?", names)
+	}
+	return query
+}
+
+// ListBoardLabels returns the labels available on a board
+func ListBoardLabels(c *gin.Context) {
+	boardID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid board ID"})
+		return
+	}
+	var board models.Board
+	if err := database.DB.First(&board, boardID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Board not found"})
+		return
+	}
+
+	labels, err := boardLabels(board.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch labels"})
+		return
+	}
+
+	c.JSON(http.StatusOK, labels)
+}
+
+// CreateBoardLabel adds a label to a board's palette, or to the palette of one of
+// its teams when team_id is set (board managers only)
+func CreateBoardLabel(c *gin.Context) {
+	boardID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid board ID"})
+		return
+	}
+
+	var input struct {
+		Name   string     `json:"name" binding:"required"`
+		Color  string     `json:"color"`
+		TeamID *uuid.UUID `json:"team_id"`
+	}
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	name, color, ok := labelInput(c, input.Name, input.Color)
+	if !ok {
+		return
+	}
+
+	var board models.Board
+	if err := database.DB.First(&board, boardID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Board not found"})
+		return
+	}
+	if _, ok := authorizeBoard(c, &board, BoardActionManage); !ok {
+		return
+	}
+
+	label := models.Label{Name: name, Color: color}
+	if input.TeamID != nil {
+		teamScoped := false
+		for _, teamID := range boardTeamIDs(board.ID) {
+			if teamID == *input.TeamID {
+				teamScoped = true
+			}
+		}
+		if !teamScoped {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Board does not belong to this team"})
+			return
+		}
+		label.TeamID = input.TeamID
+	} else {
+		label.BoardID = &board.ID
+	}
+
+	for _, id := range labelBoardIDs(label) {
+		if labelNameTaken(id, name, uuid.Nil) {
+			c.JSON(http.StatusConflict, gin.H{"error": "A label with this name already exists"})
+			return
+		}
+	}
+
+	if err := database.DB.Create(&label).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create label"})
+		return
+	}
+
+	BroadcastLabelChanged(label, "created")
+	c.JSON(http.StatusCreated, label)
+}
+
+// UpdateBoardLabel renames or recolours a label of the board (board managers only)
+func UpdateBoardLabel(c *gin.Context) {
+	boardID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid board ID"})
+		return
+	}
+
+	var input struct {
+		Name  *string `json:"name"`
+		Color *string `json:"color"`
+	}
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var board models.Board
+	if err := database.DB.First(&board, boardID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Board not found"})
+		return
+	}
+	if _, ok := authorizeBoard(c, &board, BoardActionManage); !ok {
+		return
+	}
+	label, ok := findBoardLabel(c, board.ID, c.Param("labelId"))
+	if !ok {
+		return
+	}
+
+	name, color := label.Name, label.Color
+	if input.Name != nil {
+		name = *input.Name
+	}
+	if input.Color != nil {
+		color = *input.Color
+	}
+	name, color, ok = labelInput(c, name, color)
+	if !ok {
+		return
+	}
+	for _, id := range labelBoardIDs(label) {
+		if labelNameTaken(id, name, label.ID) {
+			c.JSON(http.StatusConflict, gin.H{"error": "A label with this name already exists"})
+			return
+		}
+	}
+
+	label.Name = name
+	label.Color = color
+	if err := database.DB.Save(&label).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update label"})
+		return
+	}
+
+	BroadcastLabelChanged(label, "updated")
+	c.JSON(http.StatusOK, label)
+}
+
+// DeleteBoardLabel deletes a label of the board and untags its cards (board managers only)
+func DeleteBoardLabel(c *gin.Context) {
+	boardID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid board ID"})
+		return
+	}
+
+	var board models.Board
+	if err := database.DB.First(&board, boardID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Board not found"})
+		return
+	}
+	if _, ok := authorizeBoard(c, &board, BoardActionManage); !ok {
+		return
+	}
+	label, ok := findBoardLabel(c, board.ID, c.Param("labelId"))
+	if !ok {
+		return
+	}
+
+	// Find the boards before the label is gone, team labels are resolved through it
+	boardIDs := labelBoardIDs(label)
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("label_id = ?", label.ID).Delete(&models.CardLabel{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&label).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete label"})
+		return
+	}
+
+	for _, id := range boardIDs {
+		BroadcastLabelDeleted(id, label.ID)
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Label deleted successfully"})
+}
+
+// cardLabelRequest resolves the card and label of a tag/untag request
+func cardLabelRequest(c *gin.Context) (models.Card, models.Label, models.Board, bool) {
+	var card models.Card
+	cardID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid card ID"})
+		return card, models.Label{}, models.Board{}, false
+	}
+	if err := database.DB.First(&card, cardID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Card not found"})
+		return card, models.Label{}, models.Board{}, false
+	}
+	_, board, ok := authorizeCard(c, card.ID)
+	if !ok {
+		return card, models.Label{}, board, false
+	}
+	label, ok := findBoardLabel(c, board.ID, c.Param("labelId"))
+	return card, label, board, ok
+}
+
+// TagCard adds a label to a card
+func TagCard(c *gin.Context) {
+	card, label, board, ok := cardLabelRequest(c)
+	if !ok {
+		return
+	}
+
+	cardLabel := models.CardLabel{CardID: card.ID, LabelID: label.ID}
+	if err := database.DB.Clauses(clause.OnConflict{DoNothing: true}).Create(&cardLabel).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to tag card"})
+		return
+	}
+
+	BroadcastCardLabeled(board, card.ID, label, true)
+	c.JSON(http.StatusOK, label)
+}
+
+// UntagCard removes a label from a card
+func UntagCard(c *gin.Context) {
+	card, label, board, ok := cardLabelRequest(c)
+	if !ok {
+		return
+	}
+
+	if err := database.DB.Where("card_id = ? AND label_id = ?", card.ID, label.ID).Delete(&models.CardLabel{}).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to untag card"})
+		return
+	}
+
+	BroadcastCardLabeled(board, card.ID, label, false)
+	c.JSON(http.StatusOK, gin.H{"message": "Label removed from card"})
+}
@@ -0,0 +1,139 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/bento-lab-ops/bentro/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func setupLabelTest(t *testing.T) (*gorm.DB, *gin.Engine) {
+	db, r := setupPolicyTest(t)
+	r.GET("/boards/:id/labels", ListBoardLabels)
+	r.POST("/boards/:id/labels", CreateBoardLabel)
+	r.PUT("/boards/:id/labels/:labelId", UpdateBoardLabel)
+	r.DELETE("/boards/:id/labels/:labelId", DeleteBoardLabel)
+	r.POST("/cards/:id/labels/:labelId", TagCard)
+	r.DELETE("/cards/:id/labels/:labelId", UntagCard)
+	r.GET("/action-items", GetGlobalActionItems)
+	return db, r
+}
+
+func TestBoardLabels(t *testing.T) {
+	db, r := setupLabelTest(t)
+	owner := createTestUser(db, "facilitator", "user")
+	alice := createTestUser(db, "alice", "user")
+
+	team := models.Team{ID: uuid.New(), Name: "Platform", OwnerID: owner.ID}
+	db.Create(&team)
+	board := models.Board{ID: uuid.New(), Name: "Sprint 1", Owner: "facilitator", Status: "active"}
+	db.Create(&board)
+	db.Model(&board).Association("Teams").Append(&team)
+	db.Create(&models.BoardMember{BoardID: board.ID, Username: "alice"})
+	col := models.Column{ID: uuid.New(), BoardID: board.ID, Name: "Col"}
+	db.Create(&col)
+	flaky := models.Card{ID: uuid.New(), ColumnID: col.ID, Content: "Flaky pipeline", IsActionItem: true}
+	db.Create(&flaky)
+	pager := models.Card{ID: uuid.New(), ColumnID: col.ID, Content: "Pager at 3am"}
+	db.Create(&pager)
+
+	send := func(user models.User, method, path string, body interface{}) *httptest.ResponseRecorder {
+		var payload []byte
+		if body != nil {
+			payload, _ = json.Marshal(body)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, asUser(httptest.NewRequest(method, path, bytes.NewBuffer(payload)), user))
+		return w
+	}
+	labelsPath := "/boards/" + board.ID.String() + "/labels"
+
+	// Managers build the palette, for the board or for one of its teams
+	assert.Equal(t, http.StatusForbidden, send(alice, "POST", labelsPath, map[string]string{"name": "CI"}).Code)
+	assert.Equal(t, http.StatusBadRequest, send(owner, "POST", labelsPath, map[string]string{"name": "CI", "color": "red"}).Code)
+	w := send(owner, "POST", labelsPath, map[string]string{"name": "CI", "color": "#FF0000"})
+	assert.Equal(t, http.StatusCreated, w.Code)
+	var ci models.Label
+	json.Unmarshal(w.Body.Bytes(), &ci)
+	assert.Equal(t, "#ff0000", ci.Color)
+	assert.Equal(t, http.StatusConflict, send(owner, "POST", labelsPath, map[string]string{"name": "ci"}).Code)
+
+	w = send(owner, "POST", labelsPath, map[string]interface{}{"name": "on-call", "team_id": team.ID})
+	assert.Equal(t, http.StatusCreated, w.Code)
+	var onCall models.Label
+	json.Unmarshal(w.Body.Bytes(), &onCall)
+	assert.Equal(t, team.ID, *onCall.TeamID)
+	assert.Equal(t, defaultLabelColor, onCall.Color)
+	assert.Equal(t, http.StatusBadRequest, send(owner, "POST", labelsPath, map[string]interface{}{"name": "x", "team_id": uuid.New()}).Code)
+
+	// Team labels show up on the team's other boards
+	other := models.Board{ID: uuid.New(), Name: "Sprint 2", Owner: "facilitator", Status: "active"}
+	db.Create(&other)
+	db.Model(&other).Association("Teams").Append(&team)
+	w = send(owner, "GET", "/boards/"+other.ID.String()+"/labels", nil)
+	var labels []models.Label
+	json.Unmarshal(w.Body.Bytes(), &labels)
+	if assert.Len(t, labels, 1) {
+		assert.Equal(t, "on-call", labels[0].Name)
+	}
+
+	// Contributors tag cards with labels of the board
+	assert.Equal(t, http.StatusOK, send(alice, "POST", "/cards/"+flaky.ID.String()+"/labels/"+ci.ID.String(), nil).Code)
+	assert.Equal(t, http.StatusOK, send(alice, "POST", "/cards/"+flaky.ID.String()+"/labels/"+ci.ID.String(), nil).Code)
+	assert.Equal(t, http.StatusOK, send(alice, "POST", "/cards/"+pager.ID.String()+"/labels/"+onCall.ID.String(), nil).Code)
+	foreign := models.Label{Name: "Elsewhere", Color: defaultLabelColor, BoardID: &other.ID}
+	db.Create(&foreign)
+	assert.Equal(t, http.StatusNotFound, send(alice, "POST", "/cards/"+pager.ID.String()+"/labels/"+foreign.ID.String(), nil).Code)
+
+	// The board filters its cards by label ID or name
+	w = send(alice, "GET", "/boards/"+board.ID.String()+"?label="+ci.ID.String(), nil)
+	var loaded models.Board
+	json.Unmarshal(w.Body.Bytes(), &loaded)
+	assert.Len(t, loaded.Labels, 2)
+	if assert.Len(t, loaded.Columns[0].Cards, 1) {
+		assert.Equal(t, flaky.ID, loaded.Columns[0].Cards[0].ID)
+		assert.Len(t, loaded.Columns[0].Cards[0].Labels, 1)
+	}
+	w = send(alice, "GET", "/boards/"+board.ID.String()+"?label=On-Call", nil)
+	loaded = models.Board{}
+	json.Unmarshal(w.Body.Bytes(), &loaded)
+	if assert.Len(t, loaded.Columns[0].Cards, 1) {
+		assert.Equal(t, pager.ID, loaded.Columns[0].Cards[0].ID)
+	}
+
+	// Action items filter by label too, and carry their labels
+	var items []struct {
+		ID     uuid.UUID      `json:"id"`
+		Labels []models.Label `json:"labels"`
+	}
+	w = send(alice, "GET", "/action-items?label=ci", nil)
+	json.Unmarshal(w.Body.Bytes(), &items)
+	if assert.Len(t, items, 1) && assert.Len(t, items[0].Labels, 1) {
+		assert.Equal(t, "CI", items[0].Labels[0].Name)
+	}
+	items = nil
+	w = send(alice, "GET", "/action-items?label=on-call", nil)
+	json.Unmarshal(w.Body.Bytes(), &items)
+	assert.Len(t, items, 0)
+
+	// Renaming and deleting a label
+	w = send(owner, "PUT", labelsPath+"/"+ci.ID.String(), map[string]string{"name": "Pipeline"})
+	assert.Equal(t, http.StatusOK, w.Code)
+	json.Unmarshal(w.Body.Bytes(), &ci)
+	assert.Equal(t, "Pipeline", ci.Name)
+	assert.Equal(t, "#ff0000", ci.Color)
+
+	assert.Equal(t, http.StatusOK, send(alice, "DELETE", "/cards/"+pager.ID.String()+"/labels/"+onCall.ID.String(), nil).Code)
+	assert.Equal(t, http.StatusOK, send(owner, "DELETE", labelsPath+"/"+ci.ID.String(), nil).Code)
+	var tags int64
+	db.Model(&models.CardLabel{}).Count(&tags)
+	assert.Equal(t, int64(0), tags)
+}
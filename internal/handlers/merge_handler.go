@@ -0,0 +1,287 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"sort"
+	"strconv"
+
+	"github.com/bento-lab-ops/bentro/internal/database"
+	"github.com/bento-lab-ops/bentro/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Text similarity above which two cards are suggested as duplicates
+const (
+	defaultDuplicateSimilarity = 0.6
+	minDuplicateSimilarity     = 0.3 // Lower bound of ?similarity=
+)
+
+// ErrMergeConflict means the target of a merge was grouped concurrently
+var ErrMergeConflict = errors.New("cards changed while merging")
+
+// duplicateGroups groups texts whose trigrams are all pairwise similar: the most
+// similar pairs are joined first, and two groups only join if every text of one
+// is similar to every text of the other, so A~B and B~C do not chain A to C.
+// Groups of one are left out; each group reports its lowest pairwise similarity.
+func duplicateGroups(texts []string, threshold float64) ([][]int, []float64) {
+	grams := make([]map[string]bool, len(texts))
+	for i, text := range texts {
+		grams[i] = trigrams(normalizeText(text))
+	}
+	similarity := make([][]float64, len(texts))
+	type pair struct {
+		i, j  int
+		score float64
+	}
+	var pairs []pair
+	for i := range texts {
+		similarity[i] = make([]float64, len(texts))
+		for j := 0; j < i; j++ {
+			score := jaccard(grams[i], grams[j])
+			similarity[i][j], similarity[j][i] = score, score
+			if score >= threshold {
+				pairs = append(pairs, pair{j, i, score})
+			}
+		}
+	}
+	sort.SliceStable(pairs, func(a, b int) bool { return pairs[a].score > pairs[b].score })
+
+	groupOf := make([]int, len(texts))
+	groups := make([][]int, len(texts))
+	for i := range texts {
+		groupOf[i] = i
+		groups[i] = []int{i}
+	}
+	for _, p := range pairs {
+		gi, gj := groupOf[p.i], groupOf[p.j]
+		if gi == gj {
+			continue
+		}
+		linked := true
+		for _, a := range groups[gi] {
+			for _, b := range groups[gj] {
+				if similarity[a][b] < threshold {
+					linked = false
+				}
+			}
+		}
+		if !linked {
+			continue
+		}
+		for _, b := range groups[gj] {
+			groupOf[b] = gi
+		}
+		groups[gi] = append(groups[gi], groups[gj]...)
+		groups[gj] = nil
+	}
+
+	var result [][]int
+	var scores []float64
+	for _, group := range groups {
+		if len(group) < 2 {
+			continue
+		}
+		sort.Ints(group)
+		lowest := 1.0
+		for x, a := range group {
+			for _, b := range group[x+1:] {
+				if similarity[a][b] < lowest {
+					lowest = similarity[a][b]
+				}
+			}
+		}
+		result = append(result, group)
+		scores = append(scores, lowest)
+	}
+	return result, scores
+}
+
+// GetMergeSuggestions returns groups of near-duplicate cards per column, for
+// merging in one step instead of dragging cards one by one
+func GetMergeSuggestions(c *gin.Context) {
+	boardID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid board ID"})
+		return
+	}
+	threshold := defaultDuplicateSimilarity
+	if value := c.Query("similarity"); value != "" {
+		threshold, err = strconv.ParseFloat(value, 64)
+		if err != nil || threshold < minDuplicateSimilarity || threshold > 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "similarity must be between 0.3 and 1"})
+			return
+		}
+	}
+
+	var board models.Board
+	if err := database.DB.First(&board, boardID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Board not found"})
+		return
+	}
+	if _, ok := authorizeBoard(c, &board, BoardActionContribute); !ok {
+		return
+	}
+	if cardsHidden(&board) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Merge suggestions are available once the cards are revealed"})
+		return
+	}
+
+	var cards []models.Card
+	if err := database.DB.
+		Joins("JOIN columns ON columns.id = cards.column_id").
+		Where("columns.board_id = ?", board.ID).
+		Order("cards.created_at asc").
+		Find(&cards).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch cards"})
+		return
+	}
+	groupSize := make(map[uuid.UUID]int)
+	var columnIDs []uuid.UUID
+	byColumn := make(map[uuid.UUID][]models.Card)
+	for _, card := range cards {
+		if card.GroupID != nil {
+			groupSize[*card.GroupID]++
+		}
+		if _, ok := byColumn[card.ColumnID]; !ok {
+			columnIDs = append(columnIDs, card.ColumnID)
+		}
+		byColumn[card.ColumnID] = append(byColumn[card.ColumnID], card)
+	}
+	sizeOf := func(card models.Card) int {
+		if card.GroupID == nil {
+			return 0
+		}
+		return groupSize[*card.GroupID]
+	}
+
+	suggestions := []models.MergeSuggestion{}
+	for _, columnID := range columnIDs {
+		columnCards := byColumn[columnID]
+		texts := make([]string, len(columnCards))
+		for i, card := range columnCards {
+			texts[i] = card.Content
+		}
+		groups, scores := duplicateGroups(texts, threshold)
+		for g, group := range groups {
+			// Merge into the card whose group is the largest, else the oldest
+			target := group[0]
+			for _, i := range group[1:] {
+				if sizeOf(columnCards[i]) > sizeOf(columnCards[target]) {
+					target = i
+				}
+			}
+			targetGroup := columnCards[target].GroupID
+			suggestion := models.MergeSuggestion{
+				ColumnID:     columnID,
+				TargetCardID: columnCards[target].ID,
+				CardIDs:      []uuid.UUID{},
+				Similarity:   scores[g],
+			}
+			for _, i := range group {
+				card := columnCards[i]
+				// Cards already grouped with the target need no merging
+				if i == target || (targetGroup != nil && card.GroupID != nil && *card.GroupID == *targetGroup) {
+					continue
+				}
+				suggestion.CardIDs = append(suggestion.CardIDs, card.ID)
+			}
+			if len(suggestion.CardIDs) > 0 {
+				suggestions = append(suggestions, suggestion)
+			}
+		}
+	}
+	sort.SliceStable(suggestions, func(i, j int) bool { return suggestions[i].Similarity > suggestions[j].Similarity })
+
+	c.JSON(http.StatusOK, gin.H{"board_id": board.ID, "suggestions": suggestions})
+}
+
+// BulkMergeCards merges several cards into the group of a target card in one
+// transaction, creating the group if the target has none
+func BulkMergeCards(c *gin.Context) {
+	boardID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid board ID"})
+		return
+	}
+
+	var input struct {
+		TargetCardID uuid.UUID   `json:"target_card_id" binding:"required"`
+		CardIDs      []uuid.UUID `json:"card_ids" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	seen := map[uuid.UUID]bool{input.TargetCardID: true}
+	var cardIDs []uuid.UUID
+	for _, id := range input.CardIDs {
+		if !seen[id] {
+			seen[id] = true
+			cardIDs = append(cardIDs, id)
+		}
+	}
+	if len(cardIDs) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "At least one card other than the target is required"})
+		return
+	}
+
+	var board models.Board
+	if err := database.DB.First(&board, boardID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Board not found"})
+		return
+	}
+	if _, ok := authorizeBoard(c, &board, BoardActionContribute); !ok {
+		return
+	}
+	if !requirePhase(c, &board, PhaseActionMerge, "Cards cannot be merged in the "+boardPhase(board.Phase)+" phase") {
+		return
+	}
+
+	var count int64
+	if err := database.DB.Model(&models.Card{}).
+		Joins("JOIN columns ON columns.id = cards.column_id").
+		Where("columns.board_id = ? AND cards.id IN ?", board.ID, append([]uuid.UUID{input.TargetCardID}, cardIDs...)).
+		Count(&count).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch cards"})
+		return
+	}
+	if int(count) != len(cardIDs)+1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Cards must belong to this board"})
+		return
+	}
+	var target models.Card
+	if err := database.DB.First(&target, input.TargetCardID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Target card not found"})
+		return
+	}
+
+	var group models.CardGroup
+	var pruned []models.CardGroup
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		group, _, pruned, err = mergeIntoGroup(tx, &board, target, cardIDs)
+		return err
+	})
+	if errors.Is(err, ErrMergeConflict) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Cards were merged by someone else, reload and try again"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to merge cards"})
+		return
+	}
+
+	var merged []models.Card
+	database.DB.Where("id IN ?", cardIDs).Find(&merged)
+	for _, card := range merged {
+		BroadcastCardMerged(board.ID, card, target.ID, group)
+	}
+	broadcastPrunedGroups(board.ID, pruned)
+
+	c.JSON(http.StatusOK, group)
+}
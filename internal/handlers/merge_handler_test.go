@@ -0,0 +1,133 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/bento-lab-ops/bentro/internal/models"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func TestDuplicateGroups(t *testing.T) {
+	groups, scores := duplicateGroups([]string{
+		"Deploys are too slow",
+		"Great team lunch",
+		"deploy is slow",
+		"The deploys were slow",
+		"Reuniões muito longas",
+		"Reunião longa",
+	}, defaultDuplicateSimilarity)
+	assert.Equal(t, [][]int{{0, 2, 3}, {4, 5}}, groups)
+	for _, score := range scores {
+		assert.GreaterOrEqual(t, score, defaultDuplicateSimilarity)
+	}
+
+	// B is close to both A and C, but A and C are not duplicates of each other
+	groups, _ = duplicateGroups([]string{"alpha beta", "alpha beta gamma delta", "gamma delta"}, 0.5)
+	assert.Len(t, groups, 1)
+	assert.Len(t, groups[0], 2)
+}
+
+func TestMergeSuggestionsAndBulkMerge(t *testing.T) {
+	db, r := setupPolicyTest(t)
+	r.GET("/boards/:id/merge-suggestions", GetMergeSuggestions)
+	r.POST("/boards/:id/merge", BulkMergeCards)
+	owner := createTestUser(db, "owner", "user")
+
+	board := models.Board{ID: uuid.New(), Name: "Grouping", Owner: "owner", Status: "active", Phase: PhaseGrouping}
+	db.Create(&board)
+	wrong := models.Column{ID: uuid.New(), BoardID: board.ID, Name: "Went wrong"}
+	well := models.Column{ID: uuid.New(), BoardID: board.ID, Name: "Went well"}
+	db.Create(&wrong)
+	db.Create(&well)
+	slow := models.Card{ID: uuid.New(), ColumnID: wrong.ID, Content: "Deploys are too slow"}
+	slower := models.Card{ID: uuid.New(), ColumnID: wrong.ID, Content: "The deploys were slow"}
+	slowest := models.Card{ID: uuid.New(), ColumnID: wrong.ID, Content: "deploy is slow"}
+	lunch := models.Card{ID: uuid.New(), ColumnID: wrong.ID, Content: "No team lunch"}
+	praise := models.Card{ID: uuid.New(), ColumnID: well.ID, Content: "Deploys are fast now"}
+	for _, card := range []*models.Card{&slow, &slower, &slowest, &lunch, &praise} {
+		db.Create(card)
+	}
+	// slowest was already grouped with another card
+	earlier := models.CardGroup{ID: uuid.New(), ColumnID: wrong.ID, Title: "Deploys"}
+	db.Create(&earlier)
+	echo := models.Card{ID: uuid.New(), ColumnID: wrong.ID, Content: "Slow deploys", GroupID: &earlier.ID}
+	db.Create(&echo)
+	db.Model(&slowest).Update("group_id", earlier.ID)
+	other := models.Board{ID: uuid.New(), Name: "Other", Owner: "owner", Status: "active"}
+	db.Create(&other)
+	otherColumn := models.Column{ID: uuid.New(), BoardID: other.ID, Name: "Went wrong"}
+	db.Create(&otherColumn)
+	elsewhere := models.Card{ID: uuid.New(), ColumnID: otherColumn.ID, Content: "Deploys are slow"}
+	db.Create(&elsewhere)
+
+	send := func(method, path string, body interface{}) *httptest.ResponseRecorder {
+		var payload []byte
+		if body != nil {
+			payload, _ = json.Marshal(body)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, asUser(httptest.NewRequest(method, path, bytes.NewBuffer(payload)), owner))
+		return w
+	}
+
+	w := send("GET", "/boards/"+board.ID.String()+"/merge-suggestions", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	var response struct {
+		Suggestions []models.MergeSuggestion `json:"suggestions"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &response)
+	if !assert.Len(t, response.Suggestions, 1) {
+		return
+	}
+	suggestion := response.Suggestions[0]
+	assert.Equal(t, wrong.ID, suggestion.ColumnID)
+	// The card already in a group is the target, and its group mates are left out
+	assert.Equal(t, slowest.ID, suggestion.TargetCardID)
+	assert.ElementsMatch(t, []uuid.UUID{slow.ID, slower.ID}, suggestion.CardIDs)
+
+	assert.Equal(t, http.StatusBadRequest, send("POST", "/boards/"+board.ID.String()+"/merge", map[string]interface{}{
+		"target_card_id": slowest.ID, "card_ids": []uuid.UUID{slowest.ID},
+	}).Code)
+	assert.Equal(t, http.StatusBadRequest, send("POST", "/boards/"+board.ID.String()+"/merge", map[string]interface{}{
+		"target_card_id": slow.ID, "card_ids": []uuid.UUID{elsewhere.ID},
+	}).Code)
+
+	// An ungrouped target gets a group titled after it
+	w = send("POST", "/boards/"+board.ID.String()+"/merge", map[string]interface{}{
+		"target_card_id": slow.ID, "card_ids": []uuid.UUID{slower.ID, slowest.ID},
+	})
+	assert.Equal(t, http.StatusOK, w.Code)
+	var group models.CardGroup
+	json.Unmarshal(w.Body.Bytes(), &group)
+	assert.Equal(t, "Deploys are too slow", group.Title)
+	var merged int64
+	db.Model(&models.Card{}).Where("group_id = ?", group.ID).Count(&merged)
+	assert.Equal(t, int64(3), merged)
+
+	// The card left behind in the earlier group is suggested next
+	w = send("GET", "/boards/"+board.ID.String()+"/merge-suggestions", nil)
+	response.Suggestions = nil
+	json.Unmarshal(w.Body.Bytes(), &response)
+	if assert.Len(t, response.Suggestions, 1) {
+		assert.Equal(t, slow.ID, response.Suggestions[0].TargetCardID)
+		assert.Equal(t, []uuid.UUID{echo.ID}, response.Suggestions[0].CardIDs)
+	}
+	assert.Equal(t, http.StatusOK, send("POST", "/boards/"+board.ID.String()+"/merge", map[string]interface{}{
+		"target_card_id": slow.ID, "card_ids": []uuid.UUID{echo.ID},
+	}).Code)
+	// Emptied groups are deleted
+	assert.ErrorIs(t, db.First(&models.CardGroup{}, earlier.ID).Error, gorm.ErrRecordNotFound)
+
+	// Merging follows the phase rules
+	db.Model(&board).Update("phase", PhaseVoting)
+	assert.Equal(t, http.StatusConflict, send("POST", "/boards/"+board.ID.String()+"/merge", map[string]interface{}{
+		"target_card_id": lunch.ID, "card_ids": []uuid.UUID{praise.ID},
+	}).Code)
+}
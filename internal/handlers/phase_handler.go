@@ -0,0 +1,258 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/bento-lab-ops/bentro/internal/database"
+	"github.com/bento-lab-ops/bentro/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Board phases, in the order a retrospective usually goes through them
+const (
+	PhaseCheckIn        = "check-in"
+	PhaseInput          = "input"
+	PhaseGrouping       = "grouping"
+	PhaseVoting         = "voting"
+	PhaseDiscuss        = "discuss"
+	PhaseActionPlanning = "action-planning"
+	PhaseClosed         = "closed"
+)
+
+// phaseTransitions lists the phases a board may move to from each phase.
+// Facilitators step forward or back one phase, and may skip grouping or action planning.
+var phaseTransitions = map[string][]string{
+	PhaseCheckIn:        {PhaseInput},
+	PhaseInput:          {PhaseCheckIn, PhaseGrouping, PhaseVoting},
+	PhaseGrouping:       {PhaseInput, PhaseVoting},
+	PhaseVoting:         {PhaseInput, PhaseGrouping, PhaseDiscuss},
+	PhaseDiscuss:        {PhaseVoting, PhaseActionPlanning, PhaseClosed},
+	PhaseActionPlanning: {PhaseDiscuss, PhaseClosed},
+	PhaseClosed:         {PhaseDiscuss, PhaseActionPlanning},
+}
+
+// phaseOrder is the order a board moves through its phases when advanced automatically
+var phaseOrder = []string{PhaseCheckIn, PhaseInput, PhaseGrouping, PhaseVoting, PhaseDiscuss, PhaseActionPlanning, PhaseClosed}
+
+// PhaseAction is a board mutation restricted to some phases
+type PhaseAction int
+
+const (
+	// PhaseActionAddCard covers creating cards
+	PhaseActionAddCard PhaseAction = iota
+	// PhaseActionVote covers casting and withdrawing votes
+	PhaseActionVote
+	// PhaseActionMerge covers merging cards
+	PhaseActionMerge
+)
+
+// phaseActions lists the phases each action is allowed in
+var phaseActions = map[PhaseAction][]string{
+	PhaseActionAddCard: {PhaseInput, PhaseActionPlanning},
+	PhaseActionVote:    {PhaseVoting},
+	PhaseActionMerge:   {PhaseInput, PhaseGrouping},
+}
+
+var (
+	// ErrUnknownPhase means the phase is not part of the phase model
+	ErrUnknownPhase = errors.New("unknown phase")
+	// ErrPhaseTransition means the board cannot move directly between the two phases
+	ErrPhaseTransition = errors.New("phase transition not allowed")
+	// ErrPhaseConflict means the board's phase changed while the transition was applied
+	ErrPhaseConflict = errors.New("board phase changed concurrently")
+	// ErrCardsHidden means voting cannot close while private writing hides the cards
+	ErrCardsHidden = errors.New("voting cannot close while cards are hidden")
+)
+
+// IsValidPhase reports whether the phase is part of the phase model
+func IsValidPhase(phase string) bool {
+	_, ok := phaseTransitions[phase]
+	return ok
+}
+
+// CanTransitionPhase reports whether a board may move from one phase to another
+func CanTransitionPhase(from, to string) bool {
+	for _, next := range phaseTransitions[boardPhase(from)] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// boardPhase returns the phase a board is in; boards created before phases
+// were stored have an empty phase and are taking input
+func boardPhase(phase string) string {
+	if phase == "" {
+		return PhaseInput
+	}
+	return phase
+}
+
+// nextPhase returns the phase after the given one, or "" when the board is closed
+func nextPhase(phase string) string {
+	for i, p := range phaseOrder[:len(phaseOrder)-1] {
+		if p == boardPhase(phase) {
+			return phaseOrder[i+1]
+		}
+	}
+	return ""
+}
+
+// votingEnds reports whether moving between two phases ends the board's voting
+func votingEnds(from, to string) bool {
+	return from == PhaseVoting && (to == PhaseDiscuss || to == PhaseActionPlanning || to == PhaseClosed)
+}
+
+// PhaseAllows reports whether the action is allowed in the phase
+func PhaseAllows(phase string, action PhaseAction) bool {
+	for _, allowed := range phaseActions[action] {
+		if allowed == boardPhase(phase) {
+			return true
+		}
+	}
+	return false
+}
+
+// requirePhase rejects a request whose action is not allowed in the board's current phase
+func requirePhase(c *gin.Context, board *models.Board, action PhaseAction, message string) bool {
+	if PhaseAllows(board.Phase, action) {
+		return true
+	}
+	c.JSON(http.StatusConflict, gin.H{"error": message, "phase": boardPhase(board.Phase)})
+	return false
+}
+
+// changeBoardPhase moves a board to another phase following the phase model and
+// broadcasts phase_changed. The update only applies if the board is still in the
+// phase it was read in, so concurrent facilitators cannot skip transitions.
+func changeBoardPhase(board *models.Board, to, actor string) error {
+	if !IsValidPhase(to) {
+		return fmt.Errorf("%w %q", ErrUnknownPhase, to)
+	}
+	from := boardPhase(board.Phase)
+	if !CanTransitionPhase(from, to) {
+		return fmt.Errorf("%w: %s to %s", ErrPhaseTransition, from, to)
+	}
+	return setBoardPhase(board, to, actor)
+}
+
+// setBoardPhase moves a board to any phase of the phase model, as the admin
+// override does, and otherwise behaves like changeBoardPhase. Moving on from
+// voting closes it and takes the results snapshot, see closeVoting.
+func setBoardPhase(board *models.Board, to, actor string) error {
+	if !IsValidPhase(to) {
+		return fmt.Errorf("%w %q", ErrUnknownPhase, to)
+	}
+	from := boardPhase(board.Phase)
+	closing := votingEnds(from, to)
+	if closing && cardsHidden(board) {
+		return ErrCardsHidden
+	}
+
+	now := time.Now()
+	var results *models.VoteResults
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		query := tx.Model(&models.Board{}).Where("id = ?", board.ID)
+		if board.Phase == "" {
+			query = query.Where("phase = '' OR phase IS NULL")
+		} else {
+			query = query.Where("phase = ?", board.Phase)
+		}
+
+		updates := map[string]interface{}{
+			"phase":            to,
+			"phase_changed_at": now,
+			"phase_changed_by": actor,
+		}
+		// Going back to voting opens closed voting again; the last results
+		// snapshot stays until voting is closed once more
+		if to == PhaseVoting {
+			updates["voting_closed_at"] = nil
+		}
+		result := query.Updates(updates)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrPhaseConflict
+		}
+		if !closing {
+			return nil
+		}
+		var err error
+		results, err = closeVoting(tx, board, actor, now)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	board.Phase = to
+	board.PhaseChangedAt = &now
+	board.PhaseChangedBy = actor
+	if to == PhaseVoting {
+		board.VotingClosedAt = nil
+	}
+	BroadcastPhaseChanged(board.ID, from, to, actor, now)
+	if results != nil {
+		BroadcastResultsRevealed(board.ID, *results)
+	}
+	return nil
+}
+
+// UpdateBoardPhase moves a board to another phase (board managers only)
+func UpdateBoardPhase(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid board ID"})
+		return
+	}
+
+	var input struct {
+		Phase string `json:"phase" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var board models.Board
+	if err := database.DB.First(&board, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Board not found"})
+		return
+	}
+	access, ok := authorizeBoard(c, &board, BoardActionManage)
+	if !ok {
+		return
+	}
+
+	if err := changeBoardPhase(&board, input.Phase, access.Username); err != nil {
+		switch {
+		case errors.Is(err, ErrUnknownPhase):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, ErrPhaseTransition), errors.Is(err, ErrPhaseConflict), errors.Is(err, ErrCardsHidden):
+			c.JSON(http.StatusConflict, gin.H{"error": phaseErrorMessage(err), "phase": boardPhase(board.Phase)})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update board phase"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, board)
+}
+
+// phaseErrorMessage words a refused phase change for the board manager
+func phaseErrorMessage(err error) string {
+	if errors.Is(err, ErrCardsHidden) {
+		return "Reveal the cards before closing voting"
+	}
+	return err.Error()
+}
@@ -0,0 +1,107 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/bento-lab-ops/bentro/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func setupPhaseTest(t *testing.T) (*gorm.DB, *gin.Engine) {
+	db, r := setupPolicyTest(t)
+	r.PUT("/boards/:id/phase", UpdateBoardPhase)
+	r.POST("/cards/:id/merge", MergeCard)
+	return db, r
+}
+
+func TestPhaseTransitions(t *testing.T) {
+	assert.True(t, CanTransitionPhase(PhaseInput, PhaseVoting))
+	assert.True(t, CanTransitionPhase("", PhaseGrouping), "boards without a phase are taking input")
+	assert.True(t, CanTransitionPhase(PhaseVoting, PhaseDiscuss))
+	assert.False(t, CanTransitionPhase(PhaseInput, PhaseClosed))
+	assert.False(t, CanTransitionPhase(PhaseVoting, PhaseVoting))
+	assert.False(t, CanTransitionPhase(PhaseCheckIn, "party"))
+
+	assert.True(t, PhaseAllows(PhaseInput, PhaseActionAddCard))
+	assert.False(t, PhaseAllows(PhaseVoting, PhaseActionAddCard))
+	assert.True(t, PhaseAllows(PhaseVoting, PhaseActionVote))
+	assert.False(t, PhaseAllows(PhaseDiscuss, PhaseActionVote))
+	assert.True(t, PhaseAllows(PhaseGrouping, PhaseActionMerge))
+	assert.False(t, PhaseAllows(PhaseVoting, PhaseActionMerge))
+}
+
+func TestUpdateBoardPhase(t *testing.T) {
+	db, r := setupPhaseTest(t)
+	owner := createTestUser(db, "facilitator", "user")
+	participant := createTestUser(db, "attendee", "user")
+
+	board := models.Board{ID: uuid.New(), Name: "Phased", Owner: "facilitator", Status: "active"}
+	db.Create(&board)
+	db.Create(&models.BoardMember{BoardID: board.ID, Username: "attendee"})
+	col := models.Column{ID: uuid.New(), BoardID: board.ID, Name: "Col"}
+	db.Create(&col)
+
+	setPhase := func(user models.User, phase string) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(map[string]string{"phase": phase})
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, asUser(httptest.NewRequest("PUT", "/boards/"+board.ID.String()+"/phase", bytes.NewBuffer(body)), user))
+		return w
+	}
+	addCard := func(user models.User) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(map[string]string{"content": "Idea"})
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, asUser(httptest.NewRequest("POST", "/columns/"+col.ID.String()+"/cards", bytes.NewBuffer(body)), user))
+		return w
+	}
+
+	// Cards are added while taking input
+	w := addCard(participant)
+	assert.Equal(t, http.StatusCreated, w.Code)
+	var first models.Card
+	json.Unmarshal(w.Body.Bytes(), &first)
+	w = addCard(participant)
+	var second models.Card
+	json.Unmarshal(w.Body.Bytes(), &second)
+
+	// Only managers move the board, and only along the phase model
+	assert.Equal(t, http.StatusForbidden, setPhase(participant, PhaseVoting).Code)
+	assert.Equal(t, http.StatusBadRequest, setPhase(owner, "party").Code)
+	assert.Equal(t, http.StatusConflict, setPhase(owner, PhaseClosed).Code)
+
+	w = setPhase(owner, PhaseVoting)
+	assert.Equal(t, http.StatusOK, w.Code)
+	var updated models.Board
+	assert.NoError(t, db.First(&updated, board.ID).Error)
+	assert.Equal(t, PhaseVoting, updated.Phase)
+	assert.Equal(t, "facilitator", updated.PhaseChangedBy)
+	assert.NotNil(t, updated.PhaseChangedAt)
+
+	// No cards are added or merged during voting, but votes are cast
+	w = addCard(participant)
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.Contains(t, w.Body.String(), PhaseVoting)
+
+	mergeBody, _ := json.Marshal(map[string]string{"target_card_id": second.ID.String()})
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, asUser(httptest.NewRequest("POST", "/cards/"+first.ID.String()+"/merge", bytes.NewBuffer(mergeBody)), participant))
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	voteBody, _ := json.Marshal(map[string]string{"user_name": "attendee", "vote_type": "like"})
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, asUser(httptest.NewRequest("POST", "/cards/"+first.ID.String()+"/votes", bytes.NewBuffer(voteBody)), participant))
+	assert.Equal(t, http.StatusCreated, w.Code)
+
+	// Once discussion starts the votes are frozen
+	assert.Equal(t, http.StatusOK, setPhase(owner, PhaseDiscuss).Code)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, asUser(httptest.NewRequest("POST", "/cards/"+first.ID.String()+"/votes", bytes.NewBuffer(voteBody)), participant))
+	assert.Equal(t, http.StatusConflict, w.Code)
+}
@@ -33,6 +33,10 @@ func ToggleReaction(c *gin.Context) {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Card not found"})
 		return
 	}
+	access, board, ok := authorizeCard(c, card.ID)
+	if !ok || !forbidImpersonation(c, access, input.UserName) {
+		return
+	}
 
 	// Check if reaction exists
 	var existingReaction models.Reaction
@@ -44,6 +48,7 @@ func ToggleReaction(c *gin.Context) {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to remove reaction"})
 			return
 		}
+		BroadcastReactionToggled(board.ID, existingReaction, false)
 		c.JSON(http.StatusOK, gin.H{"message": "Reaction removed", "action": "removed"})
 	} else {
 		// Reaction does not exist, create it (Toggle ON)
@@ -56,6 +61,7 @@ func ToggleReaction(c *gin.Context) {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to add reaction"})
 			return
 		}
+		BroadcastReactionToggled(board.ID, reaction, true)
 		c.JSON(http.StatusOK, gin.H{"message": "Reaction added", "action": "added", "reaction": reaction})
 	}
 }
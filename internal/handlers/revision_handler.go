@@ -0,0 +1,235 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/bento-lab-ops/bentro/internal/database"
+	"github.com/bento-lab-ops/bentro/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Why a card revision was recorded
+const (
+	RevisionEdit    = "edit"    // UpdateCard
+	RevisionAdmin   = "admin"   // AdminUpdateActionItem
+	RevisionRestore = "restore" // RestoreCardRevision
+)
+
+// cardState holds the card fields a revision tracks, under their JSON names
+type cardState struct {
+	Content        string     `json:"content"`
+	IsActionItem   bool       `json:"is_action_item"`
+	Owner          string     `json:"owner"`
+	DueDate        *time.Time `json:"due_date"`
+	Completed      bool       `json:"completed"`
+	CompletionLink string     `json:"completion_link"`
+	CompletionDesc string     `json:"completion_desc"`
+	CompletionDate *time.Time `json:"completion_date"`
+}
+
+// cardStateOf returns the tracked fields of a card. Times are kept in UTC so the
+// same instant never shows up as a change.
+func cardStateOf(card models.Card) cardState {
+	return cardState{
+		Content:        card.Content,
+		IsActionItem:   card.IsActionItem,
+		Owner:          card.Owner,
+		DueDate:        utcTime(card.DueDate),
+		Completed:      card.Completed,
+		CompletionLink: card.CompletionLink,
+		CompletionDesc: card.CompletionDesc,
+		CompletionDate: utcTime(card.CompletionDate),
+	}
+}
+
+// apply writes the tracked fields to a card
+func (s cardState) apply(card *models.Card) {
+	card.Content = s.Content
+	card.IsActionItem = s.IsActionItem
+	card.Owner = s.Owner
+	card.DueDate = s.DueDate
+	card.Completed = s.Completed
+	card.CompletionLink = s.CompletionLink
+	card.CompletionDesc = s.CompletionDesc
+	card.CompletionDate = s.CompletionDate
+}
+
+// fields returns the JSON value of each tracked field
+func (s cardState) fields() map[string]json.RawMessage {
+	data, _ := json.Marshal(s)
+	fields := make(map[string]json.RawMessage)
+	json.Unmarshal(data, &fields)
+	return fields
+}
+
+func utcTime(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	utc := t.UTC()
+	return &utc
+}
+
+// diffCardStates returns the fields that differ between two states
+func diffCardStates(before, after cardState) map[string]models.FieldChange {
+	beforeFields, afterFields := before.fields(), after.fields()
+	changes := make(map[string]models.FieldChange)
+	for name, value := range afterFields {
+		if !bytes.Equal(beforeFields[name], value) {
+			changes[name] = models.FieldChange{Before: beforeFields[name], After: value}
+		}
+	}
+	return changes
+}
+
+// recordCardRevision stores the change from before to the card's current state.
+// Saves that change nothing leave no revision.
+func recordCardRevision(tx *gorm.DB, before cardState, card models.Card, actor, reason string, restoredFrom *uuid.UUID) error {
+	changes := diffCardStates(before, cardStateOf(card))
+	if len(changes) == 0 {
+		return nil
+	}
+	// Saving the card locked its row, so revisions of one card are numbered one at a time
+	var seq int64
+	if err := tx.Model(&models.CardRevision{}).Where("card_id = ?", card.ID).
+		Select("COALESCE(MAX(seq), 0)").Scan(&seq).Error; err != nil {
+		return err
+	}
+	revision := models.CardRevision{
+		CardID:       card.ID,
+		Seq:          seq + 1,
+		Actor:        actor,
+		Reason:       reason,
+		RestoredFrom: restoredFrom,
+		Changes:      changes,
+	}
+	return tx.Create(&revision).Error
+}
+
+// saveCardWithRevision saves a card together with the revision describing the change
+func saveCardWithRevision(card *models.Card, before cardState, actor, reason string, restoredFrom *uuid.UUID) error {
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(card).Error; err != nil {
+			return err
+		}
+		return recordCardRevision(tx, before, *card, actor, reason, restoredFrom)
+	})
+}
+
+// presentRevision hides who edited a card's content on boards that hide card
+// authors. Action item changes stay attributed, since that is who is accountable for them.
+func presentRevision(board *models.Board, revision models.CardRevision) models.CardRevision {
+	if _, contentChanged := revision.Changes["content"]; contentChanged && !authorsVisible(board) {
+		revision.Actor = ""
+	}
+	return revision
+}
+
+// authorizeCardHistory checks the caller may read a card's history, which holds
+// its content: before a private writing board is revealed only the author can.
+func authorizeCardHistory(c *gin.Context) (models.Card, BoardAccess, models.Board, bool) {
+	var card models.Card
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid card ID"})
+		return card, BoardAccess{}, models.Board{}, false
+	}
+	if err := database.DB.First(&card, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Card not found"})
+		return card, BoardAccess{}, models.Board{}, false
+	}
+	access, board, ok := authorizeCard(c, card.ID)
+	if !ok {
+		return card, access, board, false
+	}
+	if cardsHidden(&board) && (access.Username == "" || card.Author != access.Username) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only the author can see a card's history before the board is revealed"})
+		return card, access, board, false
+	}
+	return card, access, board, true
+}
+
+// GetCardHistory returns a card's revisions, newest first
+func GetCardHistory(c *gin.Context) {
+	card, _, board, ok := authorizeCardHistory(c)
+	if !ok {
+		return
+	}
+
+	var revisions []models.CardRevision
+	if err := database.DB.Where("card_id = ?", card.ID).Order("seq desc, created_at desc").Find(&revisions).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch card history"})
+		return
+	}
+	for i, revision := range revisions {
+		revisions[i] = presentRevision(&board, revision)
+	}
+
+	c.JSON(http.StatusOK, revisions)
+}
+
+// RestoreCardRevision puts a card back the way it was before a revision (board
+// managers only). The restore is itself recorded as a revision.
+func RestoreCardRevision(c *gin.Context) {
+	card, access, board, ok := authorizeCardHistory(c)
+	if !ok {
+		return
+	}
+	if !access.IsManager() {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only board managers can restore a card"})
+		return
+	}
+
+	revisionID, err := uuid.Parse(c.Param("revisionId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid revision ID"})
+		return
+	}
+	var target models.CardRevision
+	if err := database.DB.Where("id = ? AND card_id = ?", revisionID, card.ID).First(&target).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Revision not found"})
+		return
+	}
+
+	// Undo the target revision and every later one, newest first, so each field
+	// ends up with the value it had before the target revision. Revisions
+	// recorded before they were numbered are ordered by time.
+	var later []models.CardRevision
+	if err := database.DB.Where("card_id = ? AND (seq > ? OR (seq = ? AND created_at >= ?))",
+		card.ID, target.Seq, target.Seq, target.CreatedAt).
+		Order("seq desc, created_at desc").Find(&later).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch card history"})
+		return
+	}
+	before := cardStateOf(card)
+	fields := before.fields()
+	for _, revision := range later {
+		for name, change := range revision.Changes {
+			if _, tracked := fields[name]; tracked {
+				fields[name] = change.Before
+			}
+		}
+	}
+	data, _ := json.Marshal(fields)
+	var restored cardState
+	if err := json.Unmarshal(data, &restored); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read card history"})
+		return
+	}
+	restored.apply(&card)
+
+	if err := saveCardWithRevision(&card, before, access.Username, RevisionRestore, &target.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to restore card"})
+		return
+	}
+
+	BroadcastCardUpdated(board.ID, card)
+
+	c.JSON(http.StatusOK, presentCard(&board, card, access.Username))
+}
@@ -0,0 +1,137 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/bento-lab-ops/bentro/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func setupRevisionTest(t *testing.T) (*gorm.DB, *gin.Engine) {
+	db, r := setupPolicyTest(t)
+	r.PUT("/cards/:id", UpdateCard)
+	r.GET("/cards/:id/history", GetCardHistory)
+	r.POST("/cards/:id/history/:revisionId/restore", RestoreCardRevision)
+	return db, r
+}
+
+func TestCardHistory(t *testing.T) {
+	db, r := setupRevisionTest(t)
+	owner := createTestUser(db, "facilitator", "user")
+	alice := createTestUser(db, "alice", "user")
+
+	board := models.Board{ID: uuid.New(), Name: "Audit", Owner: "facilitator", Status: "active"}
+	db.Create(&board)
+	db.Create(&models.BoardMember{BoardID: board.ID, Username: "alice"})
+	col := models.Column{ID: uuid.New(), BoardID: board.ID, Name: "Col"}
+	db.Create(&col)
+	card := models.Card{ID: uuid.New(), ColumnID: col.ID, Content: "Ship faster", Author: "alice"}
+	db.Create(&card)
+
+	send := func(user models.User, method, path string, body interface{}) *httptest.ResponseRecorder {
+		var payload []byte
+		if body != nil {
+			payload, _ = json.Marshal(body)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, asUser(httptest.NewRequest(method, path, bytes.NewBuffer(payload)), user))
+		return w
+	}
+	cardPath := "/cards/" + card.ID.String()
+	history := func(user models.User) []models.CardRevision {
+		w := send(user, "GET", cardPath+"/history", nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+		var revisions []models.CardRevision
+		json.Unmarshal(w.Body.Bytes(), &revisions)
+		return revisions
+	}
+
+	// Every change is recorded with its actor and changed fields only
+	assert.Equal(t, http.StatusOK, send(alice, "PUT", cardPath, map[string]string{"content": "Ship faster with CI"}).Code)
+	assert.Equal(t, http.StatusOK, send(owner, "PUT", cardPath, map[string]interface{}{
+		"is_action_item": true, "owner": "alice", "due_date": "2026-11-01T00:00:00Z",
+	}).Code)
+	assert.Equal(t, http.StatusOK, send(owner, "PUT", cardPath, map[string]string{"owner": "bob"}).Code)
+	// Saving the same values leaves no revision
+	assert.Equal(t, http.StatusOK, send(owner, "PUT", cardPath, map[string]string{"owner": "bob"}).Code)
+
+	revisions := history(alice)
+	if !assert.Len(t, revisions, 3) {
+		return
+	}
+	assert.Equal(t, RevisionEdit, revisions[0].Reason)
+	assert.Equal(t, "facilitator", revisions[0].Actor)
+	assert.Equal(t, `"alice"`, string(revisions[0].Changes["owner"].Before))
+	assert.Equal(t, `"bob"`, string(revisions[0].Changes["owner"].After))
+	assert.Len(t, revisions[1].Changes, 3)
+	// Content edits on anonymous boards stay anonymous
+	assert.Empty(t, revisions[2].Actor)
+	assert.Equal(t, `"Ship faster"`, string(revisions[2].Changes["content"].Before))
+
+	// Only managers restore, back to the card as it was before the revision
+	actionRevision := revisions[1].ID.String()
+	assert.Equal(t, http.StatusForbidden, send(alice, "POST", cardPath+"/history/"+actionRevision+"/restore", nil).Code)
+	assert.Equal(t, http.StatusNotFound, send(owner, "POST", cardPath+"/history/"+uuid.New().String()+"/restore", nil).Code)
+	w := send(owner, "POST", cardPath+"/history/"+actionRevision+"/restore", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var restored models.Card
+	db.First(&restored, card.ID)
+	assert.Equal(t, "Ship faster with CI", restored.Content)
+	assert.False(t, restored.IsActionItem)
+	assert.Empty(t, restored.Owner)
+	assert.Nil(t, restored.DueDate)
+
+	revisions = history(owner)
+	if assert.Len(t, revisions, 4) {
+		assert.Equal(t, RevisionRestore, revisions[0].Reason)
+		assert.Equal(t, actionRevision, revisions[0].RestoredFrom.String())
+	}
+
+	// Revisions recorded within the same instant keep their order
+	db.Model(&models.CardRevision{}).Where("card_id = ?", card.ID).Update("created_at", time.Now())
+	revisions = history(owner)
+	if !assert.Len(t, revisions, 4) {
+		return
+	}
+	assert.Equal(t, RevisionRestore, revisions[0].Reason)
+	ownerRevision := revisions[1].ID.String()
+	assert.Equal(t, http.StatusOK, send(owner, "POST", cardPath+"/history/"+ownerRevision+"/restore", nil).Code)
+	db.First(&restored, card.ID)
+	assert.Equal(t, "Ship faster with CI", restored.Content)
+	assert.True(t, restored.IsActionItem)
+	assert.Equal(t, "alice", restored.Owner)
+}
+
+func TestCardHistoryPrivateWriting(t *testing.T) {
+	db, r := setupRevisionTest(t)
+	alice := createTestUser(db, "alice", "user")
+	bob := createTestUser(db, "bob", "user")
+
+	board := models.Board{ID: uuid.New(), Name: "Private", Status: "active", PrivateWriting: true}
+	db.Create(&board)
+	db.Create(&models.BoardMember{BoardID: board.ID, Username: "alice"})
+	db.Create(&models.BoardMember{BoardID: board.ID, Username: "bob"})
+	col := models.Column{ID: uuid.New(), BoardID: board.ID, Name: "Col"}
+	db.Create(&col)
+	card := models.Card{ID: uuid.New(), ColumnID: col.ID, Content: "Secret", Author: "alice"}
+	db.Create(&card)
+
+	// The history holds the content, so it stays with the author until the reveal
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, asUser(httptest.NewRequest("GET", "/cards/"+card.ID.String()+"/history", nil), alice))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, asUser(httptest.NewRequest("GET", "/cards/"+card.ID.String()+"/history", nil), bob))
+	assert.Equal(t, http.StatusForbidden, w.Code)
+}
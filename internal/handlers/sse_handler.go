@@ -0,0 +1,126 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+const (
+	// How often an idle event stream sends a comment so proxies keep it open
+	sseHeartbeat = 15 * time.Second
+
+	// Reconnect delay suggested to EventSource clients, in milliseconds
+	sseRetryMillis = 3000
+)
+
+// HandleBoardEvents streams a board's events as Server-Sent Events, for clients
+// whose proxies block WebSocket upgrades. Each event's data is the same envelope
+// a WebSocket client receives for the board, and sequenced events carry their seq
+// as the event ID so a reconnecting EventSource resumes through Last-Event-ID.
+// The stream is receive-only; the client counts as a participant while connected.
+func HandleBoardEvents(c *gin.Context) {
+	boardID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid board ID"})
+		return
+	}
+
+	identity, ok := resolveClientIdentity(c)
+	if !ok {
+		return
+	}
+
+	client := &Client{
+		hub:      hub,
+		send:     make(chan []byte, 256),
+		identity: identity,
+	}
+	if perr := client.authorizeJoin(boardID.String()); perr != nil {
+		status := http.StatusNotFound
+		if perr.Code == ErrCodeForbidden {
+			status = http.StatusForbidden
+		}
+		c.JSON(status, gin.H{"error": perr.Message})
+		return
+	}
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no") // Disable nginx response buffering
+	c.Status(http.StatusOK)
+	fmt.Fprintf(c.Writer, "retry: %d\n\n", sseRetryMillis)
+	c.Writer.Flush()
+
+	hub.register <- client
+	hub.joinBoard <- &ParticipantMessage{
+		Type:     MsgJoinBoard,
+		BoardID:  boardID.String(),
+		Username: identity.Username,
+		Avatar:   identity.Avatar,
+		IsAdmin:  identity.IsAdmin,
+		IsGuest:  identity.IsGuest,
+		Client:   client,
+		lastSeq:  lastEventID(c),
+	}
+	defer func() {
+		hub.unregister <- client
+	}()
+
+	ticker := time.NewTicker(sseHeartbeat)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case message, ok := <-client.send:
+			if !ok {
+				// The hub dropped a client that could not keep up
+				return
+			}
+			if err := writeSSEFrame(c.Writer, message); err != nil {
+				return
+			}
+			c.Writer.Flush()
+		case <-ticker.C:
+			if _, err := fmt.Fprint(c.Writer, ": heartbeat\n\n"); err != nil {
+				return
+			}
+			c.Writer.Flush()
+		}
+	}
+}
+
+// lastEventID reads the seq a client resumes from: the Last-Event-ID header an
+// EventSource sends when it reconnects, or ?last_event_id= on a fresh connection.
+func lastEventID(c *gin.Context) *uint64 {
+	value := c.GetHeader("Last-Event-ID")
+	if value == "" {
+		value = c.Query("last_event_id")
+	}
+	seq, err := strconv.ParseUint(value, 10, 64)
+	if err != nil {
+		return nil
+	}
+	return &seq
+}
+
+// writeSSEFrame writes an envelope as one event, identified by its seq when it has one
+func writeSSEFrame(w http.ResponseWriter, frame []byte) error {
+	var env struct {
+		Seq uint64 `json:"seq"`
+	}
+	if err := json.Unmarshal(frame, &env); err == nil && env.Seq > 0 {
+		if _, err := fmt.Fprintf(w, "id: %d\n", env.Seq); err != nil {
+			return err
+		}
+	}
+	_, err := fmt.Fprintf(w, "data: %s\n\n", frame)
+	return err
+}
@@ -0,0 +1,126 @@
+package handlers_test
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bento-lab-ops/bentro/internal/handlers"
+	"github.com/bento-lab-ops/bentro/internal/models"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+type sseEvent struct {
+	ID    string
+	Frame map[string]interface{}
+}
+
+// Helper to open an event stream; the stream closes when the context ends
+func openEventStream(ctx context.Context, t *testing.T, url, lastEventID string) (*http.Response, *bufio.Reader) {
+	req, _ := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if lastEventID != "" {
+		req.Header.Set("Last-Event-ID", lastEventID)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	return resp, bufio.NewReader(resp.Body)
+}
+
+// Helper to read events until one of the given type arrives, skipping comments
+func readSSEUntilType(reader *bufio.Reader, msgType string) *sseEvent {
+	event := sseEvent{}
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return nil
+		}
+		line = strings.TrimRight(line, "\n")
+		switch {
+		case strings.HasPrefix(line, "id: "):
+			event.ID = strings.TrimPrefix(line, "id: ")
+		case strings.HasPrefix(line, "data: "):
+			json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &event.Frame)
+		case line == "":
+			if event.Frame != nil && event.Frame["type"] == msgType {
+				return &event
+			}
+			event = sseEvent{}
+		}
+	}
+}
+
+func TestBoardEventStream(t *testing.T) {
+	db, r := setupWSTest(t)
+	r.GET("/boards/:id/events", handlers.HandleBoardEvents)
+
+	boardID := uuid.New()
+	db.Create(&models.Board{ID: boardID, Name: "SSE Board", Status: "active", AllowGuests: true})
+	privateID := uuid.New()
+	db.Create(&models.Board{ID: privateID, Name: "Private SSE Board", Status: "active"})
+
+	server := httptest.NewServer(r)
+	defer server.Close()
+	streamURL := server.URL + "/boards/" + boardID.String() + "/events?guest=sam"
+
+	// Boards that do not allow guests refuse the stream
+	resp, err := http.Get(server.URL + "/boards/" + privateID.String() + "/events?guest=sam")
+	if assert.NoError(t, err) {
+		assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+		resp.Body.Close()
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	resp, reader := openEventStream(ctx, t, streamURL, "")
+	assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+	assert.NotNil(t, readSSEUntilType(reader, "synced"))
+
+	// Board events arrive as the WebSocket envelope, identified by their seq
+	handlers.BroadcastVoteUpdate(boardID, uuid.New(), 2, 1, nil)
+	vote := readSSEUntilType(reader, "vote_update")
+	if !assert.NotNil(t, vote) {
+		cancel()
+		return
+	}
+	assert.Equal(t, boardID.String(), vote.Frame["board_id"])
+	assert.NotEmpty(t, vote.ID)
+	assert.Equal(t, vote.ID, jsonNumber(vote.Frame["seq"]))
+	cancel()
+	resp.Body.Close()
+
+	// Events broadcast while the stream is closed are replayed from Last-Event-ID
+	time.Sleep(100 * time.Millisecond)
+	handlers.BroadcastBoardUpdate(boardID)
+
+	ctx, cancel = context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	resp, reader = openEventStream(ctx, t, streamURL, vote.ID)
+	defer resp.Body.Close()
+
+	update := readSSEUntilType(reader, "board_update")
+	if !assert.NotNil(t, update) {
+		return
+	}
+	assert.NotEqual(t, vote.ID, update.ID)
+	synced := readSSEUntilType(reader, "synced")
+	if assert.NotNil(t, synced) {
+		payload := synced.Frame["payload"].(map[string]interface{})
+		assert.Equal(t, update.ID, jsonNumber(payload["seq"]))
+		assert.EqualValues(t, 2, payload["replayed"]) // sam leaving, then the board update
+	}
+}
+
+// Helper to format a decoded JSON number like an event ID
+func jsonNumber(value interface{}) string {
+	number, _ := value.(float64)
+	return strconv.FormatUint(uint64(number), 10)
+}
@@ -0,0 +1,220 @@
+package handlers
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/bento-lab-ops/bentro/internal/database"
+	"github.com/bento-lab-ops/bentro/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// DefaultSurveyQuestion is asked by boards that do not set their own
+const DefaultSurveyQuestion = "Was this retrospective worth the time you invested in it?"
+
+// Bounds of the closing survey
+const (
+	DefaultSurveyScale = 5
+	MinSurveyScale     = 2
+	MaxSurveyScale     = 10
+	maxSurveyComment   = 1000 // Characters kept of a comment
+)
+
+// Errors of answering the closing survey
+var (
+	ErrSurveyAnswered = errors.New("survey already answered")
+	ErrSurveyChanged  = errors.New("survey scale changed")
+)
+
+// IsValidSurveyScale reports whether ratings can run from 1 to scale
+func IsValidSurveyScale(scale int) bool {
+	return scale >= MinSurveyScale && scale <= MaxSurveyScale
+}
+
+// surveyQuestion returns the question a board asks
+func surveyQuestion(board *models.Board) string {
+	if board.SurveyQuestion != "" {
+		return board.SurveyQuestion
+	}
+	return DefaultSurveyQuestion
+}
+
+// surveyScale returns the scale a board rates on. Boards created before the
+// survey existed have none.
+func surveyScale(board *models.Board) int {
+	if IsValidSurveyScale(board.SurveyScale) {
+		return board.SurveyScale
+	}
+	return DefaultSurveyScale
+}
+
+// surveyResults returns the tally of a board's survey, empty before the first answer
+func surveyResults(board *models.Board) models.SurveyResults {
+	if board.Survey != nil {
+		return *board.Survey
+	}
+	scale := surveyScale(board)
+	return models.SurveyResults{
+		Question:     surveyQuestion(board),
+		Scale:        scale,
+		Distribution: make([]int, scale),
+		Comments:     []string{},
+	}
+}
+
+// addSurveyAnswer counts a rating, and a comment if any, into a tally
+func addSurveyAnswer(results *models.SurveyResults, rating int, comment string) {
+	results.Distribution[rating-1]++
+	results.Responses++
+	sum := 0
+	for i, count := range results.Distribution {
+		sum += (i + 1) * count
+	}
+	results.Average = math.Round(float64(sum)/float64(results.Responses)*100) / 100
+	if comment != "" {
+		results.Comments = append(results.Comments, comment)
+		sort.Strings(results.Comments)
+	}
+}
+
+// answerSurvey records that userName answered the survey and counts their
+// answer, both or neither
+func answerSurvey(board *models.Board, userName string, rating int, comment string) error {
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		// Answers to a board are counted one at a time, on its latest tally
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(board, board.ID).Error; err != nil {
+			return err
+		}
+
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).
+			Create(&models.SurveyRespondent{BoardID: board.ID, UserName: userName})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrSurveyAnswered
+		}
+
+		results := surveyResults(board)
+		if rating > results.Scale {
+			return ErrSurveyChanged
+		}
+		addSurveyAnswer(&results, rating, comment)
+		board.Survey = &results
+		return tx.Model(board).Select("survey").Updates(board).Error
+	})
+}
+
+// GetSurvey returns a board's closing survey, whether the caller answered it,
+// and its results so far
+func GetSurvey(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid board ID"})
+		return
+	}
+
+	var board models.Board
+	if err := database.DB.First(&board, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Board not found"})
+		return
+	}
+	access, ok := authorizeBoard(c, &board, BoardActionContribute)
+	if !ok {
+		return
+	}
+
+	var answered int64
+	if access.Username != "" {
+		if err := database.DB.Model(&models.SurveyRespondent{}).
+			Where("board_id = ? AND user_name = ?", board.ID, access.Username).
+			Count(&answered).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load survey"})
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"question":  surveyQuestion(&board),
+		"scale":     surveyScale(&board),
+		"comments":  board.SurveyComments,
+		"opened_at": board.SurveyOpenedAt,
+		"answered":  answered > 0,
+		"results":   surveyResults(&board),
+	})
+}
+
+// AnswerSurvey takes a participant's one answer to a board's closing survey
+func AnswerSurvey(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid board ID"})
+		return
+	}
+
+	var input struct {
+		UserName string `json:"user_name" binding:"required"`
+		Rating   int    `json:"rating" binding:"required"`
+		Comment  string `json:"comment"`
+	}
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var board models.Board
+	if err := database.DB.First(&board, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Board not found"})
+		return
+	}
+	access, ok := authorizeBoard(c, &board, BoardActionContribute)
+	if !ok || !forbidImpersonation(c, access, input.UserName) {
+		return
+	}
+
+	if board.SurveyOpenedAt == nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "The survey opens when the board is finished"})
+		return
+	}
+	// The scale of a survey no longer changes once it has answers, see UpdateBoard
+	if input.Rating < 1 || input.Rating > surveyScale(&board) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Rating must be between 1 and " + strconv.Itoa(surveyScale(&board))})
+		return
+	}
+	comment := strings.TrimSpace(input.Comment)
+	if comment != "" && !board.SurveyComments {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "This survey takes no comments"})
+		return
+	}
+	if utf8.RuneCountInString(comment) > maxSurveyComment {
+		comment = string([]rune(comment)[:maxSurveyComment])
+	}
+
+	err = answerSurvey(&board, input.UserName, input.Rating, comment)
+	if errors.Is(err, ErrSurveyAnswered) {
+		c.JSON(http.StatusConflict, gin.H{"error": "You already answered this survey"})
+		return
+	}
+	if errors.Is(err, ErrSurveyChanged) {
+		c.JSON(http.StatusConflict, gin.H{"error": "The survey scale changed, please answer again"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to answer survey"})
+		return
+	}
+
+	BroadcastSurveyUpdated(board.ID, *board.Survey)
+
+	c.JSON(http.StatusOK, board.Survey)
+}
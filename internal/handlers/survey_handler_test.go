@@ -0,0 +1,133 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/bento-lab-ops/bentro/internal/models"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClosingSurvey(t *testing.T) {
+	db, r := setupVoteReactionTest(t)
+	r.PUT("/boards/:id", UpdateBoard)
+	r.PUT("/boards/:id/status", UpdateBoardStatus)
+	r.GET("/boards/:id/survey", GetSurvey)
+	r.POST("/boards/:id/survey", AnswerSurvey)
+	ana := createTestUser(db, "ana", "user")
+	bruno := createTestUser(db, "bruno", "user")
+	carla := createTestUser(db, "carla", "user")
+	board := models.Board{ID: uuid.New(), Name: "Sprint 9", Owner: "ana", Status: "active"}
+	db.Create(&board)
+	db.Create(&models.BoardMember{BoardID: board.ID, Username: "bruno"})
+	db.Create(&models.BoardMember{BoardID: board.ID, Username: "carla"})
+
+	send := func(user models.User, method, path string, body interface{}) *httptest.ResponseRecorder {
+		var payload []byte
+		if body != nil {
+			payload, _ = json.Marshal(body)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, asUser(httptest.NewRequest(method, path, bytes.NewBuffer(payload)), user))
+		return w
+	}
+	surveyPath := "/boards/" + board.ID.String() + "/survey"
+	answer := func(user models.User, rating int, comment string) *httptest.ResponseRecorder {
+		return send(user, "POST", surveyPath, map[string]interface{}{"user_name": user.DisplayName, "rating": rating, "comment": comment})
+	}
+
+	// Managers set the survey up; the scale is bounded
+	assert.Equal(t, http.StatusBadRequest, send(ana, "PUT", "/boards/"+board.ID.String(), map[string]int{"survey_scale": 11}).Code)
+	assert.Equal(t, http.StatusOK, send(ana, "PUT", "/boards/"+board.ID.String(), map[string]interface{}{"survey_scale": 4, "survey_question": " Worth it? "}).Code)
+
+	// The survey opens when the board is finished
+	assert.Equal(t, http.StatusConflict, answer(bruno, 3, "").Code)
+	assert.Equal(t, http.StatusOK, send(ana, "PUT", "/boards/"+board.ID.String()+"/status", map[string]string{"status": "finished"}).Code)
+
+	assert.Equal(t, http.StatusBadRequest, answer(bruno, 5, "").Code)
+	assert.Equal(t, http.StatusBadRequest, answer(bruno, 0, "").Code)
+	assert.Equal(t, http.StatusForbidden, send(bruno, "POST", surveyPath, map[string]interface{}{"user_name": "carla", "rating": 1}).Code)
+	assert.Equal(t, http.StatusOK, answer(bruno, 4, "  Great focus  ").Code)
+	assert.Equal(t, http.StatusConflict, answer(bruno, 1, "").Code)
+	assert.Equal(t, http.StatusOK, answer(carla, 3, "Too long").Code)
+	w := answer(ana, 3, "")
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	// Results are anonymous: a tally and sorted comments
+	var results models.SurveyResults
+	json.Unmarshal(w.Body.Bytes(), &results)
+	assert.Equal(t, models.SurveyResults{
+		Question:     "Worth it?",
+		Scale:        4,
+		Responses:    3,
+		Average:      3.33,
+		Distribution: []int{0, 0, 2, 1},
+		Comments:     []string{"Great focus", "Too long"},
+	}, results)
+	var respondents []models.SurveyRespondent
+	db.Find(&respondents)
+	assert.Len(t, respondents, 3)
+
+	var survey struct {
+		Question string               `json:"question"`
+		Answered bool                 `json:"answered"`
+		Results  models.SurveyResults `json:"results"`
+	}
+	json.Unmarshal(send(bruno, "GET", surveyPath, nil).Body.Bytes(), &survey)
+	assert.True(t, survey.Answered)
+	assert.Equal(t, "Worth it?", survey.Question)
+	assert.Equal(t, results, survey.Results)
+
+	// Answers were given on this scale to this question
+	assert.Equal(t, http.StatusConflict, send(ana, "PUT", "/boards/"+board.ID.String(), map[string]int{"survey_scale": 5}).Code)
+	assert.Equal(t, http.StatusConflict, send(ana, "PUT", "/boards/"+board.ID.String(), map[string]string{"survey_question": "Fun?"}).Code)
+
+	// Comments can be turned off
+	assert.Equal(t, http.StatusOK, send(ana, "PUT", "/boards/"+board.ID.String(), map[string]bool{"survey_comments": false}).Code)
+	dora := createTestUser(db, "dora", "user")
+	db.Create(&models.BoardMember{BoardID: board.ID, Username: "dora"})
+	assert.Equal(t, http.StatusBadRequest, answer(dora, 2, "Meh").Code)
+	assert.Equal(t, http.StatusOK, answer(dora, 2, "").Code)
+}
+
+func TestTeamSurveyTrend(t *testing.T) {
+	db, r := setupVoteReactionTest(t)
+	r.GET("/teams/:id/analytics", GetTeamAnalytics(db))
+	ana := createTestUser(db, "ana", "user")
+	team := models.Team{ID: uuid.New(), Name: "Platform", OwnerID: ana.ID}
+	db.Create(&team)
+	db.Create(&models.TeamMember{TeamID: team.ID, UserID: ana.ID, Role: "owner"})
+
+	start := time.Now().Add(-30 * 24 * time.Hour)
+	sprint := func(i int, survey *models.SurveyResults) models.Board {
+		finished := start.Add(time.Duration(i) * 7 * 24 * time.Hour)
+		board := models.Board{ID: uuid.New(), Name: "Sprint " + string(rune('1'+i)), Status: "finished", FinishedAt: &finished, Survey: survey}
+		db.Create(&board)
+		db.Model(&board).Association("Teams").Append(&team)
+		return board
+	}
+	second := sprint(1, &models.SurveyResults{Scale: 5, Responses: 4, Average: 4.5, Distribution: []int{0, 0, 0, 2, 2}})
+	first := sprint(0, &models.SurveyResults{Scale: 5, Responses: 2, Average: 3, Distribution: []int{0, 0, 2, 0, 0}})
+	sprint(2, nil)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, asUser(httptest.NewRequest("GET", "/teams/"+team.ID.String()+"/analytics", nil), ana))
+	assert.Equal(t, http.StatusOK, w.Code)
+	var stats models.TeamStats
+	json.Unmarshal(w.Body.Bytes(), &stats)
+
+	// Boards without answers are left out, the others run oldest first
+	if assert.Len(t, stats.SurveyTrend, 2) {
+		assert.Equal(t, first.ID, stats.SurveyTrend[0].BoardID)
+		assert.Equal(t, 3.0, stats.SurveyTrend[0].Average)
+		assert.Equal(t, second.ID, stats.SurveyTrend[1].BoardID)
+		assert.Equal(t, "Sprint 2", stats.SurveyTrend[1].BoardName)
+		assert.Equal(t, 4, stats.SurveyTrend[1].Responses)
+	}
+}
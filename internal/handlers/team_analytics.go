@@ -10,6 +10,9 @@ import (
 	"gorm.io/gorm"
 )
 
+// surveyTrendBoards is how many recent boards the closing survey trend covers
+const surveyTrendBoards = 12
+
 // GetTeamAnalytics returns aggregated statistics for a team
 func GetTeamAnalytics(db *gorm.DB) gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -73,6 +76,35 @@ func GetTeamAnalytics(db *gorm.DB) gin.HandlerFunc {
 		}
 		stats.TotalActionItems = totalActionItems
 
+		// Closing survey trend of the team's most recent answered boards
+		var surveyed []models.Board
+		if err := db.Model(&models.Board{}).
+			Select("boards.id, boards.name, boards.finished_at, boards.survey").
+			Joins("JOIN board_teams ON board_teams.board_id = boards.id").
+			Where("board_teams.team_id = ? AND boards.survey IS NOT NULL", teamID).
+			Order("boards.finished_at desc").
+			Limit(surveyTrendBoards).
+			Find(&surveyed).Error; err != nil {
+			log.Printf("Error loading survey trend: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to calculate statistics"})
+			return
+		}
+		stats.SurveyTrend = []models.SurveyTrendPoint{}
+		for i := len(surveyed) - 1; i >= 0; i-- {
+			board := surveyed[i]
+			if board.Survey == nil || board.Survey.Responses == 0 {
+				continue
+			}
+			stats.SurveyTrend = append(stats.SurveyTrend, models.SurveyTrendPoint{
+				BoardID:    board.ID,
+				BoardName:  board.Name,
+				FinishedAt: board.FinishedAt,
+				Scale:      board.Survey.Scale,
+				Average:    board.Survey.Average,
+				Responses:  board.Survey.Responses,
+			})
+		}
+
 		c.JSON(http.StatusOK, stats)
 	}
 }
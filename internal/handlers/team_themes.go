@@ -0,0 +1,252 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bento-lab-ops/bentro/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Defaults and bounds of the recurring themes report
+const (
+	defaultThemeBoards     = 10  // Most recent boards analysed
+	maxThemeBoards         = 50  // Upper bound of ?boards=
+	defaultThemeSimilarity = 0.5 // Trigram Jaccard similarity that links two cards
+	minThemeSimilarity     = 0.2 // Lower bound of ?similarity=, below it everything clusters
+)
+
+// themeCard is a card considered by the recurring themes report
+type themeCard struct {
+	models.ThemeCard
+	BoardName      string
+	BoardCreatedAt time.Time
+	labels         []string // Label names as shown
+	grams          map[string]bool
+}
+
+// themeClusters groups card indexes into clusters: cards sharing a label name,
+// or with a text similarity of at least threshold, end up in the same cluster
+func themeClusters(cards []themeCard, threshold float64) [][]int {
+	parent := make([]int, len(cards))
+	for i := range parent {
+		parent[i] = i
+	}
+	var find func(int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+		return parent[i]
+	}
+	union := func(i, j int) {
+		if ri, rj := find(i), find(j); ri != rj {
+			parent[rj] = ri
+		}
+	}
+
+	// Labels are board or team scoped, so across boards they match by name
+	byLabel := make(map[string]int)
+	for i, card := range cards {
+		for _, label := range card.labels {
+			key := strings.ToLower(label)
+			if first, ok := byLabel[key]; ok {
+				union(first, i)
+			} else {
+				byLabel[key] = i
+			}
+		}
+	}
+	for i := range cards {
+		for j := i + 1; j < len(cards); j++ {
+			if find(i) != find(j) && jaccard(cards[i].grams, cards[j].grams) >= threshold {
+				union(i, j)
+			}
+		}
+	}
+
+	groups := make(map[int][]int)
+	var roots []int
+	for i := range cards {
+		root := find(i)
+		if _, ok := groups[root]; !ok {
+			roots = append(roots, root)
+		}
+		groups[root] = append(groups[root], i)
+	}
+	clusters := make([][]int, len(roots))
+	for i, root := range roots {
+		clusters[i] = groups[root]
+	}
+	return clusters
+}
+
+// buildTheme summarises a cluster of cards, given oldest board first
+func buildTheme(cards []themeCard, cluster []int) models.RecurringTheme {
+	theme := models.RecurringTheme{Labels: []string{}, Occurrences: len(cluster)}
+	boards := make(map[uuid.UUID]int)
+	labelUses := make(map[string]int)
+	for _, i := range cluster {
+		card := cards[i]
+		theme.Cards = append(theme.Cards, card.ThemeCard)
+		if card.IsActionItem {
+			theme.ActionItems++
+			if card.Completed {
+				theme.CompletedActionItems++
+			}
+		}
+		for _, label := range card.labels {
+			if labelUses[label] == 0 {
+				theme.Labels = append(theme.Labels, label)
+			}
+			labelUses[label]++
+		}
+		if index, ok := boards[card.BoardID]; ok {
+			theme.Boards[index].Cards++
+			continue
+		}
+		boards[card.BoardID] = len(theme.Boards)
+		theme.Boards = append(theme.Boards, models.ThemeBoard{
+			ID: card.BoardID, Name: card.BoardName, CreatedAt: card.BoardCreatedAt, Cards: 1,
+		})
+	}
+	theme.FirstSeen = theme.Boards[0].CreatedAt
+	theme.LastSeen = theme.Boards[len(theme.Boards)-1].CreatedAt
+
+	sort.Strings(theme.Labels)
+	theme.Title = cards[cluster[0]].Content
+	best := 0
+	for _, label := range theme.Labels {
+		if labelUses[label] > best {
+			theme.Title, best = label, labelUses[label]
+		}
+	}
+	return theme
+}
+
+// GetTeamThemes reports the themes a team keeps raising: cards from the team's
+// recent boards clustered by shared labels and text similarity, keeping the
+// clusters that appeared on more than one board
+func GetTeamThemes(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		teamID, err := uuid.Parse(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid team ID"})
+			return
+		}
+
+		userID := c.MustGet("user_id").(uuid.UUID)
+
+		// Check if user is a member of the team
+		var member models.TeamMember
+		if err := db.Where("team_id = ? AND user_id = ?", teamID, userID).First(&member).Error; err != nil {
+			c.JSON(http.StatusForbidden, gin.H{"error": "You are not a member of this team"})
+			return
+		}
+
+		boardLimit := defaultThemeBoards
+		if value := c.Query("boards"); value != "" {
+			boardLimit, err = strconv.Atoi(value)
+			if err != nil || boardLimit < 1 || boardLimit > maxThemeBoards {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "boards must be between 1 and " + strconv.Itoa(maxThemeBoards)})
+				return
+			}
+		}
+		threshold := defaultThemeSimilarity
+		if value := c.Query("similarity"); value != "" {
+			threshold, err = strconv.ParseFloat(value, 64)
+			if err != nil || threshold < minThemeSimilarity || threshold > 1 {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "similarity must be between 0.2 and 1"})
+				return
+			}
+		}
+
+		// Boards still hiding their cards from everyone but the authors are left out
+		var boardIDs []uuid.UUID
+		if err := db.Model(&models.Board{}).
+			Joins("JOIN board_teams ON board_teams.board_id = boards.id").
+			Where("board_teams.team_id = ?", teamID).
+			Where("boards.private_writing = ? OR boards.cards_revealed_at IS NOT NULL", false).
+			Order("boards.created_at desc").
+			Limit(boardLimit).
+			Pluck("boards.id", &boardIDs).Error; err != nil {
+			log.Printf("Error listing team boards: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to calculate themes"})
+			return
+		}
+
+		var cards []themeCard
+		if len(boardIDs) > 0 {
+			if err := db.Model(&models.Card{}).
+				Select("cards.id, cards.content, cards.is_action_item, cards.completed, boards.id AS board_id, boards.name AS board_name, boards.created_at AS board_created_at").
+				Joins("JOIN columns ON columns.id = cards.column_id AND columns.deleted_at IS NULL").
+				Joins("JOIN boards ON boards.id = columns.board_id").
+				Where("boards.id IN ?", boardIDs).
+				Order("boards.created_at asc, cards.created_at asc").
+				Scan(&cards).Error; err != nil {
+				log.Printf("Error loading team cards: %v", err)
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to calculate themes"})
+				return
+			}
+		}
+
+		if len(cards) > 0 {
+			cardIndex := make(map[uuid.UUID]int, len(cards))
+			cardIDs := make([]uuid.UUID, len(cards))
+			for i, card := range cards {
+				cardIndex[card.ID] = i
+				cardIDs[i] = card.ID
+			}
+			var tags []struct {
+				CardID uuid.UUID
+				Name   string
+			}
+			if err := db.Table("card_labels").
+				Select("card_labels.card_id, labels.name").
+				Joins("JOIN labels ON labels.id = card_labels.label_id").
+				Where("card_labels.card_id IN ?", cardIDs).
+				Scan(&tags).Error; err != nil {
+				log.Printf("Error loading card labels: %v", err)
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to calculate themes"})
+				return
+			}
+			for _, tag := range tags {
+				card := &cards[cardIndex[tag.CardID]]
+				card.labels = append(card.labels, tag.Name)
+			}
+		}
+		for i := range cards {
+			cards[i].grams = trigrams(normalizeText(cards[i].Content))
+		}
+
+		themes := []models.RecurringTheme{}
+		for _, cluster := range themeClusters(cards, threshold) {
+			theme := buildTheme(cards, cluster)
+			if len(theme.Boards) > 1 {
+				themes = append(themes, theme)
+			}
+		}
+		sort.SliceStable(themes, func(i, j int) bool {
+			if len(themes[i].Boards) != len(themes[j].Boards) {
+				return len(themes[i].Boards) > len(themes[j].Boards)
+			}
+			if themes[i].Occurrences != themes[j].Occurrences {
+				return themes[i].Occurrences > themes[j].Occurrences
+			}
+			return themes[i].LastSeen.After(themes[j].LastSeen)
+		})
+
+		c.JSON(http.StatusOK, gin.H{
+			"team_id":         teamID,
+			"boards_analyzed": len(boardIDs),
+			"themes":          themes,
+		})
+	}
+}
@@ -0,0 +1,106 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/bento-lab-ops/bentro/internal/models"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTextSimilarity(t *testing.T) {
+	assert.Equal(t, []string{"deploy", "slow"}, normalizeText("The deploys are SLOW!"))
+	for _, words := range [][]string{
+		{"deploys", "deploy"}, {"testing", "tests", "tested"}, {"releases", "release"}, {"process", "processes"},
+		{"problemas", "problema"}, {"reuniões", "reunião"}, {"automação", "automações"}, {"lentamente", "lento"},
+	} {
+		for _, word := range words[1:] {
+			assert.Equal(t, stem(words[0]), stem(word), words[0]+" / "+word)
+		}
+	}
+	assert.Equal(t, 1.0, textSimilarity("Deploys are slow", "deploys, slow"))
+	assert.Greater(t, textSimilarity("Deploys are too slow", "Deploy is slow again"), 0.5)
+	assert.Greater(t, textSimilarity("Reuniões muito longas", "Reunião longa demais"), 0.5)
+	assert.Less(t, textSimilarity("Deploys are too slow", "Great team lunch"), 0.2)
+	assert.Equal(t, 0.0, textSimilarity("", "anything"))
+}
+
+func TestTeamThemes(t *testing.T) {
+	db, r := setupPolicyTest(t)
+	r.GET("/teams/:id/themes", GetTeamThemes(db))
+	alice := createTestUser(db, "alice", "user")
+	stranger := createTestUser(db, "stranger", "user")
+
+	team := models.Team{ID: uuid.New(), Name: "Platform", OwnerID: alice.ID}
+	db.Create(&team)
+	db.Create(&models.TeamMember{TeamID: team.ID, UserID: alice.ID, Role: "owner"})
+
+	start := time.Now().Add(-30 * 24 * time.Hour)
+	onCall := models.Label{ID: uuid.New(), TeamID: &team.ID, Name: "on-call", Color: defaultLabelColor}
+	db.Create(&onCall)
+	sprint := func(i int, private bool, contents ...string) []models.Card {
+		board := models.Board{ID: uuid.New(), Name: "Sprint " + string(rune('1'+i)), Status: "finished",
+			CreatedAt: start.Add(time.Duration(i) * 7 * 24 * time.Hour), PrivateWriting: private}
+		db.Create(&board)
+		db.Model(&board).Association("Teams").Append(&team)
+		col := models.Column{ID: uuid.New(), BoardID: board.ID, Name: "Went wrong"}
+		db.Create(&col)
+		var cards []models.Card
+		for _, content := range contents {
+			card := models.Card{ID: uuid.New(), ColumnID: col.ID, Content: content}
+			db.Create(&card)
+			cards = append(cards, card)
+		}
+		return cards
+	}
+	first := sprint(0, false, "Deploys are too slow", "Pager woke me up twice")
+	second := sprint(1, false, "Deploy is slow again", "Night alerts")
+	sprint(2, false, "Great team lunch")
+	// Hidden cards never feed the report
+	sprint(3, true, "Deploys are too slow")
+	db.Create(&models.CardLabel{CardID: first[1].ID, LabelID: onCall.ID})
+	db.Create(&models.CardLabel{CardID: second[1].ID, LabelID: onCall.ID})
+	db.Model(&models.Card{}).Where("id = ?", second[0].ID).Updates(map[string]interface{}{"is_action_item": true, "completed": true})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, asUser(httptest.NewRequest("GET", "/teams/"+team.ID.String()+"/themes", nil), stranger))
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, asUser(httptest.NewRequest("GET", "/teams/"+team.ID.String()+"/themes?similarity=0.1", nil), alice))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, asUser(httptest.NewRequest("GET", "/teams/"+team.ID.String()+"/themes", nil), alice))
+	assert.Equal(t, http.StatusOK, w.Code)
+	var report struct {
+		BoardsAnalyzed int                     `json:"boards_analyzed"`
+		Themes         []models.RecurringTheme `json:"themes"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &report)
+	assert.Equal(t, 3, report.BoardsAnalyzed)
+	if !assert.Len(t, report.Themes, 2) {
+		return
+	}
+
+	themes := map[string]models.RecurringTheme{}
+	for _, theme := range report.Themes {
+		themes[theme.Title] = theme
+	}
+	// Similar texts cluster; the earliest card names the theme
+	deploys := themes["Deploys are too slow"]
+	assert.Equal(t, 2, deploys.Occurrences)
+	assert.Len(t, deploys.Boards, 2)
+	assert.Equal(t, "Sprint 1", deploys.Boards[0].Name)
+	assert.Equal(t, 1, deploys.ActionItems)
+	assert.Equal(t, 1, deploys.CompletedActionItems)
+	// Shared labels cluster unrelated texts and name the theme
+	pager := themes["on-call"]
+	assert.Equal(t, 2, pager.Occurrences)
+	assert.Equal(t, []string{"on-call"}, pager.Labels)
+}
@@ -0,0 +1,111 @@
+package handlers
+
+import (
+	"strings"
+	"unicode"
+)
+
+// stopWords are dropped before comparing card texts (English and Portuguese)
+var stopWords = map[string]bool{
+	"a": true, "an": true, "and": true, "are": true, "as": true, "at": true, "be": true, "but": true,
+	"by": true, "for": true, "from": true, "has": true, "have": true, "in": true, "is": true, "it": true,
+	"its": true, "of": true, "on": true, "or": true, "so": true, "that": true, "the": true, "this": true,
+	"to": true, "too": true, "was": true, "we": true, "were": true, "with": true, "our": true, "us": true,
+	"o": true, "os": true, "um": true, "uma": true, "de": true, "da": true, "do": true,
+	"das": true, "dos": true, "e": true, "em": true, "no": true, "na": true, "nos": true, "nas": true,
+	"para": true, "por": true, "com": true, "que": true, "se": true, "ao": true, "muito": true, "mais": true,
+}
+
+// stemSuffixes are the English and Portuguese suffixes stem strips, longest
+// first. Plurals, verb forms and Portuguese gender endings share a stem.
+var stemSuffixes = []struct{ suffix, replacement string }{
+	{"amentos", ""}, {"imentos", ""},
+	{"amento", ""}, {"imento", ""}, {"idades", ""}, {"ations", ""}, {"amente", ""},
+	{"mente", ""}, {"idade", ""}, {"ation", ""}, {"ments", ""},
+	{"ment", ""}, {"ness", ""}, {"coes", "c"}, {"ings", ""}, {"ando", ""}, {"endo", ""}, {"indo", ""},
+	{"ados", ""}, {"idos", ""}, {"adas", ""}, {"idas", ""}, {"ated", ""},
+	{"ing", ""}, {"ies", "y"}, {"ied", "y"}, {"cao", "c"}, {"oes", ""}, {"aes", ""},
+	{"ado", ""}, {"ido", ""}, {"ada", ""}, {"ida", ""}, {"ate", ""},
+	{"ed", ""}, {"er", ""}, {"ly", ""}, {"es", ""}, {"os", ""}, {"as", ""}, {"ao", ""},
+	{"s", ""}, {"a", ""}, {"e", ""}, {"o", ""},
+}
+
+// Shortest stem left by stem, in characters
+const minStemLength = 3
+
+// diacritics maps Portuguese accented letters to their plain letter
+var diacritics = strings.NewReplacer(
+	"á", "a", "à", "a", "â", "a", "ã", "a", "é", "e", "ê", "e", "í", "i",
+	"ó", "o", "ô", "o", "õ", "o", "ú", "u", "ü", "u", "ç", "c",
+)
+
+// stem reduces a lower-case English or Portuguese word to a light stem by
+// stripping its first matching suffix. It is not a full stemmer, but inflections
+// of the same word end up with the same stem.
+func stem(word string) string {
+	word = diacritics.Replace(word)
+	for _, rule := range stemSuffixes {
+		if !strings.HasSuffix(word, rule.suffix) {
+			continue
+		}
+		// "process" is not the plural of "proces"
+		if rule.suffix == "s" && strings.HasSuffix(word, "ss") {
+			return word
+		}
+		stemmed := strings.TrimSuffix(word, rule.suffix)
+		if len([]rune(stemmed)) >= minStemLength {
+			return stemmed + rule.replacement
+		}
+	}
+	return word
+}
+
+// normalizeText lower-cases a text, splits it into words on anything that is
+// not a letter or digit, drops stop words and stems the rest
+func normalizeText(text string) []string {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	kept := words[:0]
+	for _, word := range words {
+		if !stopWords[word] {
+			kept = append(kept, stem(word))
+		}
+	}
+	return kept
+}
+
+// trigrams returns the character trigrams of the words, each word padded so
+// that short words and word boundaries still produce trigrams
+func trigrams(words []string) map[string]bool {
+	grams := make(map[string]bool)
+	for _, word := range words {
+		padded := []rune("  " + word + " ")
+		for i := 0; i+3 <= len(padded); i++ {
+			grams[string(padded[i:i+3])] = true
+		}
+	}
+	return grams
+}
+
+// jaccard returns the Jaccard similarity of two sets, from 0 (disjoint) to 1 (equal)
+func jaccard(a, b map[string]bool) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+	if len(a) > len(b) {
+		a, b = b, a
+	}
+	shared := 0
+	for gram := range a {
+		if b[gram] {
+			shared++
+		}
+	}
+	return float64(shared) / float64(len(a)+len(b)-shared)
+}
+
+// textSimilarity returns the trigram Jaccard similarity of two texts
+func textSimilarity(a, b string) float64 {
+	return jaccard(trigrams(normalizeText(a)), trigrams(normalizeText(b)))
+}
@@ -0,0 +1,291 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/bento-lab-ops/bentro/internal/database"
+	"github.com/bento-lab-ops/bentro/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// Board timer states
+const (
+	TimerIdle    = "idle"
+	TimerRunning = "running"
+	TimerPaused  = "paused"
+	TimerExpired = "expired"
+)
+
+// Board timer actions
+const (
+	TimerActionStart  = "start"
+	TimerActionPause  = "pause"
+	TimerActionResume = "resume"
+	TimerActionExtend = "extend"
+	TimerActionReset  = "reset"
+)
+
+const (
+	// Longest a board timer may run
+	maxTimerSeconds = 4 * 60 * 60
+
+	// How often expired board timers are looked for
+	timerSweepInterval = time.Second
+)
+
+var (
+	// ErrTimerSeconds means a timer length or extension is out of bounds
+	ErrTimerSeconds = fmt.Errorf("seconds must be between 1 and %d", maxTimerSeconds)
+	// ErrUnknownTimerAction means the timer action does not exist
+	ErrUnknownTimerAction = errors.New("unknown timer action")
+	// ErrTimerState means the action is not allowed in the timer's current state
+	ErrTimerState = errors.New("timer action not allowed")
+	// ErrTimerConflict means the timer changed while the action was applied
+	ErrTimerConflict = errors.New("board timer changed concurrently")
+)
+
+// validTimerSeconds reports whether a timer length or extension is within bounds
+func validTimerSeconds(seconds int) bool {
+	return seconds > 0 && seconds <= maxTimerSeconds
+}
+
+// timerRemaining returns the whole seconds left on a timer at the given time
+func timerRemaining(timer models.BoardTimer, now time.Time) int {
+	switch timer.State {
+	case TimerRunning:
+		if timer.EndsAt == nil || !timer.EndsAt.After(now) {
+			return 0
+		}
+		left := timer.EndsAt.Sub(now)
+		seconds := int(left / time.Second)
+		if left%time.Second > 0 {
+			seconds++
+		}
+		return seconds
+	case TimerPaused:
+		return timer.Remaining
+	}
+	return 0
+}
+
+// applyTimerAction returns the timer after an action taken at the given time
+func applyTimerAction(timer models.BoardTimer, action string, seconds int, now time.Time) (models.BoardTimer, error) {
+	state := timer.State
+	if state == "" {
+		state = TimerIdle
+	}
+
+	switch action {
+	case TimerActionStart:
+		if !validTimerSeconds(seconds) {
+			return timer, ErrTimerSeconds
+		}
+		endsAt := now.Add(time.Duration(seconds) * time.Second)
+		timer.State = TimerRunning
+		timer.Duration = seconds
+		timer.Remaining = 0
+		timer.EndsAt = &endsAt
+
+	case TimerActionPause:
+		if state != TimerRunning {
+			return timer, fmt.Errorf("%w: cannot pause a %s timer", ErrTimerState, state)
+		}
+		timer.Remaining = timerRemaining(timer, now)
+		timer.State = TimerPaused
+		timer.EndsAt = nil
+
+	case TimerActionResume:
+		if state != TimerPaused {
+			return timer, fmt.Errorf("%w: cannot resume a %s timer", ErrTimerState, state)
+		}
+		endsAt := now.Add(time.Duration(timer.Remaining) * time.Second)
+		timer.State = TimerRunning
+		timer.Remaining = 0
+		timer.EndsAt = &endsAt
+
+	case TimerActionExtend:
+		if !validTimerSeconds(seconds) {
+			return timer, ErrTimerSeconds
+		}
+		if state == TimerIdle {
+			return timer, fmt.Errorf("%w: cannot extend a timer that was not started", ErrTimerState)
+		}
+		if timerRemaining(timer, now)+seconds > maxTimerSeconds {
+			return timer, fmt.Errorf("%w: timers cannot run longer than %d seconds", ErrTimerState, maxTimerSeconds)
+		}
+		timer.Duration += seconds
+		switch state {
+		case TimerPaused:
+			timer.Remaining += seconds
+		case TimerRunning:
+			endsAt := timer.EndsAt.Add(time.Duration(seconds) * time.Second)
+			timer.EndsAt = &endsAt
+		case TimerExpired:
+			// Extending an expired timer gives the team extra time from now
+			endsAt := now.Add(time.Duration(seconds) * time.Second)
+			timer.State = TimerRunning
+			timer.EndsAt = &endsAt
+		}
+
+	case TimerActionReset:
+		timer = models.BoardTimer{State: TimerIdle}
+
+	default:
+		return timer, fmt.Errorf("%w %q", ErrUnknownTimerAction, action)
+	}
+	return timer, nil
+}
+
+// timerColumns maps a timer to the board columns it is stored in
+func timerColumns(timer models.BoardTimer) map[string]interface{} {
+	return map[string]interface{}{
+		"timer_state":        timer.State,
+		"timer_duration":     timer.Duration,
+		"timer_remaining":    timer.Remaining,
+		"timer_ends_at":      timer.EndsAt,
+		"timer_auto_advance": timer.AutoAdvance,
+		"timer_updated_by":   timer.UpdatedBy,
+	}
+}
+
+// updateBoardTimer applies a timer action to a board, persists it and broadcasts
+// timer_updated. The update only applies if the timer is still as it was read,
+// so two facilitators cannot both pause the same countdown or extend it by one
+// extension. autoAdvance is left unchanged when nil.
+func updateBoardTimer(board *models.Board, action string, seconds int, autoAdvance *bool, actor string) error {
+	timer, err := applyTimerAction(board.Timer, action, seconds, time.Now())
+	if err != nil {
+		return err
+	}
+	if autoAdvance != nil {
+		timer.AutoAdvance = *autoAdvance
+	}
+	timer.UpdatedBy = actor
+
+	query := database.DB.Model(&models.Board{}).Where("id = ?", board.ID)
+	if board.Timer.State == "" || board.Timer.State == TimerIdle {
+		query = query.Where("timer_state = '' OR timer_state = ? OR timer_state IS NULL", TimerIdle)
+	} else {
+		query = query.Where("timer_state = ?", board.Timer.State)
+	}
+	query = query.Where("timer_duration = ? AND timer_remaining = ?", board.Timer.Duration, board.Timer.Remaining)
+	if board.Timer.EndsAt == nil {
+		query = query.Where("timer_ends_at IS NULL")
+	} else {
+		query = query.Where("timer_ends_at = ?", *board.Timer.EndsAt)
+	}
+
+	result := query.Updates(timerColumns(timer))
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrTimerConflict
+	}
+
+	board.Timer = timer
+	BroadcastTimerUpdated(board.ID, action, timer)
+	return nil
+}
+
+// UpdateBoardTimer starts, pauses, resumes, extends or resets a board's timer (board managers only)
+func UpdateBoardTimer(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid board ID"})
+		return
+	}
+
+	var input struct {
+		Seconds     int   `json:"seconds"`
+		AutoAdvance *bool `json:"auto_advance"`
+	}
+
+	// Only start and extend take a body
+	if err := c.ShouldBindJSON(&input); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var board models.Board
+	if err := database.DB.First(&board, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Board not found"})
+		return
+	}
+	access, ok := authorizeBoard(c, &board, BoardActionManage)
+	if !ok {
+		return
+	}
+
+	if err := updateBoardTimer(&board, c.Param("action"), input.Seconds, input.AutoAdvance, access.Username); err != nil {
+		switch {
+		case errors.Is(err, ErrUnknownTimerAction), errors.Is(err, ErrTimerSeconds):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, ErrTimerState), errors.Is(err, ErrTimerConflict):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error(), "timer": board.Timer})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update board timer"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"timer": board.Timer, "server_time": time.Now()})
+}
+
+// StartBoardTimers expires running board timers as they run out. Timers live on
+// the board rather than in memory, so countdowns survive pod restarts; every pod
+// runs the sweep and the conditional update lets exactly one of them handle each
+// expiry.
+func StartBoardTimers() {
+	go func() {
+		ticker := time.NewTicker(timerSweepInterval)
+		defer ticker.Stop()
+		for now := range ticker.C {
+			expireBoardTimers(now)
+		}
+	}()
+}
+
+// expireBoardTimers marks the running timers that ran out by now as expired,
+// broadcasts it and advances the phase of boards that asked for it
+func expireBoardTimers(now time.Time) {
+	var boards []models.Board
+	if err := database.DB.Where("timer_state = ? AND timer_ends_at <= ?", TimerRunning, now).Find(&boards).Error; err != nil {
+		log.Printf("Failed to load expired board timers: %v", err)
+		return
+	}
+
+	for i := range boards {
+		board := &boards[i]
+		result := database.DB.Model(&models.Board{}).
+			Where("id = ? AND timer_state = ? AND timer_ends_at <= ?", board.ID, TimerRunning, now).
+			Updates(map[string]interface{}{"timer_state": TimerExpired, "timer_remaining": 0})
+		if result.Error != nil {
+			log.Printf("Failed to expire timer of board %s: %v", board.ID, result.Error)
+			continue
+		}
+		if result.RowsAffected == 0 {
+			continue // Another pod expired it, or a facilitator changed it
+		}
+
+		board.Timer.State = TimerExpired
+		board.Timer.Remaining = 0
+		BroadcastTimerUpdated(board.ID, "expired", board.Timer)
+
+		if !board.Timer.AutoAdvance {
+			continue
+		}
+		if next := nextPhase(board.Phase); next != "" {
+			if err := changeBoardPhase(board, next, "timer"); err != nil {
+				log.Printf("Failed to advance phase of board %s: %v", board.ID, err)
+			}
+		}
+	}
+}
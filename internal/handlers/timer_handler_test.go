@@ -0,0 +1,158 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/bento-lab-ops/bentro/internal/models"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestApplyTimerAction(t *testing.T) {
+	now := time.Now()
+
+	running, err := applyTimerAction(models.BoardTimer{}, TimerActionStart, 300, now)
+	assert.NoError(t, err)
+	assert.Equal(t, TimerRunning, running.State)
+	assert.Equal(t, 300, timerRemaining(running, now))
+
+	paused, err := applyTimerAction(running, TimerActionPause, 0, now.Add(100*time.Second))
+	assert.NoError(t, err)
+	assert.Equal(t, TimerPaused, paused.State)
+	assert.Equal(t, 200, paused.Remaining)
+	assert.Nil(t, paused.EndsAt)
+
+	extended, err := applyTimerAction(paused, TimerActionExtend, 60, now.Add(time.Hour))
+	assert.NoError(t, err)
+	assert.Equal(t, 260, extended.Remaining, "paused timers do not run down")
+	assert.Equal(t, 360, extended.Duration)
+
+	resumed, err := applyTimerAction(extended, TimerActionResume, 0, now.Add(time.Hour))
+	assert.NoError(t, err)
+	assert.Equal(t, 260, timerRemaining(resumed, now.Add(time.Hour)))
+
+	_, err = applyTimerAction(resumed, TimerActionResume, 0, now)
+	assert.ErrorIs(t, err, ErrTimerState)
+	_, err = applyTimerAction(models.BoardTimer{}, TimerActionExtend, 60, now)
+	assert.ErrorIs(t, err, ErrTimerState)
+	_, err = applyTimerAction(models.BoardTimer{}, TimerActionStart, maxTimerSeconds+1, now)
+	assert.ErrorIs(t, err, ErrTimerSeconds)
+	_, err = applyTimerAction(models.BoardTimer{}, "rewind", 0, now)
+	assert.ErrorIs(t, err, ErrUnknownTimerAction)
+
+	reset, err := applyTimerAction(resumed, TimerActionReset, 0, now)
+	assert.NoError(t, err)
+	assert.Equal(t, models.BoardTimer{State: TimerIdle}, reset)
+}
+
+func TestUpdateBoardTimer(t *testing.T) {
+	db, r := setupPolicyTest(t)
+	r.POST("/boards/:id/timer/:action", UpdateBoardTimer)
+	owner := createTestUser(db, "facilitator", "user")
+	participant := createTestUser(db, "attendee", "user")
+
+	board := models.Board{ID: uuid.New(), Name: "Timed", Owner: "facilitator", Status: "active"}
+	db.Create(&board)
+	db.Create(&models.BoardMember{BoardID: board.ID, Username: "attendee"})
+
+	timerAction := func(user models.User, action string, body map[string]interface{}) *httptest.ResponseRecorder {
+		var payload []byte
+		if body != nil {
+			payload, _ = json.Marshal(body)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, asUser(httptest.NewRequest("POST", "/boards/"+board.ID.String()+"/timer/"+action, bytes.NewBuffer(payload)), user))
+		return w
+	}
+	stored := func() models.BoardTimer {
+		var updated models.Board
+		db.First(&updated, board.ID)
+		return updated.Timer
+	}
+
+	// Only managers control the timer
+	assert.Equal(t, http.StatusForbidden, timerAction(participant, TimerActionStart, map[string]interface{}{"seconds": 60}).Code)
+	assert.Equal(t, http.StatusBadRequest, timerAction(owner, TimerActionStart, map[string]interface{}{"seconds": 0}).Code)
+	assert.Equal(t, http.StatusBadRequest, timerAction(owner, "rewind", nil).Code)
+	assert.Equal(t, http.StatusConflict, timerAction(owner, TimerActionPause, nil).Code)
+
+	w := timerAction(owner, TimerActionStart, map[string]interface{}{"seconds": 300, "auto_advance": true})
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "server_time")
+	timer := stored()
+	assert.Equal(t, TimerRunning, timer.State)
+	assert.Equal(t, 300, timer.Duration)
+	assert.True(t, timer.AutoAdvance)
+	assert.Equal(t, "facilitator", timer.UpdatedBy)
+	assert.NotNil(t, timer.EndsAt)
+
+	assert.Equal(t, http.StatusOK, timerAction(owner, TimerActionPause, nil).Code)
+	timer = stored()
+	assert.Equal(t, TimerPaused, timer.State)
+	assert.InDelta(t, 300, timer.Remaining, 1)
+
+	assert.Equal(t, http.StatusOK, timerAction(owner, TimerActionExtend, map[string]interface{}{"seconds": 60}).Code)
+	assert.InDelta(t, 360, stored().Remaining, 1)
+
+	// Of two extensions made on the same countdown, the second one conflicts
+	var first, second models.Board
+	db.First(&first, board.ID)
+	db.First(&second, board.ID)
+	assert.NoError(t, updateBoardTimer(&first, TimerActionExtend, 30, nil, "facilitator"))
+	assert.ErrorIs(t, updateBoardTimer(&second, TimerActionExtend, 30, nil, "facilitator"), ErrTimerConflict)
+	assert.InDelta(t, 390, stored().Remaining, 1)
+
+	assert.Equal(t, http.StatusOK, timerAction(owner, TimerActionResume, nil).Code)
+	timer = stored()
+	assert.Equal(t, TimerRunning, timer.State)
+	assert.True(t, timer.AutoAdvance, "auto advance is kept unless the request changes it")
+
+	assert.Equal(t, http.StatusOK, timerAction(owner, TimerActionReset, nil).Code)
+	assert.Equal(t, TimerIdle, stored().State)
+}
+
+func TestExpireBoardTimers(t *testing.T) {
+	db, _ := setupPolicyTest(t)
+
+	endsAt := time.Now().Add(-time.Second)
+	advancing := models.Board{ID: uuid.New(), Name: "Advancing", Status: "active", Phase: PhaseVoting,
+		Timer: models.BoardTimer{State: TimerRunning, Duration: 60, EndsAt: &endsAt, AutoAdvance: true}}
+	db.Create(&advancing)
+	staying := models.Board{ID: uuid.New(), Name: "Staying", Status: "active", Phase: PhaseInput,
+		Timer: models.BoardTimer{State: TimerRunning, Duration: 60, EndsAt: &endsAt}}
+	db.Create(&staying)
+	later := time.Now().Add(time.Minute)
+	running := models.Board{ID: uuid.New(), Name: "Running", Status: "active", Phase: PhaseInput,
+		Timer: models.BoardTimer{State: TimerRunning, Duration: 120, EndsAt: &later, AutoAdvance: true}}
+	db.Create(&running)
+
+	expireBoardTimers(time.Now())
+
+	var updated models.Board
+	db.First(&updated, advancing.ID)
+	assert.Equal(t, TimerExpired, updated.Timer.State)
+	assert.Equal(t, PhaseDiscuss, updated.Phase)
+	assert.Equal(t, "timer", updated.PhaseChangedBy)
+
+	updated = models.Board{}
+	db.First(&updated, staying.ID)
+	assert.Equal(t, TimerExpired, updated.Timer.State)
+	assert.Equal(t, PhaseInput, updated.Phase)
+
+	updated = models.Board{}
+	db.First(&updated, running.ID)
+	assert.Equal(t, TimerRunning, updated.Timer.State)
+	assert.Equal(t, PhaseInput, updated.Phase)
+
+	// A second sweep, as another pod would run, changes nothing
+	expireBoardTimers(time.Now())
+	updated = models.Board{}
+	db.First(&updated, advancing.ID)
+	assert.Equal(t, PhaseDiscuss, updated.Phase)
+}
@@ -136,6 +136,20 @@ func TestAdminUpdateBoardSettings(t *testing.T) {
 	assert.Equal(t, 10, updated.VoteLimit)
 	assert.True(t, updated.BlindVoting)
 	assert.Equal(t, "discuss", updated.Phase)
+	assert.Equal(t, "admin", updated.PhaseChangedBy)
+
+	// The override skips transitions, and reopens closed voting like any move back to voting
+	closedAt := time.Now()
+	db.Model(&board).Updates(map[string]interface{}{"phase": "closed", "voting_closed_at": closedAt})
+	body, _ = json.Marshal(map[string]string{"phase": "voting"})
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("PUT", "/admin/boards/"+board.ID.String()+"/settings", bytes.NewBuffer(body))
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	db.First(&updated, board.ID)
+	assert.Equal(t, "voting", updated.Phase)
+	assert.Nil(t, updated.VotingClosedAt)
 }
 
 func TestGetSystemStats(t *testing.T) {
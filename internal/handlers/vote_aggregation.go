@@ -0,0 +1,155 @@
+package handlers
+
+import (
+	"github.com/bento-lab-ops/bentro/internal/database"
+	"github.com/bento-lab-ops/bentro/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Votes on grouped cards follow one policy everywhere. A vote stays on the
+// card it was cast on, and a group counts each voter once per vote type, no
+// matter how many of its cards they voted on. The same holds for the vote
+// limit: all the votes of a voter on one top-level group are a single ballot.
+// Taking a card out of a group needs no bookkeeping, since its votes then
+// count for the card alone again.
+//
+// Dot and points voting are the exception: dots and points are a budget people
+// spend on purpose, so a group sums them over its cards, and budgets count
+// them. See VotingMethod.Weighted.
+
+// voteTally counts the voters who liked and disliked a group
+type voteTally struct {
+	Likes    int
+	Dislikes int
+}
+
+// ballot is what a vote counts as against the vote limit: the top-level
+// group of a grouped card, or the card itself
+type ballot struct {
+	target   uuid.UUID
+	voteType string
+}
+
+// groupChain returns a group and the groups above it, innermost first
+func groupChain(groups map[uuid.UUID]models.CardGroup, groupID *uuid.UUID) []uuid.UUID {
+	var chain []uuid.UUID
+	// Bounded by the number of groups, in case parents ever form a loop
+	for depth := 0; groupID != nil && depth < len(groups); depth++ {
+		group, ok := groups[*groupID]
+		if !ok {
+			break
+		}
+		chain = append(chain, group.ID)
+		groupID = group.ParentID
+	}
+	return chain
+}
+
+// indexGroups maps groups by ID
+func indexGroups(groups []models.CardGroup) map[uuid.UUID]models.CardGroup {
+	index := make(map[uuid.UUID]models.CardGroup, len(groups))
+	for _, group := range groups {
+		index[group.ID] = group
+	}
+	return index
+}
+
+// tallyGroupVotes counts the voters of each group, or sums their weights. The
+// votes on cards of nested groups count towards every group above them.
+// Cards need their votes loaded.
+func tallyGroupVotes(groups []models.CardGroup, cards []models.Card, weighted bool) map[uuid.UUID]voteTally {
+	type voter struct {
+		groupID  uuid.UUID
+		voteType string
+		userName string
+	}
+	index := indexGroups(groups)
+	seen := make(map[voter]bool)
+	tallies := make(map[uuid.UUID]voteTally, len(groups))
+	for _, card := range cards {
+		for _, groupID := range groupChain(index, card.GroupID) {
+			for _, vote := range card.Votes {
+				key := voter{groupID, vote.VoteType, vote.UserName}
+				if seen[key] && !weighted {
+					continue
+				}
+				seen[key] = true
+				weight := 1
+				if weighted {
+					weight = vote.Weight
+				}
+				tally := tallies[groupID]
+				if vote.VoteType == "like" {
+					tally.Likes += weight
+				} else {
+					tally.Dislikes += weight
+				}
+				tallies[groupID] = tally
+			}
+		}
+	}
+	return tallies
+}
+
+// cardGroupVotes tallies the groups a card is nested in, innermost first
+func cardGroupVotes(card models.Card, weighted bool) []models.GroupVoteTotal {
+	totals := []models.GroupVoteTotal{}
+	if card.GroupID == nil {
+		return totals
+	}
+	// Groups never span columns, so the card's column holds all of them
+	var groups []models.CardGroup
+	if err := database.DB.Where("column_id = ?", card.ColumnID).Find(&groups).Error; err != nil {
+		return totals
+	}
+	var cards []models.Card
+	if err := database.DB.Preload("Votes").Where("column_id = ? AND group_id IS NOT NULL", card.ColumnID).Find(&cards).Error; err != nil {
+		return totals
+	}
+	tallies := tallyGroupVotes(groups, cards, weighted)
+	for _, groupID := range groupChain(indexGroups(groups), card.GroupID) {
+		tally := tallies[groupID]
+		totals = append(totals, models.GroupVoteTotal{GroupID: groupID, Likes: tally.Likes, Dislikes: tally.Dislikes})
+	}
+	return totals
+}
+
+// voterBallots returns the ballots a voter cast on a board, and the ballot a
+// vote of the given type on card would be
+func voterBallots(tx *gorm.DB, boardID uuid.UUID, userName string, card models.Card, voteType string) (map[ballot]bool, ballot, error) {
+	var groups []models.CardGroup
+	if err := tx.
+		Joins("JOIN columns ON columns.id = card_groups.column_id").
+		Where("columns.board_id = ?", boardID).
+		Find(&groups).Error; err != nil {
+		return nil, ballot{}, err
+	}
+	index := indexGroups(groups)
+	ballotOf := func(cardID uuid.UUID, groupID *uuid.UUID, voteType string) ballot {
+		if chain := groupChain(index, groupID); len(chain) > 0 {
+			return ballot{chain[len(chain)-1], voteType}
+		}
+		return ballot{cardID, voteType}
+	}
+
+	var votes []struct {
+		CardID   uuid.UUID
+		GroupID  *uuid.UUID
+		VoteType string
+	}
+	if err := tx.Model(&models.Vote{}).
+		Select("votes.card_id, cards.group_id, votes.vote_type").
+		Joins("JOIN cards ON votes.card_id = cards.id").
+		Joins("JOIN columns ON cards.column_id = columns.id").
+		Where("columns.board_id = ? AND votes.user_name = ?", boardID, userName).
+		Scan(&votes).Error; err != nil {
+		return nil, ballot{}, err
+	}
+	ballots := make(map[ballot]bool, len(votes))
+	for _, vote := range votes {
+		ballots[ballotOf(vote.CardID, vote.GroupID, vote.VoteType)] = true
+	}
+	return ballots, ballotOf(card.ID, card.GroupID, voteType), nil
+}
@@ -0,0 +1,221 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/bento-lab-ops/bentro/internal/database"
+	"github.com/bento-lab-ops/bentro/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Voting modes of a board
+const (
+	VotingModeLike   = "like"   // One like or dislike per person and card
+	VotingModeDot    = "dot"    // Dots stacked on cards, spent from a budget
+	VotingModeRanked = "ranked" // Cards ranked by each person, counted by instant runoff
+	VotingModePoints = "points" // PointsBudget points split by each person among cards
+)
+
+// IsValidVotingMode reports whether the mode is a voting mode
+func IsValidVotingMode(mode string) bool {
+	_, ok := votingMethods[mode]
+	return ok
+}
+
+// isDotVoting reports whether a board uses dot voting. Boards without a mode use likes.
+func isDotVoting(board *models.Board) bool {
+	return board.VotingMode == VotingModeDot
+}
+
+var (
+	// ErrVoteLimitReached is returned when a vote exceeds the board's budget
+	ErrVoteLimitReached = errors.New("vote limit reached")
+	// ErrColumnVoteLimitReached is returned when a vote exceeds a column's budget
+	ErrColumnVoteLimitReached = errors.New("column vote limit reached")
+)
+
+// boardHasVotes reports whether anyone voted on a board
+func boardHasVotes(boardID uuid.UUID) bool {
+	var count int64
+	database.DB.Model(&models.Vote{}).
+		Joins("JOIN cards ON votes.card_id = cards.id").
+		Joins("JOIN columns ON cards.column_id = columns.id").
+		Where("columns.board_id = ?", boardID).
+		Count(&count)
+	return count > 0
+}
+
+// dotsSpent sums the dots a voter placed on a board, and in one of its columns
+func dotsSpent(tx *gorm.DB, boardID, columnID uuid.UUID, userName string) (int, int, error) {
+	var spent struct {
+		Board  int
+		Column int
+	}
+	err := tx.Model(&models.Vote{}).
+		Select("COALESCE(SUM(votes.weight), 0) AS board, COALESCE(SUM(CASE WHEN cards.column_id = ? THEN votes.weight ELSE 0 END), 0) AS \"column\"", columnID).
+		Joins("JOIN cards ON votes.card_id = cards.id").
+		Joins("JOIN columns ON cards.column_id = columns.id").
+		Where("columns.board_id = ? AND votes.user_name = ?", boardID, userName).
+		Scan(&spent).Error
+	return spent.Board, spent.Column, err
+}
+
+// budget reports the use of a limit
+func budget(limit, used int) models.VoteBudget {
+	remaining := -1
+	if limit > 0 {
+		remaining = limit - used
+		if remaining < 0 {
+			remaining = 0
+		}
+	}
+	return models.VoteBudget{Limit: limit, Used: used, Remaining: remaining}
+}
+
+// remainingVotes works out what a voter has left to spend on a board. Like
+// boards count ballots, as described in vote_aggregation.go.
+func remainingVotes(board *models.Board, userName string) (models.RemainingVotes, error) {
+	remaining := models.RemainingVotes{VotingMode: VotingModeLike, Columns: []models.ColumnVoteBudget{}}
+	if votingMethodOf(board).Ballots() {
+		// Ranked ballots spend cards, points ballots spend points
+		var spent struct {
+			Cards  int
+			Points int
+		}
+		if err := database.DB.Model(&models.Vote{}).
+			Select("COUNT(*) AS cards, COALESCE(SUM(votes.weight), 0) AS points").
+			Joins("JOIN cards ON votes.card_id = cards.id").
+			Joins("JOIN columns ON cards.column_id = columns.id").
+			Where("columns.board_id = ? AND votes.user_name = ?", board.ID, userName).
+			Scan(&spent).Error; err != nil {
+			return remaining, err
+		}
+		remaining.VotingMode = board.VotingMode
+		if board.VotingMode == VotingModePoints {
+			remaining.Board = budget(PointsBudget, spent.Points)
+		} else {
+			remaining.Board = budget(board.VoteLimit, spent.Cards)
+		}
+		return remaining, nil
+	}
+	if !isDotVoting(board) {
+		ballots, _, err := voterBallots(database.DB, board.ID, userName, models.Card{}, "")
+		if err != nil {
+			return remaining, err
+		}
+		remaining.Board = budget(board.VoteLimit, len(ballots))
+		return remaining, nil
+	}
+
+	remaining.VotingMode = VotingModeDot
+	var columns []models.Column
+	if err := database.DB.Where("board_id = ?", board.ID).Order("position asc").Find(&columns).Error; err != nil {
+		return remaining, err
+	}
+	var spent []struct {
+		ColumnID uuid.UUID
+		Dots     int
+	}
+	if err := database.DB.Model(&models.Vote{}).
+		Select("cards.column_id, SUM(votes.weight) AS dots").
+		Joins("JOIN cards ON votes.card_id = cards.id").
+		Joins("JOIN columns ON cards.column_id = columns.id").
+		Where("columns.board_id = ? AND votes.user_name = ?", board.ID, userName).
+		Group("cards.column_id").
+		Scan(&spent).Error; err != nil {
+		return remaining, err
+	}
+	dots := make(map[uuid.UUID]int, len(spent))
+	total := 0
+	for _, column := range spent {
+		dots[column.ColumnID] = column.Dots
+		total += column.Dots
+	}
+
+	remaining.Board = budget(board.VoteLimit, total)
+	for _, column := range columns {
+		if column.VoteLimit > 0 {
+			remaining.Columns = append(remaining.Columns, models.ColumnVoteBudget{
+				ColumnID:   column.ID,
+				VoteBudget: budget(column.VoteLimit, dots[column.ID]),
+			})
+		}
+	}
+	return remaining, nil
+}
+
+// GetRemainingVotes returns what a voter has left to spend on a board. Guests
+// name themselves with ?user_name=, as they do when voting.
+func GetRemainingVotes(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid board ID"})
+		return
+	}
+
+	var board models.Board
+	if err := database.DB.First(&board, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Board not found"})
+		return
+	}
+	access, ok := authorizeBoard(c, &board, BoardActionContribute)
+	if !ok {
+		return
+	}
+	voter := c.DefaultQuery("user_name", access.Username)
+	if voter == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "user_name is required"})
+		return
+	}
+	if !forbidImpersonation(c, access, voter) {
+		return
+	}
+
+	remaining, err := remainingVotes(&board, voter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to count votes"})
+		return
+	}
+
+	c.JSON(http.StatusOK, remaining)
+}
+
+// UpdateColumnVoteLimit sets the dots each person may place in a column
+func UpdateColumnVoteLimit(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid column ID"})
+		return
+	}
+
+	var input struct {
+		VoteLimit *int `json:"vote_limit" binding:"required,min=0"`
+	}
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var column models.Column
+	if err := database.DB.First(&column, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Column not found"})
+		return
+	}
+	if !authorizeColumn(c, column.ID) {
+		return
+	}
+
+	column.VoteLimit = *input.VoteLimit
+	if err := database.DB.Model(&column).Update("vote_limit", column.VoteLimit).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update column"})
+		return
+	}
+
+	BroadcastColumnVoteLimitChanged(column.BoardID, column.ID, column.VoteLimit)
+	c.JSON(http.StatusOK, column)
+}
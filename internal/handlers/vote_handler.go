@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"errors"
 	"log"
 	"net/http"
 
@@ -9,6 +10,7 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"gorm.io/gorm"
 )
 
 // AddVote adds a vote to a card
@@ -35,77 +37,127 @@ func AddVote(c *gin.Context) {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Card not found"})
 		return
 	}
-
-	vote := models.Vote{
-		CardID:   cardID,
-		UserName: input.UserName,
-		VoteType: input.VoteType,
+	access, board, ok := authorizeCard(c, card.ID)
+	if !ok || !forbidImpersonation(c, access, input.UserName) {
+		return
+	}
+	// Votes are only cast or withdrawn while voting is open
+	if !requirePhase(c, &board, PhaseActionVote, "Voting is closed (Phase: "+boardPhase(board.Phase)+")") {
+		return
 	}
 
-	// Check if user has ANY vote record (Active or Soft-Deleted)
-	var existingVote models.Vote
-	if err := database.DB.Unscoped().Where("card_id = ? AND user_name = ?", cardID, input.UserName).First(&existingVote).Error; err == nil {
-		// Found a record
-		if existingVote.DeletedAt.Valid {
-			// It was soft-deleted (Ghost). We want to VOTE again.
-			// Hard delete the ghost so we can create a fresh vote without Unique Constraint violation
-			database.DB.Unscoped().Delete(&existingVote)
-			// Proceed to Create logic below...
-		} else {
-			// It is Active. We want to UNVOTE.
-			// Hard delete to remove it physically
-			if err := database.DB.Unscoped().Delete(&existingVote).Error; err != nil {
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to remove vote"})
-				return
-			}
-
-			// Broadcast Update (Unvote)
-			broadcastVoteUpdate(cardID)
+	if votingMethodOf(&board).Ballots() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "This board takes whole ballots"})
+		return
+	}
 
-			c.JSON(http.StatusOK, gin.H{"message": "Vote removed", "toggled": true})
+	// Dot voting stacks dots instead of toggling a vote; DeleteVote takes them off again
+	if isDotVoting(&board) {
+		if input.VoteType != "like" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Dot voting has no dislikes"})
 			return
 		}
-	}
-
-	// Check Vote Limit AND Phase
-	var column models.Column
-	if err := database.DB.First(&column, card.ColumnID).Error; err == nil {
-		var board models.Board
-		if err := database.DB.First(&board, column.BoardID).Error; err == nil {
-			// Phase Check
-			if board.Phase != "voting" {
-				c.JSON(http.StatusBadRequest, gin.H{"error": "Voting is closed (Phase: " + board.Phase + ")"})
-				return
-			}
-
-			if board.VoteLimit > 0 {
-				var totalUserVotes int64
-				// Get all cards in this board
-				database.DB.Model(&models.Vote{}).
-					Joins("JOIN cards ON votes.card_id = cards.id").
-					Joins("JOIN columns ON cards.column_id = columns.id").
-					Where("columns.board_id = ? AND votes.user_name = ?", board.ID, input.UserName).
-					Count(&totalUserVotes)
-
-				if totalUserVotes >= int64(board.VoteLimit) {
-					c.JSON(http.StatusForbidden, gin.H{"error": "Vote limit reached"})
-					return
-				}
-			}
+		vote, err := addDot(&board, card, input.UserName)
+		if err != nil {
+			respondVoteError(c, err, "Failed to add vote")
+			return
 		}
+		broadcastVoteUpdate(cardID)
+		c.JSON(http.StatusCreated, vote)
+		return
 	}
 
-	if err := database.DB.Create(&vote).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to add vote"})
+	// Voting again on a card withdraws the vote
+	vote, removed, err := toggleVote(&board, card, input.UserName, input.VoteType)
+	if err != nil {
+		respondVoteError(c, err, "Failed to add vote")
 		return
 	}
 
 	// Broadcast Update
 	broadcastVoteUpdate(cardID)
 
+	if removed {
+		c.JSON(http.StatusOK, gin.H{"message": "Vote removed", "toggled": true})
+		return
+	}
 	c.JSON(http.StatusCreated, vote)
 }
 
+// SubmitBallot replaces the caller's votes on a ranked or points voting board
+func SubmitBallot(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid board ID"})
+		return
+	}
+
+	var input struct {
+		UserName string `json:"user_name" binding:"required"`
+		Votes    []struct {
+			CardID uuid.UUID `json:"card_id" binding:"required"`
+			Weight int       `json:"weight"` // Rank, or points
+		} `json:"votes"`
+	}
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var board models.Board
+	if err := database.DB.First(&board, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Board not found"})
+		return
+	}
+	access, ok := authorizeBoard(c, &board, BoardActionContribute)
+	if !ok || !forbidImpersonation(c, access, input.UserName) {
+		return
+	}
+	if !requirePhase(c, &board, PhaseActionVote, "Voting is closed (Phase: "+boardPhase(board.Phase)+")") {
+		return
+	}
+
+	method := votingMethodOf(&board)
+	if !method.Ballots() {
+		respondVoteError(c, ErrVotesCardByCard, "")
+		return
+	}
+	votes := make([]models.Vote, len(input.Votes))
+	cardIDs := make([]uuid.UUID, len(input.Votes))
+	for i, entry := range input.Votes {
+		votes[i] = models.Vote{CardID: entry.CardID, UserName: input.UserName, VoteType: "like", Weight: entry.Weight}
+		cardIDs[i] = entry.CardID
+	}
+	if err := method.ValidateBallot(&board, votes); err != nil {
+		respondVoteError(c, err, "Failed to validate ballot")
+		return
+	}
+	if len(cardIDs) > 0 {
+		var onBoard int64
+		database.DB.Model(&models.Card{}).
+			Joins("JOIN columns ON cards.column_id = columns.id").
+			Where("columns.board_id = ? AND cards.id IN ?", board.ID, cardIDs).
+			Count(&onBoard)
+		if int(onBoard) != len(cardIDs) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Ballot votes on cards of another board"})
+			return
+		}
+	}
+
+	changed, err := replaceBallot(&board, input.UserName, votes)
+	if err != nil {
+		respondVoteError(c, err, "Failed to save ballot")
+		return
+	}
+
+	for _, cardID := range changed {
+		broadcastVoteUpdate(cardID)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"votes": votes})
+}
+
 // GetVotes retrieves all votes for a card with counts
 func GetVotes(c *gin.Context) {
 	cardID, err := uuid.Parse(c.Param("id"))
@@ -114,7 +166,7 @@ func GetVotes(c *gin.Context) {
 		return
 	}
 
-	votes, likes, dislikes, isBlind := getVoteData(cardID, c.Query("user"))
+	votes, likes, dislikes, groups, isBlind := getVoteData(cardID, c.Query("user"))
 
 	if isBlind {
 		// Hide count and other votes
@@ -122,6 +174,7 @@ func GetVotes(c *gin.Context) {
 			"votes":    votes, // Contains only user votes
 			"likes":    -1,    // Indicator for hidden
 			"dislikes": -1,
+			"groups":   groups,
 		})
 		return
 	}
@@ -130,10 +183,11 @@ func GetVotes(c *gin.Context) {
 		"votes":    votes,
 		"likes":    likes,
 		"dislikes": dislikes,
+		"groups":   groups, // Totals of the groups the card is nested in
 	})
 }
 
-// DeleteVote removes a vote
+// DeleteVote removes a vote, or one of its dots on a dot voting board
 func DeleteVote(c *gin.Context) {
 	id, err := uuid.Parse(c.Param("id"))
 	if err != nil {
@@ -150,22 +204,63 @@ func DeleteVote(c *gin.Context) {
 
 	cardID := vote.CardID
 
-	if err := database.DB.Delete(&vote).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete vote"})
+	// Voters remove their own votes; managers may remove any
+	access, board, ok := authorizeCard(c, cardID)
+	if !ok || !forbidImpersonation(c, access, vote.UserName) {
+		return
+	}
+	if !requirePhase(c, &board, PhaseActionVote, "Voting is closed (Phase: "+boardPhase(board.Phase)+")") {
+		return
+	}
+
+	weight, err := removeVote(&board, vote)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Vote not found"})
+		return
+	}
+	if err != nil {
+		respondVoteError(c, err, "Failed to delete vote")
 		return
 	}
 
 	// Broadcast Update
 	broadcastVoteUpdate(cardID)
 
+	if weight > 0 {
+		c.JSON(http.StatusOK, gin.H{"message": "Dot removed", "weight": weight})
+		return
+	}
 	c.JSON(http.StatusOK, gin.H{"message": "Vote deleted successfully"})
 }
 
+// respondVoteError tells a voter why their vote was refused. Errors no voter
+// can act on are answered with the fallback message.
+func respondVoteError(c *gin.Context, err error, fallback string) {
+	switch {
+	case errors.Is(err, ErrVotingClosed):
+		c.JSON(http.StatusConflict, gin.H{"error": "Voting is closed"})
+	case errors.Is(err, ErrVoteLimitReached):
+		c.JSON(http.StatusForbidden, gin.H{"error": "Vote limit reached"})
+	case errors.Is(err, ErrColumnVoteLimitReached):
+		c.JSON(http.StatusForbidden, gin.H{"error": "Column vote limit reached"})
+	case errors.Is(err, ErrVotesCardByCard):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "This board is voted card by card"})
+	case errors.Is(err, ErrBallotPoints):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Points must be positive"})
+	case errors.Is(err, ErrBallotRanks):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Ranks must run from 1 without gaps"})
+	case errors.Is(err, ErrBallotRepeats):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "A ballot votes on each card once"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fallback})
+	}
+}
+
 // Helper to broadcast vote updates
 func broadcastVoteUpdate(cardID uuid.UUID) {
 	// Re-fetch data using the same logic as GetVotes
 	// We need to know if it's blind voting.
-	_, likes, dislikes, isBlind := getVoteData(cardID, "")
+	_, likes, dislikes, groups, isBlind := getVoteData(cardID, "")
 
 	if isBlind {
 		likes = -1
@@ -179,7 +274,7 @@ func broadcastVoteUpdate(cardID uuid.UUID) {
 		var column models.Column
 		if err := database.DB.Select("board_id").First(&column, card.ColumnID).Error; err == nil {
 			// Use the new granular broadcast function
-			BroadcastVoteUpdate(column.BoardID, cardID, likes, dislikes)
+			BroadcastVoteUpdate(column.BoardID, cardID, likes, dislikes, groups)
 		} else {
 			log.Printf("[Vote Update] Failed to find Board for Column %s: %v\n", card.ColumnID, err)
 		}
@@ -188,11 +283,11 @@ func broadcastVoteUpdate(cardID uuid.UUID) {
 	}
 }
 
-// Helper to calculate votes
-func getVoteData(cardID uuid.UUID, currentUser string) ([]models.Vote, int, int, bool) {
+// Helper to calculate votes. Group totals hold -1 while voting is blind.
+func getVoteData(cardID uuid.UUID, currentUser string) ([]models.Vote, int, int, []models.GroupVoteTotal, bool) {
 	var votes []models.Vote
 	if err := database.DB.Where("card_id = ?", cardID).Find(&votes).Error; err != nil {
-		return []models.Vote{}, 0, 0, false
+		return []models.Vote{}, 0, 0, []models.GroupVoteTotal{}, false
 	}
 
 	// Check Board Phase for Blind Voting
@@ -204,7 +299,7 @@ func getVoteData(cardID uuid.UUID, currentUser string) ([]models.Vote, int, int,
 		var column models.Column
 		if err := database.DB.First(&column, card.ColumnID).Error; err == nil {
 			if err := database.DB.First(&board, column.BoardID).Error; err == nil {
-				if board.BlindVoting && board.Phase == "voting" {
+				if board.BlindVoting && board.Phase == "voting" && board.VotingClosedAt == nil {
 					isBlindVoting = true
 				}
 			}
@@ -215,11 +310,17 @@ func getVoteData(cardID uuid.UUID, currentUser string) ([]models.Vote, int, int,
 	dislikes := 0
 	userVotes := []models.Vote{}
 
+	// Dots and points add up; a rank is one voter
+	weighted := votingMethodOf(&board).Weighted()
 	for _, vote := range votes {
+		weight := 1
+		if weighted {
+			weight = vote.Weight
+		}
 		if vote.VoteType == "like" {
-			likes++
+			likes += weight
 		} else {
-			dislikes++
+			dislikes += weight
 		}
 
 		// Optimization: Only gather userVotes if needed (for GetVotes response)
@@ -230,11 +331,18 @@ func getVoteData(cardID uuid.UUID, currentUser string) ([]models.Vote, int, int,
 		}
 	}
 
+	groups := cardGroupVotes(card, weighted)
+	if isBlindVoting {
+		for i := range groups {
+			groups[i].Likes, groups[i].Dislikes = -1, -1
+		}
+	}
+
 	if isBlindVoting && currentUser != "" {
-		return userVotes, likes, dislikes, true
+		return userVotes, likes, dislikes, groups, true
 	} else if isBlindVoting {
-		return votes, likes, dislikes, true
+		return votes, likes, dislikes, groups, true
 	}
 
-	return votes, likes, dislikes, false
+	return votes, likes, dislikes, groups, false
 }
@@ -0,0 +1,219 @@
+package handlers
+
+import (
+	"errors"
+
+	"github.com/bento-lab-ops/bentro/internal/database"
+	"github.com/bento-lab-ops/bentro/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Votes are counted and written in one transaction that first locks the
+// voter, so parallel requests of one person (a double click, two tabs) take
+// turns and never overspend a budget, and then checks that voting is still
+// open. Postgres locks the voter's row until the transaction ends; SQLite
+// runs write transactions one at a time, see database.SQLiteDSN. Votes are
+// deleted for good, so the unique index on card and voter only ever sees
+// live votes.
+
+// ErrVotingClosed is returned for votes on a board whose voting was closed
+var ErrVotingClosed = errors.New("voting is closed")
+
+// lockVotingOpen checks that a board's votes may still change, and keeps its
+// voting from being closed until the transaction ends. Closing voting updates
+// the board row, so it waits for the votes in flight and the results snapshot
+// sees them all.
+func lockVotingOpen(tx *gorm.DB, boardID uuid.UUID) error {
+	var board models.Board
+	if err := tx.Clauses(clause.Locking{Strength: "SHARE"}).
+		Select("id", "voting_closed_at").
+		First(&board, "id = ?", boardID).Error; err != nil {
+		return err
+	}
+	if board.VotingClosedAt != nil {
+		return ErrVotingClosed
+	}
+	return nil
+}
+
+// lockVoter locks the votes of a person on a board until the transaction ends
+func lockVoter(tx *gorm.DB, boardID uuid.UUID, userName string) error {
+	voter := models.BoardVoter{BoardID: boardID, UserName: userName}
+	if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&voter).Error; err != nil {
+		return err
+	}
+	return tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("board_id = ? AND user_name = ?", boardID, userName).
+		First(&voter).Error
+}
+
+// clearWithdrawnVote removes a vote withdrawn before votes were deleted for
+// good, which would still hold the voter's place on the card
+func clearWithdrawnVote(tx *gorm.DB, cardID uuid.UUID, userName string) error {
+	return tx.Unscoped().
+		Where("card_id = ? AND user_name = ? AND deleted_at IS NOT NULL", cardID, userName).
+		Delete(&models.Vote{}).Error
+}
+
+// toggleVote casts a like or dislike on a card, or withdraws the voter's vote
+// on it. It reports whether the vote was withdrawn.
+func toggleVote(board *models.Board, card models.Card, userName, voteType string) (models.Vote, bool, error) {
+	var vote models.Vote
+	removed := false
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := lockVoter(tx, board.ID, userName); err != nil {
+			return err
+		}
+		if err := lockVotingOpen(tx, board.ID); err != nil {
+			return err
+		}
+		if err := clearWithdrawnVote(tx, card.ID, userName); err != nil {
+			return err
+		}
+
+		err := tx.Where("card_id = ? AND user_name = ?", card.ID, userName).First(&vote).Error
+		if err == nil {
+			removed = true
+			return tx.Unscoped().Delete(&vote).Error
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+
+		// Another vote on a group the voter already voted on costs nothing,
+		// since the group counts each voter once
+		if board.VoteLimit > 0 {
+			ballots, next, err := voterBallots(tx, board.ID, userName, card, voteType)
+			if err != nil {
+				return err
+			}
+			if !ballots[next] && len(ballots) >= board.VoteLimit {
+				return ErrVoteLimitReached
+			}
+		}
+		vote = models.Vote{CardID: card.ID, UserName: userName, VoteType: voteType}
+		return tx.Create(&vote).Error
+	})
+	return vote, removed, err
+}
+
+// addDot places one more dot of a voter on a card, within the budgets of the
+// board and of the card's column
+func addDot(board *models.Board, card models.Card, userName string) (models.Vote, error) {
+	var vote models.Vote
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := lockVoter(tx, board.ID, userName); err != nil {
+			return err
+		}
+		if err := lockVotingOpen(tx, board.ID); err != nil {
+			return err
+		}
+		var column models.Column
+		if err := tx.First(&column, card.ColumnID).Error; err != nil {
+			return err
+		}
+		boardDots, columnDots, err := dotsSpent(tx, board.ID, column.ID, userName)
+		if err != nil {
+			return err
+		}
+		if board.VoteLimit > 0 && boardDots >= board.VoteLimit {
+			return ErrVoteLimitReached
+		}
+		if column.VoteLimit > 0 && columnDots >= column.VoteLimit {
+			return ErrColumnVoteLimitReached
+		}
+		if err := clearWithdrawnVote(tx, card.ID, userName); err != nil {
+			return err
+		}
+
+		err = tx.Where("card_id = ? AND user_name = ?", card.ID, userName).First(&vote).Error
+		if err == nil {
+			vote.Weight++
+			return tx.Model(&vote).Update("weight", gorm.Expr("weight + 1")).Error
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+		vote = models.Vote{CardID: card.ID, UserName: userName, VoteType: "like", Weight: 1}
+		return tx.Create(&vote).Error
+	})
+	return vote, err
+}
+
+// removeVote deletes a vote, or takes one dot off it on a dot voting board.
+// It returns the dots left on the card, 0 once the vote is gone.
+func removeVote(board *models.Board, vote models.Vote) (int, error) {
+	weight := 0
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := lockVoter(tx, board.ID, vote.UserName); err != nil {
+			return err
+		}
+		if err := lockVotingOpen(tx, board.ID); err != nil {
+			return err
+		}
+		// The vote may have changed while waiting for the lock
+		if err := tx.First(&vote, vote.ID).Error; err != nil {
+			return err
+		}
+		if isDotVoting(board) && vote.Weight > 1 {
+			weight = vote.Weight - 1
+			return tx.Model(&vote).Update("weight", weight).Error
+		}
+		return tx.Unscoped().Delete(&vote).Error
+	})
+	return weight, err
+}
+
+// replaceBallot swaps a voter's votes on a board for a validated ballot. It
+// returns the cards whose votes changed.
+func replaceBallot(board *models.Board, userName string, votes []models.Vote) ([]uuid.UUID, error) {
+	var changed []uuid.UUID
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := lockVoter(tx, board.ID, userName); err != nil {
+			return err
+		}
+		if err := lockVotingOpen(tx, board.ID); err != nil {
+			return err
+		}
+		var previous []models.Vote
+		if err := tx.Unscoped().Select("votes.id, votes.card_id").
+			Joins("JOIN cards ON votes.card_id = cards.id").
+			Joins("JOIN columns ON cards.column_id = columns.id").
+			Where("columns.board_id = ? AND votes.user_name = ?", board.ID, userName).
+			Find(&previous).Error; err != nil {
+			return err
+		}
+		if len(previous) > 0 {
+			if err := tx.Unscoped().Delete(&previous).Error; err != nil {
+				return err
+			}
+		}
+		for _, vote := range previous {
+			changed = append(changed, vote.CardID)
+		}
+		if len(votes) == 0 {
+			return nil
+		}
+		for _, vote := range votes {
+			changed = append(changed, vote.CardID)
+		}
+		return tx.Create(&votes).Error
+	})
+	return uniqueIDs(changed), err
+}
+
+// uniqueIDs drops repeated IDs, keeping the first of each
+func uniqueIDs(ids []uuid.UUID) []uuid.UUID {
+	seen := make(map[uuid.UUID]bool, len(ids))
+	unique := ids[:0]
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			unique = append(unique, id)
+		}
+	}
+	return unique
+}
@@ -6,6 +6,8 @@ import (
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"path/filepath"
+	"sync"
 	"testing"
 
 	"github.com/bento-lab-ops/bentro/internal/database"
@@ -32,7 +34,17 @@ func setupVoteReactionTest(t *testing.T) (*gorm.DB, *gin.Engine) {
 		&models.Column{},
 		&models.Card{},
 		&models.Vote{},
+		&models.BoardVoter{},
 		&models.Reaction{},
+		&models.CardComment{},
+		&models.CardRevision{},
+		&models.Label{},
+		&models.CardLabel{},
+		&models.CardGroup{},
+		&models.Team{},
+		&models.TeamMember{},
+		&models.BoardMember{},
+		&models.SurveyRespondent{},
 	)
 	if err != nil {
 		panic(err)
@@ -41,6 +53,7 @@ func setupVoteReactionTest(t *testing.T) (*gorm.DB, *gin.Engine) {
 	database.DB = db
 	gin.SetMode(gin.TestMode)
 	r := gin.Default()
+	r.Use(simulateAuth())
 
 	// Vote Routes
 	r.POST("/cards/:id/votes", AddVote)
@@ -57,8 +70,12 @@ func TestVoteFlow(t *testing.T) {
 	db, r := setupVoteReactionTest(t)
 
 	// Setup Board, Column, Card
+	user1 := createTestUser(db, "user1", "user")
+	user2 := createTestUser(db, "user2", "user")
 	board := models.Board{ID: uuid.New(), Name: "Vote Board", Phase: "voting"} // Phase must be voting
 	db.Create(&board)
+	db.Create(&models.BoardMember{BoardID: board.ID, Username: "user1"})
+	db.Create(&models.BoardMember{BoardID: board.ID, Username: "user2"})
 	col := models.Column{ID: uuid.New(), BoardID: board.ID, Name: "Col1"}
 	db.Create(&col)
 	card := models.Card{ID: uuid.New(), ColumnID: col.ID, Content: "Votable"}
@@ -69,7 +86,7 @@ func TestVoteFlow(t *testing.T) {
 	body, _ := json.Marshal(input)
 	w := httptest.NewRecorder()
 	req, _ := http.NewRequest("POST", "/cards/"+card.ID.String()+"/votes", bytes.NewBuffer(body))
-	r.ServeHTTP(w, req)
+	r.ServeHTTP(w, asUser(req, user1))
 
 	assert.Equal(t, http.StatusCreated, w.Code)
 
@@ -79,11 +96,11 @@ func TestVoteFlow(t *testing.T) {
 
 	// 2. Toggle Vote (Remove) - sending same request again should toggle it off
 	w2 := httptest.NewRecorder()
-	r.ServeHTTP(w2, req) // Re-use request? No, Body is consumed.
+	r.ServeHTTP(w2, asUser(req, user1)) // Re-use request? No, Body is consumed.
 
 	w2 = httptest.NewRecorder()
 	req2, _ := http.NewRequest("POST", "/cards/"+card.ID.String()+"/votes", bytes.NewBuffer(body))
-	r.ServeHTTP(w2, req2)
+	r.ServeHTTP(w2, asUser(req2, user1))
 
 	assert.Equal(t, http.StatusOK, w2.Code)
 	var resp map[string]interface{}
@@ -103,7 +120,7 @@ func TestVoteFlow(t *testing.T) {
 	input2 := map[string]string{"user_name": "user2", "vote_type": "like"}
 	body2, _ := json.Marshal(input2)
 	w3 := httptest.NewRecorder()
-	r.ServeHTTP(w3, httptest.NewRequest("POST", "/cards/"+card.ID.String()+"/votes", bytes.NewBuffer(body2)))
+	r.ServeHTTP(w3, asUser(httptest.NewRequest("POST", "/cards/"+card.ID.String()+"/votes", bytes.NewBuffer(body2)), user2))
 	assert.Equal(t, http.StatusCreated, w3.Code)
 
 	// User2 tries to vote again on SAME card -> Toggle OFF (Allowed even at limit? Yes, removing vote reduces count)
@@ -113,8 +130,18 @@ func TestVoteFlow(t *testing.T) {
 	db.Create(&card2)
 
 	w4 := httptest.NewRecorder()
-	r.ServeHTTP(w4, httptest.NewRequest("POST", "/cards/"+card2.ID.String()+"/votes", bytes.NewBuffer(body2)))
+	r.ServeHTTP(w4, asUser(httptest.NewRequest("POST", "/cards/"+card2.ID.String()+"/votes", bytes.NewBuffer(body2)), user2))
 	assert.Equal(t, http.StatusForbidden, w4.Code) // Limit reached
+
+	// Votes stay once the board has left voting
+	var kept models.Vote
+	db.Where("card_id = ? AND user_name = ?", card.ID, "user2").First(&kept)
+	db.Model(&board).Update("phase", "discuss")
+	w5 := httptest.NewRecorder()
+	r.ServeHTTP(w5, asUser(httptest.NewRequest("DELETE", "/votes/"+kept.ID.String(), nil), user2))
+	assert.Equal(t, http.StatusConflict, w5.Code)
+	db.Model(&models.Vote{}).Where("id = ?", kept.ID).Count(&count)
+	assert.Equal(t, int64(1), count)
 }
 
 func TestGetVotesBlind(t *testing.T) {
@@ -155,7 +182,13 @@ func TestGetVotesBlind(t *testing.T) {
 
 func TestReactionFlow(t *testing.T) {
 	db, r := setupVoteReactionTest(t)
-	card := models.Card{ID: uuid.New(), Content: "React Me"}
+	user1 := createTestUser(db, "user1", "user")
+	board := models.Board{ID: uuid.New(), Name: "Reaction Board"}
+	db.Create(&board)
+	db.Create(&models.BoardMember{BoardID: board.ID, Username: "user1"})
+	col := models.Column{ID: uuid.New(), BoardID: board.ID, Name: "Col1"}
+	db.Create(&col)
+	card := models.Card{ID: uuid.New(), ColumnID: col.ID, Content: "React Me"}
 	db.Create(&card)
 
 	// Toggle On
@@ -163,7 +196,7 @@ func TestReactionFlow(t *testing.T) {
 	body, _ := json.Marshal(input)
 	w := httptest.NewRecorder()
 	req, _ := http.NewRequest("POST", "/cards/"+card.ID.String()+"/reactions", bytes.NewBuffer(body))
-	r.ServeHTTP(w, req)
+	r.ServeHTTP(w, asUser(req, user1))
 	assert.Equal(t, http.StatusOK, w.Code)
 
 	var reaction models.Reaction
@@ -172,7 +205,7 @@ func TestReactionFlow(t *testing.T) {
 
 	// Toggle Off
 	w2 := httptest.NewRecorder()
-	r.ServeHTTP(w2, httptest.NewRequest("POST", "/cards/"+card.ID.String()+"/reactions", bytes.NewBuffer(body)))
+	r.ServeHTTP(w2, asUser(httptest.NewRequest("POST", "/cards/"+card.ID.String()+"/reactions", bytes.NewBuffer(body)), user1))
 	assert.Equal(t, http.StatusOK, w2.Code)
 
 	var count int64
@@ -183,6 +216,425 @@ func TestReactionFlow(t *testing.T) {
 	inputBad := map[string]string{"user_name": "user1", "reaction_type": "hate"}
 	bodyBad, _ := json.Marshal(inputBad)
 	w3 := httptest.NewRecorder()
-	r.ServeHTTP(w3, httptest.NewRequest("POST", "/cards/"+card.ID.String()+"/reactions", bytes.NewBuffer(bodyBad)))
+	r.ServeHTTP(w3, asUser(httptest.NewRequest("POST", "/cards/"+card.ID.String()+"/reactions", bytes.NewBuffer(bodyBad)), user1))
 	assert.Equal(t, http.StatusBadRequest, w3.Code)
 }
+
+func TestGroupedVotes(t *testing.T) {
+	db, r := setupVoteReactionTest(t)
+	r.POST("/cards/:id/unmerge", UnmergeCard)
+	user1 := createTestUser(db, "user1", "user")
+	user2 := createTestUser(db, "user2", "user")
+	board := models.Board{ID: uuid.New(), Name: "Vote Board", Phase: "voting", VoteLimit: 2}
+	db.Create(&board)
+	db.Create(&models.BoardMember{BoardID: board.ID, Username: "user1"})
+	db.Create(&models.BoardMember{BoardID: board.ID, Username: "user2"})
+	col := models.Column{ID: uuid.New(), BoardID: board.ID, Name: "Col1"}
+	db.Create(&col)
+	tooling := models.CardGroup{ID: uuid.New(), ColumnID: col.ID, Title: "Tooling"}
+	db.Create(&tooling)
+	ci := models.CardGroup{ID: uuid.New(), ColumnID: col.ID, ParentID: &tooling.ID, Title: "CI"}
+	db.Create(&ci)
+	build := models.Card{ID: uuid.New(), ColumnID: col.ID, Content: "Builds are slow", GroupID: &tooling.ID}
+	flaky := models.Card{ID: uuid.New(), ColumnID: col.ID, Content: "Flaky tests", GroupID: &tooling.ID}
+	runners := models.Card{ID: uuid.New(), ColumnID: col.ID, Content: "Not enough runners", GroupID: &ci.ID}
+	lunch := models.Card{ID: uuid.New(), ColumnID: col.ID, Content: "Team lunch"}
+	demo := models.Card{ID: uuid.New(), ColumnID: col.ID, Content: "Demo day"}
+	for _, card := range []*models.Card{&build, &flaky, &runners, &lunch, &demo} {
+		db.Create(card)
+	}
+
+	vote := func(user models.User, card models.Card) int {
+		body, _ := json.Marshal(map[string]string{"user_name": user.DisplayName, "vote_type": "like"})
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, asUser(httptest.NewRequest("POST", "/cards/"+card.ID.String()+"/votes", bytes.NewBuffer(body)), user))
+		return w.Code
+	}
+	groupVotes := func(card models.Card) (int, []models.GroupVoteTotal) {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest("GET", "/cards/"+card.ID.String()+"/votes", nil))
+		var resp struct {
+			Likes  int                     `json:"likes"`
+			Groups []models.GroupVoteTotal `json:"groups"`
+		}
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		return resp.Likes, resp.Groups
+	}
+
+	// Votes anywhere in a group are one ballot against the limit
+	assert.Equal(t, http.StatusCreated, vote(user1, build))
+	assert.Equal(t, http.StatusCreated, vote(user1, flaky))
+	assert.Equal(t, http.StatusCreated, vote(user1, runners))
+	assert.Equal(t, http.StatusCreated, vote(user1, lunch))
+	assert.Equal(t, http.StatusForbidden, vote(user1, demo))
+	assert.Equal(t, http.StatusCreated, vote(user2, runners))
+
+	// Groups count each voter once, whichever of their cards was voted on
+	likes, groups := groupVotes(runners)
+	assert.Equal(t, 2, likes)
+	assert.Equal(t, []models.GroupVoteTotal{
+		{GroupID: ci.ID, Likes: 2},
+		{GroupID: tooling.ID, Likes: 2},
+	}, groups)
+
+	// Groups stay as they are while voting
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, asUser(httptest.NewRequest("POST", "/cards/"+flaky.ID.String()+"/unmerge", nil), user1))
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	// A card taken out of its group takes its votes along, and they count
+	// against the limit on their own again
+	db.Model(&board).Update("phase", PhaseGrouping)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, asUser(httptest.NewRequest("POST", "/cards/"+flaky.ID.String()+"/unmerge", nil), user1))
+	assert.Equal(t, http.StatusOK, w.Code)
+	db.Model(&board).Update("phase", PhaseVoting)
+	likes, groups = groupVotes(flaky)
+	assert.Equal(t, 1, likes)
+	assert.Empty(t, groups)
+	_, groups = groupVotes(build)
+	assert.Equal(t, []models.GroupVoteTotal{{GroupID: tooling.ID, Likes: 2}}, groups)
+	assert.Equal(t, http.StatusOK, vote(user1, flaky)) // Withdrawing is always allowed
+	assert.Equal(t, http.StatusForbidden, vote(user1, flaky))
+}
+
+func TestDotVoting(t *testing.T) {
+	db, r := setupVoteReactionTest(t)
+	r.GET("/boards/:id/votes/remaining", GetRemainingVotes)
+	r.PUT("/columns/:id/vote-limit", UpdateColumnVoteLimit)
+	owner := createTestUser(db, "owner", "user")
+	voter := createTestUser(db, "voter", "user")
+	board := models.Board{ID: uuid.New(), Name: "Dots", Owner: "owner", Phase: "voting", VotingMode: VotingModeDot, VoteLimit: 5}
+	db.Create(&board)
+	db.Create(&models.BoardMember{BoardID: board.ID, Username: "voter"})
+	wrong := models.Column{ID: uuid.New(), BoardID: board.ID, Name: "Went wrong"}
+	ideas := models.Column{ID: uuid.New(), BoardID: board.ID, Name: "Ideas", Position: 1}
+	db.Create(&wrong)
+	db.Create(&ideas)
+	deploys := models.Card{ID: uuid.New(), ColumnID: wrong.ID, Content: "Slow deploys"}
+	meetings := models.Card{ID: uuid.New(), ColumnID: wrong.ID, Content: "Long meetings"}
+	pairing := models.Card{ID: uuid.New(), ColumnID: ideas.ID, Content: "More pairing"}
+	for _, card := range []*models.Card{&deploys, &meetings, &pairing} {
+		db.Create(card)
+	}
+
+	send := func(user models.User, method, path string, body interface{}) *httptest.ResponseRecorder {
+		var payload []byte
+		if body != nil {
+			payload, _ = json.Marshal(body)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, asUser(httptest.NewRequest(method, path, bytes.NewBuffer(payload)), user))
+		return w
+	}
+	dot := func(card models.Card) int {
+		return send(voter, "POST", "/cards/"+card.ID.String()+"/votes", map[string]string{"user_name": "voter", "vote_type": "like"}).Code
+	}
+	remaining := func() models.RemainingVotes {
+		var resp models.RemainingVotes
+		json.Unmarshal(send(voter, "GET", "/boards/"+board.ID.String()+"/votes/remaining", nil).Body.Bytes(), &resp)
+		return resp
+	}
+
+	assert.Equal(t, http.StatusForbidden, send(voter, "PUT", "/columns/"+wrong.ID.String()+"/vote-limit", map[string]int{"vote_limit": 3}).Code)
+	assert.Equal(t, http.StatusOK, send(owner, "PUT", "/columns/"+wrong.ID.String()+"/vote-limit", map[string]int{"vote_limit": 3}).Code)
+
+	// Dots stack on a card, within the column's budget
+	assert.Equal(t, http.StatusCreated, dot(deploys))
+	assert.Equal(t, http.StatusCreated, dot(deploys))
+	assert.Equal(t, http.StatusCreated, dot(meetings))
+	assert.Equal(t, http.StatusForbidden, dot(meetings))
+	var stacked models.Vote
+	db.Where("card_id = ? AND user_name = ?", deploys.ID, "voter").First(&stacked)
+	assert.Equal(t, 2, stacked.Weight)
+	assert.Equal(t, http.StatusBadRequest, send(voter, "POST", "/cards/"+pairing.ID.String()+"/votes", map[string]string{"user_name": "voter", "vote_type": "dislike"}).Code)
+
+	// ...and within the board's
+	assert.Equal(t, http.StatusCreated, dot(pairing))
+	assert.Equal(t, http.StatusCreated, dot(pairing))
+	assert.Equal(t, http.StatusForbidden, dot(pairing))
+	budgets := remaining()
+	assert.Equal(t, VotingModeDot, budgets.VotingMode)
+	assert.Equal(t, models.VoteBudget{Limit: 5, Used: 5, Remaining: 0}, budgets.Board)
+	assert.Equal(t, []models.ColumnVoteBudget{{ColumnID: wrong.ID, VoteBudget: models.VoteBudget{Limit: 3, Used: 3, Remaining: 0}}}, budgets.Columns)
+
+	// Deleting takes one dot off at a time
+	w := send(voter, "DELETE", "/votes/"+stacked.ID.String(), nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	db.First(&stacked, stacked.ID)
+	assert.Equal(t, 1, stacked.Weight)
+	assert.Equal(t, 1, remaining().Board.Remaining)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/cards/"+deploys.ID.String()+"/votes", nil))
+	var counts map[string]interface{}
+	json.Unmarshal(w.Body.Bytes(), &counts)
+	assert.Equal(t, float64(1), counts["likes"])
+	assert.Equal(t, http.StatusCreated, dot(meetings))
+}
+
+func TestConcurrentVotes(t *testing.T) {
+	setupVoteReactionTest(t)
+	// Parallel writers need a database file; shared memory databases fail
+	// with "database is locked" instead of waiting
+	db, err := gorm.Open(sqlite.Open(database.SQLiteDSN(filepath.Join(t.TempDir(), "votes.db"))), &gorm.Config{})
+	assert.NoError(t, err)
+	assert.NoError(t, database.PerformMigrations(db))
+	database.DB = db
+	r := gin.New()
+	r.Use(simulateAuth())
+	r.POST("/cards/:id/votes", AddVote)
+
+	voter := createTestUser(db, "voter", "user")
+	likes := models.Board{ID: uuid.New(), Name: "Likes", Owner: "owner", Phase: "voting", VoteLimit: 3}
+	dots := models.Board{ID: uuid.New(), Name: "Dots", Owner: "owner", Phase: "voting", VotingMode: VotingModeDot, VoteLimit: 4}
+	var likeCards []models.Card
+	var dotCard models.Card
+	for _, board := range []models.Board{likes, dots} {
+		db.Create(&board)
+		db.Create(&models.BoardMember{BoardID: board.ID, Username: "voter"})
+		column := models.Column{ID: uuid.New(), BoardID: board.ID, Name: "Ideas"}
+		db.Create(&column)
+		if board.ID == dots.ID {
+			dotCard = models.Card{ID: uuid.New(), ColumnID: column.ID, Content: "Dotted"}
+			db.Create(&dotCard)
+			continue
+		}
+		for i := 0; i < 8; i++ {
+			card := models.Card{ID: uuid.New(), ColumnID: column.ID, Content: fmt.Sprintf("Idea %d", i)}
+			db.Create(&card)
+			likeCards = append(likeCards, card)
+		}
+	}
+
+	// vote sends the votes at once and counts the responses by status
+	vote := func(cards []models.Card) map[int]int {
+		var mu sync.Mutex
+		var wg sync.WaitGroup
+		codes := make(map[int]int)
+		start := make(chan struct{})
+		for _, card := range cards {
+			wg.Add(1)
+			go func(card models.Card) {
+				defer wg.Done()
+				<-start
+				payload, _ := json.Marshal(map[string]string{"user_name": "voter", "vote_type": "like"})
+				w := httptest.NewRecorder()
+				r.ServeHTTP(w, asUser(httptest.NewRequest("POST", "/cards/"+card.ID.String()+"/votes", bytes.NewBuffer(payload)), voter))
+				mu.Lock()
+				codes[w.Code]++
+				mu.Unlock()
+			}(card)
+		}
+		close(start)
+		wg.Wait()
+		return codes
+	}
+
+	// One like on each of eight cards, with three to spend
+	assert.Equal(t, map[int]int{http.StatusCreated: 3, http.StatusForbidden: 5}, vote(likeCards))
+	var liked int64
+	db.Model(&models.Vote{}).Where("user_name = ?", "voter").Count(&liked)
+	assert.Equal(t, int64(3), liked)
+
+	// Eight dots on one card, with four to spend
+	burst := make([]models.Card, 8)
+	for i := range burst {
+		burst[i] = dotCard
+	}
+	assert.Equal(t, map[int]int{http.StatusCreated: 4, http.StatusForbidden: 4}, vote(burst))
+	var stacked models.Vote
+	db.Where("card_id = ? AND user_name = ?", dotCard.ID, "voter").First(&stacked)
+	assert.Equal(t, 4, stacked.Weight)
+}
+
+func TestBallotVoting(t *testing.T) {
+	db, r := setupVoteReactionTest(t)
+	r.PUT("/boards/:id/ballot", SubmitBallot)
+	r.GET("/boards/:id/votes/results", GetVoteResults)
+	r.GET("/boards/:id/votes/remaining", GetRemainingVotes)
+	ana := createTestUser(db, "ana", "user")
+	bruno := createTestUser(db, "bruno", "user")
+	board := models.Board{ID: uuid.New(), Name: "Ranked", Owner: "ana", Phase: "voting", VotingMode: VotingModeRanked, VoteLimit: 2}
+	db.Create(&board)
+	db.Create(&models.BoardMember{BoardID: board.ID, Username: "bruno"})
+	column := models.Column{ID: uuid.New(), BoardID: board.ID, Name: "Ideas"}
+	db.Create(&column)
+	group := models.CardGroup{ID: uuid.New(), ColumnID: column.ID, Title: "Testing"}
+	db.Create(&group)
+	flaky := models.Card{ID: uuid.New(), ColumnID: column.ID, Content: "Flaky tests", GroupID: &group.ID, Position: 0}
+	slow := models.Card{ID: uuid.New(), ColumnID: column.ID, Content: "Slow tests", GroupID: &group.ID, Position: 1}
+	docs := models.Card{ID: uuid.New(), ColumnID: column.ID, Content: "Write docs", Position: 2}
+	for _, card := range []*models.Card{&flaky, &slow, &docs} {
+		db.Create(card)
+	}
+
+	send := func(user models.User, method, path string, body interface{}) *httptest.ResponseRecorder {
+		var payload []byte
+		if body != nil {
+			payload, _ = json.Marshal(body)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, asUser(httptest.NewRequest(method, path, bytes.NewBuffer(payload)), user))
+		return w
+	}
+	ballot := func(user models.User, weights ...interface{}) int {
+		var votes []gin.H
+		for i := 0; i < len(weights); i += 2 {
+			votes = append(votes, gin.H{"card_id": weights[i].(models.Card).ID, "weight": weights[i+1]})
+		}
+		return send(user, "PUT", "/boards/"+board.ID.String()+"/ballot", gin.H{"user_name": user.DisplayName, "votes": votes}).Code
+	}
+	results := func(by string) []models.VoteResult {
+		var resp models.VoteResults
+		json.Unmarshal(send(ana, "GET", "/boards/"+board.ID.String()+"/votes/results?by="+by, nil).Body.Bytes(), &resp)
+		return resp.Results
+	}
+	order := func(results []models.VoteResult) []string {
+		var titles []string
+		for _, result := range results {
+			titles = append(titles, result.Title)
+		}
+		return titles
+	}
+
+	// Ranked boards take whole ballots, within the vote limit
+	assert.Equal(t, http.StatusBadRequest, send(ana, "POST", "/cards/"+docs.ID.String()+"/votes", map[string]string{"user_name": "ana", "vote_type": "like"}).Code)
+	assert.Equal(t, http.StatusForbidden, ballot(ana, docs, 1, flaky, 2, slow, 3))
+	assert.Equal(t, http.StatusBadRequest, ballot(ana, docs, 1, flaky, 3))
+	assert.Equal(t, http.StatusForbidden, send(bruno, "PUT", "/boards/"+board.ID.String()+"/ballot", gin.H{"user_name": "ana"}).Code)
+	assert.Equal(t, http.StatusOK, ballot(ana, slow, 1, docs, 2))
+	assert.Equal(t, http.StatusOK, ballot(bruno, docs, 1, flaky, 2))
+	// Slow tests and docs tie, and the card listed first wins
+	assert.Equal(t, []string{"Slow tests", "Write docs", "Flaky tests"}, order(results("card")))
+
+	// A new ballot replaces the old one
+	assert.Equal(t, http.StatusOK, ballot(ana, slow, 1))
+	var count int64
+	db.Model(&models.Vote{}).Where("user_name = ?", "ana").Count(&count)
+	assert.Equal(t, int64(1), count)
+
+	// By group, a voter's best card ranks the group, and bruno's ballot moves
+	// on to it once docs are eliminated
+	byGroup := results("group")
+	assert.Equal(t, []string{"Testing", "Write docs"}, order(byGroup))
+	assert.Equal(t, models.VoteResult{Rank: 1, Kind: "group", ID: group.ID, ColumnID: column.ID, Title: "Testing", Score: 2, Voters: 2}, byGroup[0])
+	assert.Equal(t, http.StatusBadRequest, send(ana, "GET", "/boards/"+board.ID.String()+"/votes/results?by=column", nil).Code)
+
+	// Points boards split a budget of 100 points
+	db.Where("1 = 1").Delete(&models.Vote{})
+	db.Model(&board).Update("voting_mode", VotingModePoints)
+	assert.Equal(t, http.StatusForbidden, ballot(ana, docs, 60, flaky, 50))
+	assert.Equal(t, http.StatusOK, ballot(ana, docs, 60, flaky, 40))
+	assert.Equal(t, http.StatusOK, ballot(bruno, slow, 70, flaky, 30))
+	assert.Equal(t, []string{"Testing", "Write docs"}, order(results("group")))
+	assert.Equal(t, 140, results("group")[0].Score)
+	var remaining models.RemainingVotes
+	json.Unmarshal(send(bruno, "GET", "/boards/"+board.ID.String()+"/votes/remaining", nil).Body.Bytes(), &remaining)
+	assert.Equal(t, models.VoteBudget{Limit: 100, Used: 100, Remaining: 0}, remaining.Board)
+
+	// Results stay hidden while blind voting is open
+	db.Model(&board).Update("blind_voting", true)
+	assert.Equal(t, http.StatusForbidden, send(ana, "GET", "/boards/"+board.ID.String()+"/votes/results", nil).Code)
+
+	// Like boards are voted card by card
+	db.Model(&board).Updates(map[string]interface{}{"voting_mode": VotingModeLike, "blind_voting": false})
+	assert.Equal(t, http.StatusBadRequest, ballot(ana, docs, 1))
+}
+
+func TestCloseVoting(t *testing.T) {
+	db, r := setupVoteReactionTest(t)
+	r.POST("/boards/:id/votes/close", CloseVoting)
+	r.GET("/boards/:id/votes/results", GetVoteResults)
+	r.PUT("/boards/:id/phase", UpdateBoardPhase)
+	ana := createTestUser(db, "ana", "user")
+	bruno := createTestUser(db, "bruno", "user")
+	board := models.Board{ID: uuid.New(), Name: "Closing", Owner: "ana", Phase: "voting", BlindVoting: true}
+	db.Create(&board)
+	db.Create(&models.BoardMember{BoardID: board.ID, Username: "bruno"})
+	column := models.Column{ID: uuid.New(), BoardID: board.ID, Name: "Ideas"}
+	db.Create(&column)
+	group := models.CardGroup{ID: uuid.New(), ColumnID: column.ID, Title: "Testing"}
+	db.Create(&group)
+	flaky := models.Card{ID: uuid.New(), ColumnID: column.ID, Content: "Flaky tests", GroupID: &group.ID}
+	docs := models.Card{ID: uuid.New(), ColumnID: column.ID, Content: "Write docs", Position: 1}
+	db.Create(&flaky)
+	db.Create(&docs)
+
+	send := func(user models.User, method, path string, body interface{}) *httptest.ResponseRecorder {
+		var payload []byte
+		if body != nil {
+			payload, _ = json.Marshal(body)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, asUser(httptest.NewRequest(method, path, bytes.NewBuffer(payload)), user))
+		return w
+	}
+	vote := func(user models.User, card models.Card) int {
+		return send(user, "POST", "/cards/"+card.ID.String()+"/votes", map[string]string{"user_name": user.DisplayName, "vote_type": "like"}).Code
+	}
+	closeVoting := func(user models.User) *httptest.ResponseRecorder {
+		return send(user, "POST", "/boards/"+board.ID.String()+"/votes/close", nil)
+	}
+
+	assert.Equal(t, http.StatusCreated, vote(ana, docs))
+	assert.Equal(t, http.StatusCreated, vote(bruno, docs))
+	assert.Equal(t, http.StatusCreated, vote(bruno, flaky))
+	assert.Equal(t, http.StatusForbidden, send(ana, "GET", "/boards/"+board.ID.String()+"/votes/results", nil).Code)
+
+	// Only managers close voting, which takes a snapshot by group and moves on to discussion
+	assert.Equal(t, http.StatusForbidden, closeVoting(bruno).Code)
+	w := closeVoting(ana)
+	assert.Equal(t, http.StatusOK, w.Code)
+	var snapshot models.VoteResults
+	json.Unmarshal(w.Body.Bytes(), &snapshot)
+	assert.Equal(t, ResultsByGroup, snapshot.By)
+	assert.Equal(t, "ana", snapshot.ClosedBy)
+	assert.NotNil(t, snapshot.ClosedAt)
+	assert.Equal(t, []models.VoteResult{
+		{Rank: 1, Kind: "card", ID: docs.ID, ColumnID: column.ID, Title: "Write docs", Score: 2, Voters: 2},
+		{Rank: 2, Kind: "group", ID: group.ID, ColumnID: column.ID, Title: "Testing", Score: 1, Voters: 1},
+	}, snapshot.Results)
+
+	var closed models.Board
+	db.First(&closed, board.ID)
+	assert.Equal(t, PhaseDiscuss, closed.Phase)
+	assert.NotNil(t, closed.VotingClosedAt)
+	assert.Equal(t, snapshot.Results, closed.Results.Results)
+	assert.Equal(t, http.StatusConflict, closeVoting(ana).Code)
+
+	// Votes stay frozen even if the phase is set back behind the board's back
+	db.Model(&board).Update("phase", PhaseVoting)
+	assert.Equal(t, http.StatusConflict, vote(ana, flaky))
+	var cast models.Vote
+	db.Where("user_name = ? AND card_id = ?", "ana", docs.ID).First(&cast)
+	assert.Equal(t, http.StatusConflict, send(ana, "DELETE", "/votes/"+cast.ID.String(), nil).Code)
+
+	// Results keep the snapshot, even when cards change afterwards
+	db.Model(&docs).Update("content", "Write more docs")
+	var results models.VoteResults
+	json.Unmarshal(send(bruno, "GET", "/boards/"+board.ID.String()+"/votes/results", nil).Body.Bytes(), &results)
+	assert.Equal(t, snapshot.Results, results.Results)
+
+	// Going back to voting opens it again
+	db.Model(&board).Update("phase", PhaseDiscuss)
+	assert.Equal(t, http.StatusOK, send(ana, "PUT", "/boards/"+board.ID.String()+"/phase", map[string]string{"phase": PhaseVoting}).Code)
+	assert.Equal(t, http.StatusCreated, vote(ana, flaky))
+
+	// Moving on to discussion closes voting the same way
+	assert.Equal(t, http.StatusOK, send(ana, "PUT", "/boards/"+board.ID.String()+"/phase", map[string]string{"phase": PhaseDiscuss}).Code)
+	db.First(&closed, board.ID)
+	assert.NotNil(t, closed.VotingClosedAt)
+	if assert.NotNil(t, closed.Results) && assert.Len(t, closed.Results.Results, 2) {
+		assert.Equal(t, group.ID, closed.Results.Results[0].ID)
+		assert.Equal(t, 2, closed.Results.Results[0].Score)
+	}
+
+	// Voting on cards private writing still hides cannot close
+	hidden := models.Board{ID: uuid.New(), Name: "Hidden", Owner: "ana", Phase: "voting", PrivateWriting: true}
+	db.Create(&hidden)
+	assert.Equal(t, http.StatusConflict, send(ana, "POST", "/boards/"+hidden.ID.String()+"/votes/close", nil).Code)
+	assert.Equal(t, http.StatusConflict, send(ana, "PUT", "/boards/"+hidden.ID.String()+"/phase", map[string]string{"phase": PhaseDiscuss}).Code)
+	db.First(&hidden, hidden.ID)
+	assert.Equal(t, PhaseVoting, hidden.Phase)
+	assert.Nil(t, hidden.VotingClosedAt)
+}
@@ -0,0 +1,181 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/bento-lab-ops/bentro/internal/database"
+	"github.com/bento-lab-ops/bentro/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// What vote results are ordered by
+const (
+	ResultsByCard  = "card"  // Every card on its own
+	ResultsByGroup = "group" // The cards of a top-level group together, the others on their own
+)
+
+// voteResults counts the votes of a board with its voting method. Candidates
+// are listed column by column, groups before ungrouped cards, which is how
+// ties are broken.
+func voteResults(db *gorm.DB, board *models.Board, by, viewer string) (models.VoteResults, error) {
+	results := models.VoteResults{VotingMode: board.VotingMode, By: by, Results: []models.VoteResult{}}
+	if results.VotingMode == "" {
+		results.VotingMode = VotingModeLike
+	}
+
+	var columns []models.Column
+	if err := db.Where("board_id = ?", board.ID).Order("position asc").
+		Preload("Cards", func(db *gorm.DB) *gorm.DB { return db.Order("position asc") }).
+		Preload("Cards.Votes").
+		Preload("Groups", func(db *gorm.DB) *gorm.DB { return db.Order("position asc") }).
+		Find(&columns).Error; err != nil {
+		return results, err
+	}
+
+	var candidates []uuid.UUID
+	var votes []candidateVote
+	entries := make(map[uuid.UUID]models.VoteResult)
+	for _, column := range columns {
+		index := indexGroups(column.Groups)
+		if by == ResultsByGroup {
+			for _, group := range column.Groups {
+				if group.ParentID == nil {
+					candidates = append(candidates, group.ID)
+					entries[group.ID] = models.VoteResult{Kind: "group", ID: group.ID, ColumnID: column.ID, Title: group.Title}
+				}
+			}
+		}
+		for _, card := range column.Cards {
+			candidate := card.ID
+			if chain := groupChain(index, card.GroupID); by == ResultsByGroup && len(chain) > 0 {
+				candidate = chain[len(chain)-1]
+			} else {
+				candidates = append(candidates, card.ID)
+				entries[card.ID] = models.VoteResult{Kind: "card", ID: card.ID, ColumnID: column.ID, Title: presentCard(board, card, viewer).Content}
+			}
+			for _, vote := range card.Votes {
+				votes = append(votes, candidateVote{Candidate: candidate, Vote: vote})
+			}
+		}
+	}
+
+	for i, score := range votingMethodOf(board).Tally(candidates, votes) {
+		entry := entries[score.ID]
+		entry.Rank = i + 1
+		entry.Score = score.Score
+		entry.Voters = score.Voters
+		results.Results = append(results.Results, entry)
+	}
+	return results, nil
+}
+
+// GetVoteResults orders the cards of a board, or its card groups, by their votes
+func GetVoteResults(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid board ID"})
+		return
+	}
+
+	by := c.DefaultQuery("by", ResultsByCard)
+	if by != ResultsByCard && by != ResultsByGroup {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Results are by card or by group"})
+		return
+	}
+
+	var board models.Board
+	if err := database.DB.First(&board, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Board not found"})
+		return
+	}
+	access, ok := authorizeBoard(c, &board, BoardActionContribute)
+	if !ok {
+		return
+	}
+	// Once voting is closed, the snapshot taken then is the result
+	if board.VotingClosedAt != nil && board.Results != nil && (c.Query("by") == "" || by == board.Results.By) {
+		c.JSON(http.StatusOK, board.Results)
+		return
+	}
+	// Blind votes stay hidden while voting is open
+	if board.BlindVoting && board.Phase == PhaseVoting && board.VotingClosedAt == nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Results are hidden until voting ends"})
+		return
+	}
+
+	results, err := voteResults(database.DB, &board, by, access.Username)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to count votes"})
+		return
+	}
+
+	c.JSON(http.StatusOK, results)
+}
+
+// closeVoting freezes the votes of a board and stores a snapshot of the results
+// on it, as part of moving the board on from voting. The snapshot is by group,
+// so grouped cards are discussed together, and is the order the discussion
+// follows. Closing waits for the votes in flight, see lockVotingOpen. Voting
+// that is closed already keeps its snapshot, and nil is returned.
+func closeVoting(tx *gorm.DB, board *models.Board, actor string, now time.Time) (*models.VoteResults, error) {
+	result := tx.Model(&models.Board{}).
+		Where("id = ? AND voting_closed_at IS NULL", board.ID).
+		Update("voting_closed_at", now)
+	if result.Error != nil || result.RowsAffected == 0 {
+		return nil, result.Error
+	}
+	board.VotingClosedAt = &now
+
+	results, err := voteResults(tx, board, ResultsByGroup, "")
+	if err != nil {
+		return nil, err
+	}
+	results.ClosedAt = &now
+	results.ClosedBy = actor
+	board.Results = &results
+	if err := tx.Model(board).Select("results").Updates(board).Error; err != nil {
+		return nil, err
+	}
+	return &results, nil
+}
+
+// CloseVoting moves a board from voting on to discussion, which freezes its
+// votes and stores the results snapshot (board managers only)
+func CloseVoting(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid board ID"})
+		return
+	}
+
+	var board models.Board
+	if err := database.DB.First(&board, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Board not found"})
+		return
+	}
+	access, ok := authorizeBoard(c, &board, BoardActionManage)
+	if !ok {
+		return
+	}
+	if !requirePhase(c, &board, PhaseActionVote, "Voting is not open (Phase: "+boardPhase(board.Phase)+")") {
+		return
+	}
+
+	// Only the first of concurrent closes moves the board on
+	err = changeBoardPhase(&board, PhaseDiscuss, access.Username)
+	if errors.Is(err, ErrCardsHidden) || errors.Is(err, ErrPhaseConflict) {
+		c.JSON(http.StatusConflict, gin.H{"error": phaseErrorMessage(err)})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to close voting"})
+		return
+	}
+
+	c.JSON(http.StatusOK, board.Results)
+}
@@ -0,0 +1,306 @@
+package handlers
+
+import (
+	"errors"
+	"sort"
+
+	"github.com/bento-lab-ops/bentro/internal/models"
+
+	"github.com/google/uuid"
+)
+
+// PointsBudget is what each voter allocates on a points voting board
+const PointsBudget = 100
+
+var (
+	// ErrVotesCardByCard is returned for ballots on boards voted card by card
+	ErrVotesCardByCard = errors.New("board is voted card by card")
+	// ErrBallotPoints is returned for points ballots giving a card no points
+	ErrBallotPoints = errors.New("points must be positive")
+	// ErrBallotRanks is returned for ranked ballots whose ranks skip or repeat
+	ErrBallotRanks = errors.New("ranks must run from 1 without gaps")
+	// ErrBallotRepeats is returned for ballots voting twice on a card
+	ErrBallotRepeats = errors.New("ballot votes on a card twice")
+)
+
+// VotingMethod validates and counts the votes of one voting mode. Ballot
+// methods take a voter's votes as a whole through SubmitBallot, the others
+// take them card by card through AddVote. Either way every vote is a row on a
+// card whose weight means what the method says: dots, a rank or points.
+type VotingMethod interface {
+	// Ballots reports whether voters hand in whole ballots
+	Ballots() bool
+	// Weighted reports whether vote weights add up, instead of each voter
+	// counting once
+	Weighted() bool
+	// ValidateBallot checks the votes a voter hands in on a board
+	ValidateBallot(board *models.Board, votes []models.Vote) error
+	// Tally orders candidates from the votes cast on them, best first. Ties
+	// keep the candidates' order.
+	Tally(candidates []uuid.UUID, votes []candidateVote) []candidateScore
+}
+
+// candidateVote is a vote counted for a candidate: its card, or the card's
+// top-level group when results are by group
+type candidateVote struct {
+	Candidate uuid.UUID
+	models.Vote
+}
+
+// candidateScore is how a candidate did. Voters counts the people who voted
+// on it at all.
+type candidateScore struct {
+	ID     uuid.UUID
+	Score  int
+	Voters int
+}
+
+var votingMethods = map[string]VotingMethod{
+	VotingModeLike:   likeVoting{},
+	VotingModeDot:    dotVoting{},
+	VotingModeRanked: rankedVoting{},
+	VotingModePoints: pointsVoting{},
+}
+
+// votingMethodOf returns the voting method of a board. Boards without a mode use likes.
+func votingMethodOf(board *models.Board) VotingMethod {
+	if method, ok := votingMethods[board.VotingMode]; ok {
+		return method
+	}
+	return votingMethods[VotingModeLike]
+}
+
+// likeVoting scores each candidate by its likes less its dislikes, counting
+// each voter once
+type likeVoting struct{}
+
+func (likeVoting) Ballots() bool  { return false }
+func (likeVoting) Weighted() bool { return false }
+
+func (likeVoting) ValidateBallot(*models.Board, []models.Vote) error { return ErrVotesCardByCard }
+
+func (likeVoting) Tally(candidates []uuid.UUID, votes []candidateVote) []candidateScore {
+	type voter struct {
+		candidate uuid.UUID
+		userName  string
+	}
+	likes := make(map[voter]bool)
+	dislikes := make(map[voter]bool)
+	for _, vote := range votes {
+		key := voter{vote.Candidate, vote.UserName}
+		if vote.VoteType == "like" {
+			likes[key] = true
+		} else {
+			dislikes[key] = true
+		}
+	}
+	scores := make(map[uuid.UUID]candidateScore, len(candidates))
+	for key := range likes {
+		score := scores[key.candidate]
+		score.Score++
+		score.Voters++
+		scores[key.candidate] = score
+	}
+	for key := range dislikes {
+		score := scores[key.candidate]
+		score.Score--
+		if !likes[key] {
+			score.Voters++
+		}
+		scores[key.candidate] = score
+	}
+	return rankByScore(candidates, scores)
+}
+
+// dotVoting scores each candidate by the dots on it
+type dotVoting struct{}
+
+func (dotVoting) Ballots() bool  { return false }
+func (dotVoting) Weighted() bool { return true }
+
+func (dotVoting) ValidateBallot(*models.Board, []models.Vote) error { return ErrVotesCardByCard }
+
+func (dotVoting) Tally(candidates []uuid.UUID, votes []candidateVote) []candidateScore {
+	return rankByScore(candidates, sumWeights(votes))
+}
+
+// pointsVoting has each voter split PointsBudget points among the cards, and
+// scores each candidate by its points
+type pointsVoting struct{}
+
+func (pointsVoting) Ballots() bool  { return true }
+func (pointsVoting) Weighted() bool { return true }
+
+func (pointsVoting) ValidateBallot(board *models.Board, votes []models.Vote) error {
+	if err := distinctCards(votes); err != nil {
+		return err
+	}
+	total := 0
+	for _, vote := range votes {
+		if vote.Weight < 1 {
+			return ErrBallotPoints
+		}
+		total += vote.Weight
+	}
+	if total > PointsBudget {
+		return ErrVoteLimitReached
+	}
+	return nil
+}
+
+func (pointsVoting) Tally(candidates []uuid.UUID, votes []candidateVote) []candidateScore {
+	return rankByScore(candidates, sumWeights(votes))
+}
+
+// rankedVoting has each voter rank cards, 1 being their first choice. The
+// winner is found by instant runoff: while no candidate holds a majority of
+// the first choices, the weakest is eliminated and its ballots move on to
+// their next choice. Ordering all candidates repeats the runoff without the
+// winners so far. A score is the first choices of a candidate in the round it
+// won.
+type rankedVoting struct{}
+
+func (rankedVoting) Ballots() bool  { return true }
+func (rankedVoting) Weighted() bool { return false }
+
+func (rankedVoting) ValidateBallot(board *models.Board, votes []models.Vote) error {
+	if err := distinctCards(votes); err != nil {
+		return err
+	}
+	if board.VoteLimit > 0 && len(votes) > board.VoteLimit {
+		return ErrVoteLimitReached
+	}
+	ranks := make(map[int]bool, len(votes))
+	for _, vote := range votes {
+		if vote.Weight < 1 || vote.Weight > len(votes) || ranks[vote.Weight] {
+			return ErrBallotRanks
+		}
+		ranks[vote.Weight] = true
+	}
+	return nil
+}
+
+func (rankedVoting) Tally(candidates []uuid.UUID, votes []candidateVote) []candidateScore {
+	// Each voter's preferences, best first. A voter's first card of a group
+	// ranks the group.
+	votes = append([]candidateVote(nil), votes...)
+	sort.SliceStable(votes, func(i, j int) bool { return votes[i].Weight < votes[j].Weight })
+	preferences := make(map[string][]uuid.UUID)
+	ranked := make(map[string]map[uuid.UUID]bool)
+	var voters []string
+	voterCounts := make(map[uuid.UUID]int)
+	for _, vote := range votes {
+		if ranked[vote.UserName] == nil {
+			ranked[vote.UserName] = make(map[uuid.UUID]bool)
+			voters = append(voters, vote.UserName)
+		}
+		if ranked[vote.UserName][vote.Candidate] {
+			continue
+		}
+		ranked[vote.UserName][vote.Candidate] = true
+		preferences[vote.UserName] = append(preferences[vote.UserName], vote.Candidate)
+		voterCounts[vote.Candidate]++
+	}
+
+	results := make([]candidateScore, 0, len(candidates))
+	remaining := append([]uuid.UUID(nil), candidates...)
+	for len(remaining) > 0 {
+		winner, score := instantRunoff(remaining, voters, preferences)
+		results = append(results, candidateScore{ID: winner, Score: score, Voters: voterCounts[winner]})
+		remaining = without(remaining, winner)
+	}
+	return results
+}
+
+// instantRunoff finds the candidate a majority prefers, and its first choices
+// in the final round. Ties eliminate the candidate listed last.
+func instantRunoff(candidates []uuid.UUID, voters []string, preferences map[string][]uuid.UUID) (uuid.UUID, int) {
+	active := append([]uuid.UUID(nil), candidates...)
+	for {
+		inRace := make(map[uuid.UUID]bool, len(active))
+		for _, id := range active {
+			inRace[id] = true
+		}
+		firsts := make(map[uuid.UUID]int, len(active))
+		total := 0
+		for _, voter := range voters {
+			for _, id := range preferences[voter] {
+				if inRace[id] {
+					firsts[id]++
+					total++
+					break
+				}
+			}
+		}
+
+		leader, weakest := active[0], active[0]
+		for _, id := range active {
+			if firsts[id] > firsts[leader] {
+				leader = id
+			}
+			if firsts[id] <= firsts[weakest] {
+				weakest = id
+			}
+		}
+		if len(active) == 1 || firsts[leader]*2 > total || total == 0 {
+			return leader, firsts[leader]
+		}
+		active = without(active, weakest)
+	}
+}
+
+// without returns the candidates but one
+func without(candidates []uuid.UUID, id uuid.UUID) []uuid.UUID {
+	rest := make([]uuid.UUID, 0, len(candidates))
+	for _, candidate := range candidates {
+		if candidate != id {
+			rest = append(rest, candidate)
+		}
+	}
+	return rest
+}
+
+// sumWeights adds up the weights of the votes on each candidate
+func sumWeights(votes []candidateVote) map[uuid.UUID]candidateScore {
+	type voter struct {
+		candidate uuid.UUID
+		userName  string
+	}
+	scores := make(map[uuid.UUID]candidateScore)
+	seen := make(map[voter]bool)
+	for _, vote := range votes {
+		score := scores[vote.Candidate]
+		score.Score += vote.Weight
+		// Voters are counted once, however many of a group's cards they voted on
+		voter := voter{vote.Candidate, vote.UserName}
+		if !seen[voter] {
+			seen[voter] = true
+			score.Voters++
+		}
+		scores[vote.Candidate] = score
+	}
+	return scores
+}
+
+// rankByScore orders candidates by score, best first
+func rankByScore(candidates []uuid.UUID, scores map[uuid.UUID]candidateScore) []candidateScore {
+	results := make([]candidateScore, len(candidates))
+	for i, id := range candidates {
+		results[i] = scores[id]
+		results[i].ID = id
+	}
+	sort.SliceStable(results, func(i, j int) bool { return results[i].Score > results[j].Score })
+	return results
+}
+
+// distinctCards rejects ballots voting twice on a card
+func distinctCards(votes []models.Vote) error {
+	seen := make(map[uuid.UUID]bool, len(votes))
+	for _, vote := range votes {
+		if seen[vote.CardID] {
+			return ErrBallotRepeats
+		}
+		seen[vote.CardID] = true
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+package handlers
+
+import (
+	"testing"
+
+	"github.com/bento-lab-ops/bentro/internal/models"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRankedVotingTally(t *testing.T) {
+	a, b, c := uuid.New(), uuid.New(), uuid.New()
+	rank := func(voter string, candidates ...uuid.UUID) []candidateVote {
+		votes := make([]candidateVote, len(candidates))
+		for i, id := range candidates {
+			votes[i] = candidateVote{Candidate: id, Vote: models.Vote{CardID: id, UserName: voter, VoteType: "like", Weight: i + 1}}
+		}
+		return votes
+	}
+	var votes []candidateVote
+	votes = append(votes, rank("ana", a)...)
+	votes = append(votes, rank("bruno", a)...)
+	votes = append(votes, rank("carla", b)...)
+	votes = append(votes, rank("davi", b)...)
+	votes = append(votes, rank("eva", c, b)...)
+
+	// A and B tie on first choices; C is eliminated and its ballot makes B win
+	results := rankedVoting{}.Tally([]uuid.UUID{a, b, c}, votes)
+	assert.Equal(t, []candidateScore{
+		{ID: b, Score: 3, Voters: 3},
+		{ID: a, Score: 2, Voters: 2},
+		{ID: c, Score: 1, Voters: 1},
+	}, results)
+
+	// Without votes candidates keep their order
+	results = rankedVoting{}.Tally([]uuid.UUID{a, b}, nil)
+	assert.Equal(t, []candidateScore{{ID: a}, {ID: b}}, results)
+}
+
+func TestVotingMethodBallots(t *testing.T) {
+	board := &models.Board{VoteLimit: 2}
+	card1, card2, card3 := uuid.New(), uuid.New(), uuid.New()
+	ballot := func(weights map[uuid.UUID]int) []models.Vote {
+		var votes []models.Vote
+		for _, id := range []uuid.UUID{card1, card2, card3} {
+			if weight, ok := weights[id]; ok {
+				votes = append(votes, models.Vote{CardID: id, Weight: weight})
+			}
+		}
+		return votes
+	}
+
+	ranked := rankedVoting{}
+	assert.NoError(t, ranked.ValidateBallot(board, ballot(map[uuid.UUID]int{card1: 2, card2: 1})))
+	assert.Error(t, ranked.ValidateBallot(board, ballot(map[uuid.UUID]int{card1: 1, card2: 3})))
+	assert.Error(t, ranked.ValidateBallot(board, ballot(map[uuid.UUID]int{card1: 1, card2: 1})))
+	assert.ErrorIs(t, ranked.ValidateBallot(board, ballot(map[uuid.UUID]int{card1: 1, card2: 2, card3: 3})), ErrVoteLimitReached)
+	assert.Error(t, ranked.ValidateBallot(board, []models.Vote{{CardID: card1, Weight: 1}, {CardID: card1, Weight: 2}}))
+
+	points := pointsVoting{}
+	assert.NoError(t, points.ValidateBallot(board, ballot(map[uuid.UUID]int{card1: 60, card2: 30, card3: 10})))
+	assert.ErrorIs(t, points.ValidateBallot(board, ballot(map[uuid.UUID]int{card1: 60, card2: 50})), ErrVoteLimitReached)
+	assert.Error(t, points.ValidateBallot(board, ballot(map[uuid.UUID]int{card1: 0})))
+
+	assert.ErrorIs(t, likeVoting{}.ValidateBallot(board, nil), ErrVotesCardByCard)
+	assert.ErrorIs(t, dotVoting{}.ValidateBallot(board, nil), ErrVotesCardByCard)
+}
+
+func TestLikeVotingTally(t *testing.T) {
+	group, card := uuid.New(), uuid.New()
+	vote := func(candidate uuid.UUID, voter, voteType string) candidateVote {
+		return candidateVote{Candidate: candidate, Vote: models.Vote{UserName: voter, VoteType: voteType, Weight: 1}}
+	}
+	// Liking two cards of a group counts once
+	results := likeVoting{}.Tally([]uuid.UUID{card, group}, []candidateVote{
+		vote(group, "ana", "like"),
+		vote(group, "ana", "like"),
+		vote(group, "bruno", "like"),
+		vote(card, "ana", "like"),
+		vote(card, "bruno", "dislike"),
+		vote(card, "carla", "like"),
+	})
+	assert.Equal(t, []candidateScore{
+		{ID: group, Score: 2, Voters: 2},
+		{ID: card, Score: 1, Voters: 3},
+	}, results)
+}
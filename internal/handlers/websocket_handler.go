@@ -2,10 +2,12 @@ package handlers
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
 	"log"
 	"net/http"
 	"os"
+	"strconv"
+	"strings"
 	"sync"
 	"time"
 
@@ -31,8 +33,14 @@ const (
 	// Maximum message size allowed from peer.
 	maxMessageSize = 1024
 
-	// Redis Pub/Sub Channel
-	redisChannel = "bentro:broadcast"
+	// Maximum length of a guest display name.
+	maxGuestNameLength = 50
+
+	// Broker topic for messages addressed to every client
+	broadcastTopic = "bentro:broadcast"
+
+	// Broker topic prefix for board-scoped messages (bentro:board:<id>)
+	boardTopicPrefix = "bentro:board:"
 )
 
 var upgrader = websocket.Upgrader{
@@ -41,24 +49,37 @@ var upgrader = websocket.Upgrader{
 	},
 }
 
-// Global Redis Client
+// Global Redis Client, used for the event log and presence (nil without Redis)
 var rdb *redis.Client
 
 // Client is a middleman between the websocket connection and the hub.
 type Client struct {
 	hub *Hub
 
-	// The websocket connection.
+	// The websocket connection; nil for Server-Sent Events clients.
 	conn *websocket.Conn
 
 	// Buffered channel of outbound messages.
 	send chan []byte
 
-	// Board and User context
+	// Server-derived identity, fixed at handshake
+	identity clientIdentity
+
+	// Board and User context, owned by the hub (guarded by hub.mutex)
 	boardID  string
 	username string
 }
 
+// clientIdentity is who a WebSocket client acts as. It is derived from the
+// auth token at handshake (or the explicit guest name), never from client messages.
+type clientIdentity struct {
+	User     *models.User // nil for guests
+	Username string
+	Avatar   string
+	IsAdmin  bool
+	IsGuest  bool
+}
+
 // readPump pumps messages from the websocket connection to the hub.
 func (c *Client) readPump() {
 	defer func() {
@@ -77,77 +98,192 @@ func (c *Client) readPump() {
 			break
 		}
 
-		// Process Message Here or Forward to Hub
-		// For our app, we process join/leave/phase logic directly here or via the hub
-		// To match previous logic, we will inspect the message content here
-
-		var msg map[string]interface{}
-		// Basic parsing to handle logic side-effects
-		if err := json.Unmarshal(message, &msg); err == nil {
-			msgType, _ := msg["type"].(string)
-
-			if msgType == "join_board" {
-				boardID, _ := msg["board_id"].(string)
-				username, _ := msg["username"].(string)
-				avatar, _ := msg["avatar"].(string)
-				if boardID != "" && username != "" {
-					isAdmin, _ := msg["is_admin"].(bool)
-
-					// Update Client Context
-					c.boardID = boardID
-					c.username = username
-
-					c.hub.joinBoard <- &ParticipantMessage{
-						Type:     "join_board",
-						BoardID:  boardID,
-						Username: username,
-						Avatar:   avatar,
-						IsAdmin:  isAdmin,
-						Client:   c,
-					}
-				}
-			} else if msgType == "leave_board" {
-				boardID, _ := msg["board_id"].(string)
-				username, _ := msg["username"].(string)
-				if boardID != "" && username != "" {
-					c.hub.leaveBoard <- &ParticipantMessage{
-						Type:     "leave_board",
-						BoardID:  boardID,
-						Username: username,
-						Client:   c,
-					}
-				}
-			} else if msgType == "phase_change" {
-				// Persist phase change to DB locally (only once per cluster)
-				// Concurrency note: Multiple pods might try this if multiple users trigger it,
-				// but DB transaction handles it.
-				if boardID, ok := msg["board_id"].(string); ok {
-					if phase, ok := msg["phase"].(string); ok && boardID != "" {
-						if err := database.DB.Model(&models.Board{}).Where("id = ?", boardID).Update("phase", phase).Error; err != nil {
-							log.Printf("Failed to update board phase: %v", err)
-						}
-						// Broadcast this update GLOBALLY via Redis
-						// Note: The message will be published below
-					}
-				}
-			}
+		env, payload, perr := decodeEnvelope(message)
+		if perr == nil {
+			perr = c.dispatch(env, payload)
 		}
+		if perr != nil {
+			c.sendError(env.Type, perr)
+		}
+	}
+}
 
-		// PUB/SUB INTEGRATION:
-		// Instead of sending directly to c.hub.broadcast (local only),
-		// we publish to Redis so ALL pods receive it.
-		if rdb != nil {
-			err := rdb.Publish(context.Background(), redisChannel, message).Err()
-			if err != nil {
-				log.Printf("Redis Publish Error: %v", err)
-				// Fallback to local broadcast if Redis fails
-				c.hub.broadcast <- message
+// dispatch applies a validated client message. Outbound frames are always built
+// by the server; client payloads are never relayed verbatim.
+func (c *Client) dispatch(env Envelope, payload ClientPayload) *ProtocolError {
+	switch p := payload.(type) {
+	case *JoinBoardPayload:
+		if perr := c.checkClaims(p.Username, p.IsAdmin); perr != nil {
+			return perr
+		}
+		if perr := c.authorizeJoin(env.BoardID); perr != nil {
+			return perr
+		}
+
+		// Avatar falls back to the client's choice when the account has none
+		avatar := c.identity.Avatar
+		if avatar == "" {
+			avatar = p.Avatar
+		}
+
+		// Room membership and client context are updated by the hub
+		c.hub.joinBoard <- &ParticipantMessage{
+			Type:     MsgJoinBoard,
+			BoardID:  env.BoardID,
+			Username: c.identity.Username,
+			Avatar:   avatar,
+			IsAdmin:  c.identity.IsAdmin,
+			IsGuest:  c.identity.IsGuest,
+			Client:   c,
+			lastSeq:  p.LastSeq,
+		}
+
+	case *ResumePayload:
+		boardID, perr := c.joinedBoard(env.BoardID)
+		if perr != nil {
+			return perr
+		}
+		c.hub.mutex.Lock()
+		c.hub.syncClient(c, boardID, &p.LastSeq)
+		c.hub.mutex.Unlock()
+
+	case *LeaveBoardPayload:
+		if perr := c.checkClaims(p.Username, false); perr != nil {
+			return perr
+		}
+		if env.BoardID == "" {
+			return protocolError(ErrCodeInvalidMessage, "board_id is required")
+		}
+		c.hub.leaveBoard <- &ParticipantMessage{
+			Type:     MsgLeaveBoard,
+			BoardID:  env.BoardID,
+			Username: c.identity.Username,
+			Client:   c,
+		}
+
+	case *PhaseChangePayload:
+		boardID, perr := c.joinedBoard(env.BoardID)
+		if perr != nil {
+			return perr
+		}
+		var board models.Board
+		if err := database.DB.First(&board, "id = ?", boardID).Error; err != nil {
+			return protocolError(ErrCodeInvalidMessage, "board not found")
+		}
+		if !boardAccessFor(c.identity.User, &board).Can(BoardActionManage) {
+			return protocolError(ErrCodeForbidden, "only board managers can change the phase")
+		}
+		if err := changeBoardPhase(&board, p.Phase, c.identity.Username); err != nil {
+			if errors.Is(err, ErrPhaseTransition) || errors.Is(err, ErrPhaseConflict) || errors.Is(err, ErrCardsHidden) {
+				return protocolError(ErrCodeInvalidTransition, "%v", err)
 			}
-		} else {
-			// Fallback if Redis not initialized
-			c.hub.broadcast <- message
+			log.Printf("Failed to update board phase: %v", err)
+			return protocolError(ErrCodeInternal, "failed to update board phase")
+		}
+
+	case *TimerStartPayload:
+		return c.updateTimer(env.BoardID, TimerActionStart, p.Seconds)
+
+	case *TimerStopPayload:
+		return c.updateTimer(env.BoardID, TimerActionReset, 0)
+
+	case *CursorMovePayload:
+		boardID, perr := c.joinedBoard(env.BoardID)
+		if perr != nil {
+			return perr
+		}
+		c.hub.broadcast(boardID, MsgCursorMove, map[string]interface{}{
+			"board_id": boardID,
+			"user":     c.identity.Username,
+			"x":        p.X,
+			"y":        p.Y,
+		})
+	}
+	return nil
+}
+
+// updateTimer applies a timer action from a legacy timer message, which only
+// board managers may send
+func (c *Client) updateTimer(envBoardID, action string, seconds int) *ProtocolError {
+	boardID, perr := c.joinedBoard(envBoardID)
+	if perr != nil {
+		return perr
+	}
+	var board models.Board
+	if err := database.DB.First(&board, "id = ?", boardID).Error; err != nil {
+		return protocolError(ErrCodeInvalidMessage, "board not found")
+	}
+	if !boardAccessFor(c.identity.User, &board).Can(BoardActionManage) {
+		return protocolError(ErrCodeForbidden, "only board managers can control the timer")
+	}
+	if err := updateBoardTimer(&board, action, seconds, nil, c.identity.Username); err != nil {
+		if errors.Is(err, ErrTimerState) || errors.Is(err, ErrTimerConflict) {
+			return protocolError(ErrCodeInvalidTransition, "%v", err)
+		}
+		log.Printf("Failed to update board timer: %v", err)
+	}
+	return nil
+}
+
+// joinedBoard resolves the board a scoped message targets. Clients may only
+// address the board they have joined; an empty board_id means that board.
+func (c *Client) joinedBoard(boardID string) (string, *ProtocolError) {
+	current := c.hub.clientBoard(c)
+	if current == "" {
+		return "", protocolError(ErrCodeNotJoined, "join a board before sending board messages")
+	}
+	if boardID != "" && boardID != current {
+		return "", protocolError(ErrCodeNotJoined, "messages can only target the joined board")
+	}
+	return current, nil
+}
+
+// checkClaims rejects messages whose username or admin flag disagree with the handshake identity
+func (c *Client) checkClaims(username string, isAdmin bool) *ProtocolError {
+	if username != "" && username != c.identity.Username {
+		return protocolError(ErrCodeForbidden, "username does not match the authenticated user")
+	}
+	if isAdmin && !c.identity.IsAdmin {
+		return protocolError(ErrCodeForbidden, "admin privileges cannot be claimed by the client")
+	}
+	return nil
+}
+
+// authorizeJoin checks that the client may enter the board's room
+func (c *Client) authorizeJoin(boardID string) *ProtocolError {
+	if boardID == "" {
+		return protocolError(ErrCodeInvalidMessage, "board_id is required")
+	}
+	var board models.Board
+	if err := database.DB.First(&board, "id = ?", boardID).Error; err != nil {
+		return protocolError(ErrCodeInvalidMessage, "board not found")
+	}
+	if !boardAccessFor(c.identity.User, &board).Can(BoardActionJoin) {
+		if c.identity.IsGuest {
+			return protocolError(ErrCodeForbidden, "this board does not allow guests")
 		}
+		return protocolError(ErrCodeForbidden, "you do not have access to this board")
+	}
+	return nil
+}
+
+// sendError sends an error frame to this client only.
+// ref_type names the message type that was rejected, when known.
+func (c *Client) sendError(refType string, perr *ProtocolError) {
+	c.sendFrame(MsgError, "", map[string]interface{}{
+		"code":     perr.Code,
+		"message":  perr.Message,
+		"ref_type": refType,
+	})
+}
+
+// sendFrame sends an unsequenced frame to this client only
+func (c *Client) sendFrame(messageType, boardID string, payload interface{}) {
+	data, err := encodeEnvelope(messageType, boardID, 0, payload)
+	if err != nil {
+		return
 	}
+	c.hub.sendTo(c, data)
 }
 
 // writePump pumps messages from the hub to the websocket connection.
@@ -167,19 +303,8 @@ func (c *Client) writePump() {
 				return
 			}
 
-			w, err := c.conn.NextWriter(websocket.TextMessage)
-			if err != nil {
-				return
-			}
-			w.Write(message)
-
-			// Add queued chat messages to the current websocket message.
-			n := len(c.send)
-			for i := 0; i < n; i++ {
-				w.Write(<-c.send)
-			}
-
-			if err := w.Close(); err != nil {
+			// Each envelope goes in its own frame; clients parse one JSON document per message
+			if err := c.conn.WriteMessage(websocket.TextMessage, message); err != nil {
 				return
 			}
 		case <-ticker.C:
@@ -198,37 +323,59 @@ type ParticipantMessage struct {
 	Username string  `json:"username"`
 	Avatar   string  `json:"avatar"`
 	IsAdmin  bool    `json:"is_admin"`
+	IsGuest  bool    `json:"is_guest"`
 	Client   *Client `json:"-"`
+
+	lastSeq *uint64 // Last event seen by a rejoining client
 }
 
-// Hub maintains active WebSocket connections
+// Hub maintains active WebSocket connections, grouped into one room per board
 type Hub struct {
 	clients           map[*Client]bool
+	rooms             map[string]map[*Client]bool              // boardID -> clients viewing the board
 	boardParticipants map[string]map[string]models.Participant // boardID -> username -> Participant
-	broadcast         chan []byte
 	register          chan *Client
 	unregister        chan *Client
 	joinBoard         chan *ParticipantMessage
 	leaveBoard        chan *ParticipantMessage
+	broker            Broker                  // Carries events between pods
+	boardSubs         map[string]Subscription // boardID -> broker subscription, owned by Run
+	events            EventLog                // Sequences board events and keeps them for replay
+	presence          *clusterPresence        // Shares participants with other pods; nil without Redis
 	mutex             sync.RWMutex
 }
 
 // Global hub instance
-var hub = &Hub{
-	clients:           make(map[*Client]bool),
-	boardParticipants: make(map[string]map[string]models.Participant),
-	broadcast:         make(chan []byte),
-	register:          make(chan *Client),
-	unregister:        make(chan *Client),
-	joinBoard:         make(chan *ParticipantMessage),
-	leaveBoard:        make(chan *ParticipantMessage),
+var hub = NewHub(NewMemoryBroker())
+
+// NewHub creates a hub exchanging events with other pods through the broker
+func NewHub(broker Broker) *Hub {
+	return &Hub{
+		clients:           make(map[*Client]bool),
+		rooms:             make(map[string]map[*Client]bool),
+		boardParticipants: make(map[string]map[string]models.Participant),
+		register:          make(chan *Client),
+		unregister:        make(chan *Client),
+		joinBoard:         make(chan *ParticipantMessage),
+		leaveBoard:        make(chan *ParticipantMessage),
+		broker:            broker,
+		boardSubs:         make(map[string]Subscription),
+		events:            newMemoryEventLog(defaultEventLogSize),
+	}
 }
 
 // Run starts the hub
 func (h *Hub) Run() {
-	// Start Redis Subscriber in background
-	if rdb != nil {
-		go h.subscribeToRedis()
+	// Messages for every client arrive on the broadcast topic.
+	// Board topics are added and removed as rooms open and close on this pod.
+	_, err := h.broker.Subscribe(context.Background(), broadcastTopic, func(message []byte) {
+		h.deliver("", message)
+	})
+	if err != nil {
+		log.Printf("Broker Subscribe Error for %s: %v", broadcastTopic, err)
+	}
+	if h.presence != nil {
+		go h.heartbeatPresence()
 	}
 
 	for {
@@ -237,69 +384,251 @@ func (h *Hub) Run() {
 			h.mutex.Lock()
 			h.clients[client] = true
 			h.mutex.Unlock()
-			// log.Printf("Client connected. Total clients: %d", len(h.clients))
 
 		case client := <-h.unregister:
 			h.mutex.Lock()
 			if _, ok := h.clients[client]; ok {
-				// Cleanup participation
-				if client.boardID != "" && client.username != "" {
-					h.removeParticipant(client.boardID, client.username)
-					h.broadcastParticipants(client.boardID)
-				}
 				delete(h.clients, client)
 				close(client.send)
 			}
+			boardID, username := client.boardID, client.username
+			closed := h.leaveRoom(client)
+			if boardID != "" && username != "" {
+				h.removeParticipant(boardID, username)
+			}
 			h.mutex.Unlock()
-			// log.Printf("Client disconnected. Total clients: %d", len(h.clients))
+
+			if closed {
+				h.unsubscribeBoard(boardID)
+			}
+			if boardID != "" && username != "" {
+				h.presenceLeft(boardID, username)
+				h.broadcastParticipants(boardID)
+			}
 
 		case msg := <-h.joinBoard:
 			h.mutex.Lock()
+			// A client views one board at a time; switching boards leaves the previous room
+			previous, previousUser := msg.Client.boardID, msg.Client.username
+			previousClosed := false
+			if previous != "" && previous != msg.BoardID {
+				previousClosed = h.leaveRoom(msg.Client)
+				h.removeParticipant(previous, previousUser)
+			}
+			opened := h.joinRoom(msg.Client, msg.BoardID)
+			msg.Client.username = msg.Username
+
 			if _, ok := h.boardParticipants[msg.BoardID]; !ok {
 				h.boardParticipants[msg.BoardID] = make(map[string]models.Participant)
 			}
-			h.boardParticipants[msg.BoardID][msg.Username] = models.Participant{
+			participant := models.Participant{
 				Username: msg.Username,
 				Avatar:   msg.Avatar,
 				IsAdmin:  msg.IsAdmin,
+				IsGuest:  msg.IsGuest,
+				JoinedAt: time.Now(),
 			}
+			h.boardParticipants[msg.BoardID][msg.Username] = participant
+			// Catch the client up before any later event can reach the room
+			h.syncClient(msg.Client, msg.BoardID, msg.lastSeq)
 			h.mutex.Unlock()
+
+			if previousClosed {
+				h.unsubscribeBoard(previous)
+			}
+			if opened {
+				h.subscribeBoard(msg.BoardID)
+			}
+			if previous != "" && previous != msg.BoardID {
+				h.presenceLeft(previous, previousUser)
+				h.broadcastParticipants(previous)
+			}
+			h.presenceJoined(msg.BoardID, participant)
 			h.broadcastParticipants(msg.BoardID)
 
 		case msg := <-h.leaveBoard:
 			h.mutex.Lock()
 			h.removeParticipant(msg.BoardID, msg.Username)
+			closed := false
+			if msg.Client.boardID == msg.BoardID {
+				closed = h.leaveRoom(msg.Client)
+			}
 			h.mutex.Unlock()
-			h.broadcastParticipants(msg.BoardID)
 
-		// This channel now receives messages from Redis Subscription OR fallback
-		case message := <-h.broadcast:
-			// Non-blocking broadcast
-			h.mutex.RLock()
-			for client := range h.clients {
-				select {
-				case client.send <- message:
-				default:
-					close(client.send)
-					delete(h.clients, client)
-				}
+			if closed {
+				h.unsubscribeBoard(msg.BoardID)
 			}
-			h.mutex.RUnlock()
+			h.presenceLeft(msg.BoardID, msg.Username)
+			h.broadcastParticipants(msg.BoardID)
+		}
+	}
+}
+
+// joinRoom adds a client to a board room. Must be called with the hub mutex held.
+// Returns true if the room was created by this call.
+func (h *Hub) joinRoom(client *Client, boardID string) bool {
+	client.boardID = boardID
+	room, ok := h.rooms[boardID]
+	if !ok {
+		room = make(map[*Client]bool)
+		h.rooms[boardID] = room
+	}
+	room[client] = true
+	return !ok
+}
+
+// leaveRoom removes a client from its current room. Must be called with the hub mutex held.
+// Returns true if the room became empty and was removed.
+func (h *Hub) leaveRoom(client *Client) bool {
+	boardID := client.boardID
+	client.boardID = ""
+	if boardID == "" {
+		return false
+	}
+	room, ok := h.rooms[boardID]
+	if !ok {
+		return false
+	}
+	delete(room, client)
+	if len(room) == 0 {
+		delete(h.rooms, boardID)
+		return true
+	}
+	return false
+}
+
+// clientBoard returns the board the client is currently viewing
+func (h *Hub) clientBoard(client *Client) string {
+	h.mutex.RLock()
+	defer h.mutex.RUnlock()
+	return client.boardID
+}
+
+// boardTopic returns the broker topic carrying events for a single board
+func boardTopic(boardID string) string {
+	return boardTopicPrefix + boardID
+}
+
+// subscribeBoard starts receiving a board's events from the broker
+func (h *Hub) subscribeBoard(boardID string) {
+	sub, err := h.broker.Subscribe(context.Background(), boardTopic(boardID), func(message []byte) {
+		h.deliver(boardID, message)
+	})
+	if err != nil {
+		log.Printf("Broker Subscribe Error for board %s: %v", boardID, err)
+		return
+	}
+	h.boardSubs[boardID] = sub
+}
+
+// unsubscribeBoard stops receiving a board's events once no local client is viewing it
+func (h *Hub) unsubscribeBoard(boardID string) {
+	sub, ok := h.boardSubs[boardID]
+	if !ok {
+		return
+	}
+	delete(h.boardSubs, boardID)
+	if err := sub.Unsubscribe(); err != nil {
+		log.Printf("Broker Unsubscribe Error for board %s: %v", boardID, err)
+	}
+}
+
+// publish sends a message to every pod through the broker.
+// An empty boardID addresses every connected client.
+func (h *Hub) publish(boardID string, message []byte) {
+	topic := broadcastTopic
+	if boardID != "" {
+		topic = boardTopic(boardID)
+	}
+	if err := h.broker.Publish(context.Background(), topic, message); err != nil {
+		log.Printf("Broker Publish Error: %v", err)
+		// Clients on this pod still get the message
+		h.deliver(boardID, message)
+	}
+}
+
+// deliver writes a message to the local clients of a board room, or to all clients when boardID is empty
+func (h *Hub) deliver(boardID string, message []byte) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	targets := h.clients
+	if boardID != "" {
+		targets = h.rooms[boardID]
+	}
+
+	for client := range targets {
+		if !h.clients[client] {
+			// Already dropped; room membership is cleaned up on unregister
+			continue
+		}
+		// Non-blocking send; drop clients that cannot keep up
+		select {
+		case client.send <- message:
+		default:
+			close(client.send)
+			delete(h.clients, client)
 		}
 	}
 }
 
-func (h *Hub) subscribeToRedis() {
+// sendTo writes a message to a single local client
+func (h *Hub) sendTo(client *Client, message []byte) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	h.enqueue(client, message)
+}
+
+// enqueue writes a message to a client's send buffer. Must be called with the hub mutex held.
+func (h *Hub) enqueue(client *Client, message []byte) {
+	if !h.clients[client] {
+		return
+	}
+	select {
+	case client.send <- message:
+	default:
+		close(client.send)
+		delete(h.clients, client)
+	}
+}
+
+// syncClient brings a client up to date with a board's event sequence.
+// Without lastSeq it only reports the current seq; otherwise it replays the missed
+// events, or asks the client to reload the board when they are gone.
+// Must be called with the hub mutex held, so live events cannot overtake the replay.
+func (h *Hub) syncClient(client *Client, boardID string, lastSeq *uint64) {
 	ctx := context.Background()
-	pubsub := rdb.Subscribe(ctx, redisChannel)
-	defer pubsub.Close()
+	var (
+		frames [][]byte
+		latest uint64
+		err    error
+	)
+	if lastSeq == nil {
+		latest, err = h.events.Latest(ctx, boardID)
+	} else {
+		frames, latest, err = h.events.Since(ctx, boardID, *lastSeq)
+		if err == nil && len(frames) > maxReplayFrames {
+			err = ErrResyncRequired
+		}
+	}
 
-	ch := pubsub.Channel()
-	log.Printf("Subscribed to Redis channel: %s", redisChannel)
+	messageType := MsgSynced
+	payload := map[string]interface{}{"board_id": boardID, "seq": latest}
+	if err != nil {
+		if !errors.Is(err, ErrResyncRequired) {
+			log.Printf("Failed to read event log for board %s: %v", boardID, err)
+		}
+		messageType = MsgResyncRequired
+		frames = nil
+	} else {
+		payload["replayed"] = len(frames)
+	}
 
-	for msg := range ch {
-		// Forward message from Redis to local broadcast loop
-		h.broadcast <- []byte(msg.Payload)
+	for _, frame := range frames {
+		h.enqueue(client, frame)
+	}
+	if data, err := encodeEnvelope(messageType, boardID, 0, payload); err == nil {
+		h.enqueue(client, data)
 	}
 }
 
@@ -313,33 +642,63 @@ func (h *Hub) removeParticipant(boardID, username string) {
 }
 
 func (h *Hub) broadcastParticipants(boardID string) {
-	h.mutex.RLock()
-	participantsMap, ok := h.boardParticipants[boardID]
-	h.mutex.RUnlock()
-
-	if !ok {
-		participantsMap = make(map[string]models.Participant)
+	data := map[string]interface{}{
+		"board_id":     boardID,
+		"participants": h.GetBoardParticipants(boardID),
 	}
+	h.broadcast(boardID, MsgParticipantsUpdate, data)
+}
 
-	participants := make([]models.Participant, 0, len(participantsMap))
-	for _, p := range participantsMap {
-		participants = append(participants, p)
+// presenceJoined records a local participant in the cluster view
+func (h *Hub) presenceJoined(boardID string, participant models.Participant) {
+	if h.presence == nil {
+		return
 	}
+	if err := h.presence.Set(context.Background(), boardID, participant); err != nil {
+		log.Printf("Failed to record presence on board %s: %v", boardID, err)
+	}
+}
 
-	data := map[string]interface{}{
-		"board_id":     boardID,
-		"participants": participants,
+// presenceLeft removes a local participant from the cluster view
+func (h *Hub) presenceLeft(boardID, username string) {
+	if h.presence == nil {
+		return
+	}
+	if err := h.presence.Remove(context.Background(), boardID, username); err != nil {
+		log.Printf("Failed to remove presence on board %s: %v", boardID, err)
 	}
-	BroadcastMessage("participants_update", data)
 }
 
-// Public Methods maintained for compatibility
+// heartbeatPresence keeps this pod's participants alive in the cluster view and
+// announces participants whose pod stopped heartbeating
+func (h *Hub) heartbeatPresence() {
+	ticker := time.NewTicker(presenceHeartbeat)
+	defer ticker.Stop()
 
-// GetBoardParticipants returns the list of participants for a board
-func (h *Hub) GetBoardParticipants(boardID string) []models.Participant {
-	h.mutex.RLock()
-	defer h.mutex.RUnlock()
+	for range ticker.C {
+		h.mutex.RLock()
+		boards := make(map[string][]models.Participant, len(h.boardParticipants))
+		for boardID := range h.boardParticipants {
+			boards[boardID] = h.localParticipants(boardID)
+		}
+		h.mutex.RUnlock()
 
+		ctx := context.Background()
+		for boardID, participants := range boards {
+			if err := h.presence.Set(ctx, boardID, participants...); err != nil {
+				log.Printf("Failed to refresh presence on board %s: %v", boardID, err)
+				continue
+			}
+			if _, pruned, err := h.presence.List(ctx, boardID); err == nil && pruned {
+				h.broadcastParticipants(boardID)
+			}
+		}
+	}
+}
+
+// localParticipants lists the participants connected to this pod.
+// Must be called with the hub mutex held.
+func (h *Hub) localParticipants(boardID string) []models.Participant {
 	participantsMap, ok := h.boardParticipants[boardID]
 	if !ok {
 		return []models.Participant{}
@@ -352,8 +711,30 @@ func (h *Hub) GetBoardParticipants(boardID string) []models.Participant {
 	return participants
 }
 
+// Public Methods maintained for compatibility
+
+// GetBoardParticipants returns the list of participants for a board.
+// With Redis this is the view across every pod.
+func (h *Hub) GetBoardParticipants(boardID string) []models.Participant {
+	if h.presence != nil {
+		participants, _, err := h.presence.List(context.Background(), boardID)
+		if err == nil {
+			return participants
+		}
+		log.Printf("Failed to read presence for board %s: %v", boardID, err)
+	}
+
+	h.mutex.RLock()
+	defer h.mutex.RUnlock()
+	return h.localParticipants(boardID)
+}
+
 // GetParticipantCount returns the number of active participants for a board
 func (h *Hub) GetParticipantCount(boardID string) int {
+	if h.presence != nil {
+		return len(h.GetBoardParticipants(boardID))
+	}
+
 	h.mutex.RLock()
 	defer h.mutex.RUnlock()
 
@@ -365,30 +746,56 @@ func (h *Hub) GetParticipantCount(boardID string) int {
 
 // BroadcastMessage sends a message to all connected clients
 func BroadcastMessage(messageType string, data interface{}) {
-	message := map[string]interface{}{
-		"type": messageType,
-		"data": data,
+	BroadcastBoardMessage("", messageType, data)
+}
+
+// BroadcastBoardMessage sends a message to the clients viewing a board.
+// An empty boardID broadcasts to all connected clients.
+func BroadcastBoardMessage(boardID string, messageType string, data interface{}) {
+	hub.broadcast(boardID, messageType, data)
+}
+
+// broadcast publishes a message for a board, or for every client when boardID is empty.
+// Board events are numbered and kept in the event log for reconnecting clients.
+func (h *Hub) broadcast(boardID string, messageType string, data interface{}) {
+	build := func(seq uint64) ([]byte, error) {
+		return encodeEnvelope(messageType, boardID, seq, data)
+	}
+
+	var jsonData []byte
+	var err error
+	if boardID == "" || ephemeralMessageTypes[messageType] {
+		jsonData, err = build(0)
+	} else {
+		jsonData, err = h.events.Append(context.Background(), boardID, build)
+		if err != nil {
+			log.Printf("Error recording board event: %v", err)
+			if jsonData == nil {
+				// No sequence number could be assigned; still deliver the event live
+				jsonData, err = build(0)
+			} else {
+				err = nil
+			}
+		}
 	}
-	jsonData, err := json.Marshal(message)
 	if err != nil {
 		log.Printf("Error marshaling broadcast message: %v", err)
 		return
 	}
 
-	// Publish to Redis instead of local hub directly
-	if rdb != nil {
-		err := rdb.Publish(context.Background(), redisChannel, jsonData).Err()
-		if err != nil {
-			log.Printf("Redis Broadcast Error: %v", err)
-			hub.broadcast <- jsonData
-		}
-	} else {
-		hub.broadcast <- jsonData
-	}
+	h.publish(boardID, jsonData)
 }
 
-// HandleWebSocket handles WebSocket connections
+// HandleWebSocket handles WebSocket connections.
+// The connection acts as the user behind the same auth_token cookie / Bearer token
+// accepted by AuthMiddleware. Unauthenticated clients must opt into guest mode
+// with ?guest=<name> and can only join boards that allow guests.
 func HandleWebSocket(c *gin.Context) {
+	identity, ok := resolveClientIdentity(c)
+	if !ok {
+		return
+	}
+
 	conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
 	if err != nil {
 		log.Printf("Failed to upgrade connection: %v", err)
@@ -396,9 +803,10 @@ func HandleWebSocket(c *gin.Context) {
 	}
 
 	client := &Client{
-		hub:  hub,
-		conn: conn,
-		send: make(chan []byte, 256),
+		hub:      hub,
+		conn:     conn,
+		send:     make(chan []byte, 256),
+		identity: identity,
 	}
 	client.hub.register <- client
 
@@ -408,6 +816,37 @@ func HandleWebSocket(c *gin.Context) {
 	go client.readPump()
 }
 
+// resolveClientIdentity derives the identity of a real-time client from the request:
+// the authenticated user, or the guest named by ?guest=<name>. It writes the error
+// response and returns false when neither is acceptable.
+func resolveClientIdentity(c *gin.Context) (clientIdentity, bool) {
+	user, err := authenticateRequest(c)
+	if err == nil {
+		return clientIdentity{
+			User:     &user,
+			Username: displayName(user),
+			Avatar:   user.AvatarURL,
+			IsAdmin:  user.Role == "admin",
+		}, true
+	}
+
+	guestName := strings.TrimSpace(c.Query("guest"))
+	if guestName == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+		return clientIdentity{}, false
+	}
+	if len(guestName) > maxGuestNameLength {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Guest name is too long"})
+		return clientIdentity{}, false
+	}
+	// Guests may not borrow the name of a registered user
+	if isRegisteredName(guestName) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Guest name belongs to a registered user"})
+		return clientIdentity{}, false
+	}
+	return clientIdentity{Username: guestName, IsGuest: true}, true
+}
+
 // InitWebSocketHub initializes and starts the WebSocket hub
 func InitWebSocketHub() {
 	// Initialize Redis
@@ -425,34 +864,86 @@ func InitWebSocketHub() {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 	if err := rdb.Ping(ctx).Err(); err != nil {
-		log.Printf("Warning: Failed to connect to Redis at %s: %v. Pub/Sub will be disabled.", redisAddr, err)
+		log.Printf("Warning: Failed to connect to Redis at %s: %v. Redis features will be disabled.", redisAddr, err)
 		rdb = nil // Disable Redis
 	} else {
 		log.Printf("Connected to Redis at %s", redisAddr)
 	}
 
+	// Event log for reconnect replay; shared through Redis when it is available
+	backend := os.Getenv("EVENT_LOG_BACKEND")
+	if backend == "" {
+		backend = "memory"
+		if rdb != nil {
+			backend = "redis"
+		}
+	}
+	size, _ := strconv.Atoi(os.Getenv("EVENT_LOG_SIZE"))
+	events, err := NewEventLog(backend, rdb, size)
+	if err != nil {
+		log.Printf("Warning: %v. Falling back to the in-memory event log.", err)
+		events, _ = NewEventLog("memory", nil, size)
+	}
+	hub.events = events
+
+	// Participants are shared between pods when Redis is available
+	if rdb != nil {
+		hub.presence = newClusterPresence(rdb)
+	}
+
+	// Broker carrying events between pods; Redis Pub/Sub when it is available
+	brokerConfig := BrokerConfig{
+		Backend: os.Getenv("BROKER"),
+		NATSURL: os.Getenv("NATS_URL"),
+	}
+	if brokerConfig.Backend == "" {
+		brokerConfig.Backend = "local"
+		if rdb != nil {
+			brokerConfig.Backend = "redis"
+		}
+	}
+	broker, err := NewBroker(brokerConfig, rdb)
+	if err != nil {
+		log.Printf("Warning: %v. Falling back to the in-process broker; events will not reach other pods.", err)
+		broker = NewMemoryBroker()
+	} else {
+		log.Printf("Using %s broker", brokerConfig.Backend)
+	}
+	hub.broker = broker
+
 	go hub.Run()
 }
 
-// BroadcastBoardUpdate sends the updated board data to all connected clients
+// BroadcastBoardUpdate tells the clients viewing a board to refresh it
 func BroadcastBoardUpdate(boardID uuid.UUID) {
 	data := map[string]interface{}{
 		"board_id": boardID.String(),
 		"action":   "refresh_board",
 	}
-	BroadcastMessage("board_update", data)
+	BroadcastBoardMessage(boardID.String(), MsgBoardUpdate, data)
+}
+
+// BroadcastBoardListChanged tells every connected client, including dashboards
+// that joined no board, that a board was created, changed or deleted
+func BroadcastBoardListChanged(boardID uuid.UUID, action string) {
+	data := map[string]interface{}{
+		"board_id": boardID.String(),
+		"action":   action,
+	}
+	BroadcastMessage(MsgBoardListChanged, data)
 }
 
 // BroadcastVoteUpdate sends only the updated vote counts for a specific card
-func BroadcastVoteUpdate(boardID uuid.UUID, cardID uuid.UUID, likes int, dislikes int) {
+func BroadcastVoteUpdate(boardID uuid.UUID, cardID uuid.UUID, likes int, dislikes int, groups []models.GroupVoteTotal) {
 	data := map[string]interface{}{
 		"board_id": boardID.String(),
 		"card_id":  cardID.String(),
 		"likes":    likes,
 		"dislikes": dislikes,
+		"groups":   groups,
 		"action":   "vote_updated",
 	}
-	BroadcastMessage("vote_update", data)
+	BroadcastBoardMessage(boardID.String(), MsgVoteUpdate, data)
 }
 
 // BroadcastCardMove sends the new position of a card
@@ -464,5 +955,300 @@ func BroadcastCardMove(boardID uuid.UUID, cardID uuid.UUID, columnID uuid.UUID,
 		"position":  position,
 		"action":    "card_moved",
 	}
-	BroadcastMessage("card_move", data)
+	BroadcastBoardMessage(boardID.String(), MsgCardMove, data)
+}
+
+// BroadcastPhaseChanged announces a board's new phase, who changed it and when
+func BroadcastPhaseChanged(boardID uuid.UUID, previous, phase, actor string, changedAt time.Time) {
+	data := map[string]interface{}{
+		"board_id":       boardID.String(),
+		"phase":          phase,
+		"previous_phase": previous,
+		"changed_by":     actor,
+		"changed_at":     changedAt,
+		"action":         "phase_changed",
+	}
+	BroadcastBoardMessage(boardID.String(), MsgPhaseChanged, data)
+}
+
+// BroadcastTimerUpdated sends a board's timer after an action, stamped with the
+// server time so clients can correct for their clock skew
+func BroadcastTimerUpdated(boardID uuid.UUID, action string, timer models.BoardTimer) {
+	now := time.Now()
+	data := map[string]interface{}{
+		"board_id":    boardID.String(),
+		"timer":       timer,
+		"remaining":   timerRemaining(timer, now),
+		"server_time": now,
+		"action":      "timer_" + action,
+	}
+	BroadcastBoardMessage(boardID.String(), MsgTimerUpdated, data)
+}
+
+// BroadcastCardCreated sends a newly created card.
+// Card broadcasts leave out authors on boards that keep them anonymous.
+func BroadcastCardCreated(boardID uuid.UUID, card models.Card) {
+	data := map[string]interface{}{
+		"board_id": boardID.String(),
+		"card":     presentBroadcastCard(boardID, card),
+		"action":   "card_created",
+	}
+	BroadcastBoardMessage(boardID.String(), MsgCardCreated, data)
+}
+
+// BroadcastCardUpdated sends the new state of a card.
+// Clients keep the votes, reactions and comments they already have.
+func BroadcastCardUpdated(boardID uuid.UUID, card models.Card) {
+	data := map[string]interface{}{
+		"board_id": boardID.String(),
+		"card":     presentBroadcastCard(boardID, card),
+		"action":   "card_updated",
+	}
+	BroadcastBoardMessage(boardID.String(), MsgCardUpdated, data)
+}
+
+// BroadcastCardDeleted sends the ID of a deleted card
+func BroadcastCardDeleted(boardID uuid.UUID, cardID uuid.UUID, columnID uuid.UUID) {
+	data := map[string]interface{}{
+		"board_id":  boardID.String(),
+		"card_id":   cardID.String(),
+		"column_id": columnID.String(),
+		"action":    "card_deleted",
+	}
+	BroadcastBoardMessage(boardID.String(), MsgCardDeleted, data)
+}
+
+// BroadcastCardMerged sends a card that was merged into another one, with the
+// group both cards now belong to
+func BroadcastCardMerged(boardID uuid.UUID, card models.Card, targetCardID uuid.UUID, group models.CardGroup) {
+	data := map[string]interface{}{
+		"board_id":       boardID.String(),
+		"card":           presentBroadcastCard(boardID, card),
+		"target_card_id": targetCardID.String(),
+		"group":          group,
+		"action":         "card_merged",
+	}
+	BroadcastBoardMessage(boardID.String(), MsgCardMerged, data)
+}
+
+// BroadcastGroupChanged sends a created or renamed card group
+func BroadcastGroupChanged(boardID uuid.UUID, group models.CardGroup, action string) {
+	msgType := MsgGroupUpdated
+	if action == "created" {
+		msgType = MsgGroupCreated
+	}
+	data := map[string]interface{}{
+		"board_id": boardID.String(),
+		"group":    group,
+		"action":   "group_" + action,
+	}
+	BroadcastBoardMessage(boardID.String(), msgType, data)
+}
+
+// BroadcastGroupMoved sends a group moved to another column or parent; the
+// cards and groups nested in it moved along
+func BroadcastGroupMoved(boardID uuid.UUID, group models.CardGroup) {
+	data := map[string]interface{}{
+		"board_id": boardID.String(),
+		"group":    group,
+		"action":   "group_moved",
+	}
+	BroadcastBoardMessage(boardID.String(), MsgGroupMoved, data)
+}
+
+// BroadcastGroupDeleted sends the ID of a deleted group. Its cards and
+// subgroups moved up to parentID, or out of any group when it is nil.
+func BroadcastGroupDeleted(boardID uuid.UUID, groupID uuid.UUID, parentID *uuid.UUID) {
+	data := map[string]interface{}{
+		"board_id":  boardID.String(),
+		"group_id":  groupID.String(),
+		"parent_id": parentID,
+		"action":    "group_deleted",
+	}
+	BroadcastBoardMessage(boardID.String(), MsgGroupDeleted, data)
+}
+
+// BroadcastColumnRenamed sends the new name of a column
+func BroadcastColumnRenamed(boardID uuid.UUID, columnID uuid.UUID, name string) {
+	data := map[string]interface{}{
+		"board_id":  boardID.String(),
+		"column_id": columnID.String(),
+		"name":      name,
+		"action":    "column_renamed",
+	}
+	BroadcastBoardMessage(boardID.String(), MsgColumnRenamed, data)
+}
+
+// BroadcastColumnReordered sends the new position of a column
+func BroadcastColumnReordered(boardID uuid.UUID, columnID uuid.UUID, position int) {
+	data := map[string]interface{}{
+		"board_id":  boardID.String(),
+		"column_id": columnID.String(),
+		"position":  position,
+		"action":    "column_reordered",
+	}
+	BroadcastBoardMessage(boardID.String(), MsgColumnReordered, data)
+}
+
+// BroadcastColumnVoteLimitChanged sends the new dot budget of a column
+func BroadcastColumnVoteLimitChanged(boardID uuid.UUID, columnID uuid.UUID, voteLimit int) {
+	data := map[string]interface{}{
+		"board_id":   boardID.String(),
+		"column_id":  columnID.String(),
+		"vote_limit": voteLimit,
+		"action":     "column_vote_limit_changed",
+	}
+	BroadcastBoardMessage(boardID.String(), MsgColumnVoteLimit, data)
+}
+
+// BroadcastBoardSettingsChanged sends a board's settings after a change
+func BroadcastBoardSettingsChanged(board models.Board) {
+	data := map[string]interface{}{
+		"board_id": board.ID.String(),
+		"settings": map[string]interface{}{
+			"name":         board.Name,
+			"status":       board.Status,
+			"finished_at":  board.FinishedAt,
+			"vote_limit":   board.VoteLimit,
+			"voting_mode":  board.VotingMode,
+			"blind_voting": board.BlindVoting,
+			"allow_guests": board.AllowGuests,
+			"anonymity":    board.Anonymity,
+			// Clients reload the board to get the authors once they are revealed
+			"authors_revealed":  board.AuthorsRevealed,
+			"private_writing":   board.PrivateWriting,
+			"cards_revealed_at": board.CardsRevealedAt,
+			"survey_question":   board.SurveyQuestion,
+			"survey_scale":      board.SurveyScale,
+			"survey_comments":   board.SurveyComments,
+			"survey_opened_at":  board.SurveyOpenedAt,
+		},
+		"action": "board_settings_changed",
+	}
+	BroadcastBoardMessage(board.ID.String(), MsgBoardSettingsChanged, data)
+}
+
+// BroadcastCardsRevealed discloses every card of a private writing board at once
+func BroadcastCardsRevealed(board models.Board, cards []models.Card) {
+	revealed := make([]models.Card, len(cards))
+	for i, card := range cards {
+		revealed[i] = presentCard(&board, card, "")
+	}
+	data := map[string]interface{}{
+		"board_id":    board.ID.String(),
+		"cards":       revealed,
+		"revealed_at": board.CardsRevealedAt,
+		"action":      "cards_revealed",
+	}
+	BroadcastBoardMessage(board.ID.String(), MsgCardsRevealed, data)
+}
+
+// BroadcastResultsRevealed sends the results snapshot taken when voting closed
+func BroadcastResultsRevealed(boardID uuid.UUID, results models.VoteResults) {
+	data := map[string]interface{}{
+		"board_id": boardID.String(),
+		"results":  results,
+		"action":   "results_revealed",
+	}
+	BroadcastBoardMessage(boardID.String(), MsgResultsRevealed, data)
+}
+
+// BroadcastSurveyUpdated sends the closing survey tally after an answer
+func BroadcastSurveyUpdated(boardID uuid.UUID, survey models.SurveyResults) {
+	data := map[string]interface{}{
+		"board_id": boardID.String(),
+		"survey":   survey,
+		"action":   "survey_updated",
+	}
+	BroadcastBoardMessage(boardID.String(), MsgSurveyUpdated, data)
+}
+
+// BroadcastCommentCreated sends a new comment, without its author on anonymous boards
+func BroadcastCommentCreated(board models.Board, comment models.CardComment) {
+	data := map[string]interface{}{
+		"board_id": board.ID.String(),
+		"card_id":  comment.CardID.String(),
+		"comment":  presentComment(&board, comment, ""),
+		"action":   "comment_created",
+	}
+	BroadcastBoardMessage(board.ID.String(), MsgCommentCreated, data)
+}
+
+// BroadcastCommentUpdated sends an edited comment, without its author on anonymous boards
+func BroadcastCommentUpdated(board models.Board, comment models.CardComment) {
+	data := map[string]interface{}{
+		"board_id": board.ID.String(),
+		"card_id":  comment.CardID.String(),
+		"comment":  presentComment(&board, comment, ""),
+		"action":   "comment_updated",
+	}
+	BroadcastBoardMessage(board.ID.String(), MsgCommentUpdated, data)
+}
+
+// BroadcastCommentDeleted sends the ID of a deleted comment
+func BroadcastCommentDeleted(boardID uuid.UUID, cardID uuid.UUID, commentID uuid.UUID) {
+	data := map[string]interface{}{
+		"board_id":   boardID.String(),
+		"card_id":    cardID.String(),
+		"comment_id": commentID.String(),
+		"action":     "comment_deleted",
+	}
+	BroadcastBoardMessage(boardID.String(), MsgCommentDeleted, data)
+}
+
+// BroadcastLabelChanged sends a created or updated label to every board that shows it
+func BroadcastLabelChanged(label models.Label, action string) {
+	msgType := MsgLabelUpdated
+	if action == "created" {
+		msgType = MsgLabelCreated
+	}
+	for _, boardID := range labelBoardIDs(label) {
+		data := map[string]interface{}{
+			"board_id": boardID.String(),
+			"label":    label,
+			"action":   "label_" + action,
+		}
+		BroadcastBoardMessage(boardID.String(), msgType, data)
+	}
+}
+
+// BroadcastLabelDeleted sends the ID of a deleted label
+func BroadcastLabelDeleted(boardID uuid.UUID, labelID uuid.UUID) {
+	data := map[string]interface{}{
+		"board_id": boardID.String(),
+		"label_id": labelID.String(),
+		"action":   "label_deleted",
+	}
+	BroadcastBoardMessage(boardID.String(), MsgLabelDeleted, data)
+}
+
+// BroadcastCardLabeled sends a label added to (active) or removed from a card.
+// Cards hidden by private writing keep their labels to themselves until the reveal.
+func BroadcastCardLabeled(board models.Board, cardID uuid.UUID, label models.Label, active bool) {
+	if cardsHidden(&board) {
+		return
+	}
+	msgType, action := MsgCardUnlabeled, "card_unlabeled"
+	if active {
+		msgType, action = MsgCardLabeled, "card_labeled"
+	}
+	data := map[string]interface{}{
+		"board_id": board.ID.String(),
+		"card_id":  cardID.String(),
+		"label":    label,
+		"action":   action,
+	}
+	BroadcastBoardMessage(board.ID.String(), msgType, data)
+}
+
+// BroadcastReactionToggled sends a reaction added to (active) or removed from a card
+func BroadcastReactionToggled(boardID uuid.UUID, reaction models.Reaction, active bool) {
+	data := map[string]interface{}{
+		"board_id": boardID.String(),
+		"card_id":  reaction.CardID.String(),
+		"reaction": reaction,
+		"active":   active,
+		"action":   "reaction_toggled",
+	}
+	BroadcastBoardMessage(boardID.String(), MsgReactionToggled, data)
 }
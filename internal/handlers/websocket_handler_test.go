@@ -1,8 +1,12 @@
 package handlers_test
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
+	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"strings"
 	"sync"
 	"testing"
@@ -17,6 +21,7 @@ import (
 	"github.com/google/uuid"
 	"github.com/gorilla/websocket"
 	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/bcrypt"
 	"gorm.io/gorm"
 )
 
@@ -35,6 +40,16 @@ func setupWSTest(t *testing.T) (*gorm.DB, *gin.Engine) {
 		&models.Board{},
 		&models.Column{},
 		&models.Card{},
+		&models.Vote{},
+		&models.Reaction{},
+		&models.CardComment{},
+		&models.CardRevision{},
+		&models.Label{},
+		&models.CardLabel{},
+		&models.CardGroup{},
+		&models.Team{},
+		&models.TeamMember{},
+		&models.BoardMember{},
 	)
 	if err != nil {
 		panic(err)
@@ -46,21 +61,70 @@ func setupWSTest(t *testing.T) (*gorm.DB, *gin.Engine) {
 
 	// Start Hub Once
 	startHubOnce.Do(func() {
+		handlers.InitAuth()
 		handlers.InitWebSocketHub()
 		time.Sleep(50 * time.Millisecond) // Warmup
 	})
 
+	r.POST("/login", handlers.Login)
 	r.GET("/ws", handlers.HandleWebSocket)
 
 	return db, r
 }
 
+// Helper to register a user and return an auth header carrying their JWT
+func wsAuthHeader(t *testing.T, db *gorm.DB, r *gin.Engine, displayName string) http.Header {
+	email := displayName + "@example.com"
+	hash, _ := bcrypt.GenerateFromPassword([]byte("password123"), bcrypt.MinCost)
+	db.Where("email = ?", email).Delete(&models.User{})
+	db.Create(&models.User{DisplayName: displayName, Email: email, PasswordHash: string(hash), Role: "user"})
+
+	body, _ := json.Marshal(map[string]string{"email": email, "password": "password123"})
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("POST", "/login", bytes.NewBuffer(body)))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var resp map[string]interface{}
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	token, _ := resp["token"].(string)
+
+	header := http.Header{}
+	header.Set("Authorization", "Bearer "+token)
+	return header
+}
+
+// Helper to build a client envelope
+func envelope(msgType string, boardID uuid.UUID, payload map[string]interface{}) map[string]interface{} {
+	return map[string]interface{}{
+		"v":        handlers.ProtocolVersion,
+		"type":     msgType,
+		"board_id": boardID.String(),
+		"payload":  payload,
+	}
+}
+
+// Helper to read messages until one of the given type arrives
+func readUntilType(ws *websocket.Conn, msgType string, timeout time.Duration) map[string]interface{} {
+	ws.SetReadDeadline(time.Now().Add(timeout))
+	for {
+		_, msg, err := ws.ReadMessage()
+		if err != nil {
+			return nil
+		}
+		var resp map[string]interface{}
+		json.Unmarshal(msg, &resp)
+		if resp["type"] == msgType {
+			return resp
+		}
+	}
+}
+
 func TestWebSocketFlow(t *testing.T) {
 	db, r := setupWSTest(t)
 
 	// Create a Board
 	boardID := uuid.New()
-	db.Create(&models.Board{ID: boardID, Name: "WS Board", Status: "active"})
+	db.Create(&models.Board{ID: boardID, Name: "WS Board", Status: "active", Owner: "user1"})
 
 	// Start Test Server
 	server := httptest.NewServer(r)
@@ -70,17 +134,15 @@ func TestWebSocketFlow(t *testing.T) {
 	wsURL := "ws" + strings.TrimPrefix(server.URL, "http") + "/ws"
 
 	// Connect Client 1
-	ws1, _, err := websocket.DefaultDialer.Dial(wsURL, nil)
+	ws1, _, err := websocket.DefaultDialer.Dial(wsURL, wsAuthHeader(t, db, r, "user1"))
 	assert.NoError(t, err)
 	defer ws1.Close()
 
 	// Client 1 Joins Board
-	joinMsg := map[string]interface{}{
-		"type":     "join_board",
-		"board_id": boardID.String(),
+	joinMsg := envelope("join_board", boardID, map[string]interface{}{
 		"username": "user1",
 		"avatar":   "u1.png",
-	}
+	})
 	err = ws1.WriteJSON(joinMsg)
 	assert.NoError(t, err)
 
@@ -95,7 +157,7 @@ func TestWebSocketFlow(t *testing.T) {
 		json.Unmarshal(msg, &resp)
 
 		if resp["type"] == "participants_update" {
-			data := resp["data"].(map[string]interface{})
+			data := resp["payload"].(map[string]interface{})
 			if data["board_id"] == boardID.String() {
 				foundParticipants = true
 				break
@@ -105,17 +167,15 @@ func TestWebSocketFlow(t *testing.T) {
 	assert.True(t, foundParticipants, "Client 1 should receive participants_update")
 
 	// Connect Client 2
-	ws2, _, err := websocket.DefaultDialer.Dial(wsURL, nil)
+	ws2, _, err := websocket.DefaultDialer.Dial(wsURL, wsAuthHeader(t, db, r, "user2"))
 	assert.NoError(t, err)
 	defer ws2.Close()
 
 	// Client 2 Joins
-	joinMsg2 := map[string]interface{}{
-		"type":     "join_board",
-		"board_id": boardID.String(),
+	joinMsg2 := envelope("join_board", boardID, map[string]interface{}{
 		"username": "user2",
 		"avatar":   "u2.png",
-	}
+	})
 	ws2.WriteJSON(joinMsg2)
 
 	// Client 1 should receive Client 2's join or update
@@ -129,7 +189,7 @@ func TestWebSocketFlow(t *testing.T) {
 		var resp map[string]interface{}
 		json.Unmarshal(msg, &resp)
 		if resp["type"] == "participants_update" {
-			data := resp["data"].(map[string]interface{})
+			data := resp["payload"].(map[string]interface{})
 			parts := data["participants"].([]interface{})
 			if len(parts) >= 2 { // Should see 2 users now
 				foundUpdate = true
@@ -140,18 +200,437 @@ func TestWebSocketFlow(t *testing.T) {
 	assert.True(t, foundUpdate, "Client 1 should see Client 2 joining")
 
 	// Test Phase Change
-	phaseMsg := map[string]interface{}{
-		"type":     "phase_change",
-		"board_id": boardID.String(),
-		"phase":    "voting",
+	// Only managers may change the phase
+	ws2.WriteJSON(envelope("phase_change", boardID, map[string]interface{}{"phase": "voting"}))
+	errFrame := readUntilType(ws2, "error", 2*time.Second)
+	if assert.NotNil(t, errFrame) {
+		assert.Equal(t, "forbidden", errFrame["payload"].(map[string]interface{})["code"])
 	}
-	ws1.WriteJSON(phaseMsg)
 
-	// Give it a moment to process DB update
-	time.Sleep(100 * time.Millisecond)
+	ws1.WriteJSON(envelope("phase_change", boardID, map[string]interface{}{"phase": "voting"}))
+
+	// Every participant receives the server-built phase_changed
+	phase := readUntilType(ws2, "phase_changed", 2*time.Second)
+	if assert.NotNil(t, phase) {
+		assert.Equal(t, boardID.String(), phase["board_id"])
+		payload := phase["payload"].(map[string]interface{})
+		assert.Equal(t, "voting", payload["phase"])
+		assert.Equal(t, "input", payload["previous_phase"])
+		assert.Equal(t, "user1", payload["changed_by"])
+	}
+
+	// Transitions outside the phase model are refused
+	ws1.WriteJSON(envelope("phase_change", boardID, map[string]interface{}{"phase": "closed"}))
+	errFrame = readUntilType(ws1, "error", 2*time.Second)
+	if assert.NotNil(t, errFrame) {
+		assert.Equal(t, "invalid_transition", errFrame["payload"].(map[string]interface{})["code"])
+	}
+
+	// Voting does not close while private writing hides the cards
+	db.Model(&models.Board{}).Where("id = ?", boardID).Update("private_writing", true)
+	ws1.WriteJSON(envelope("phase_change", boardID, map[string]interface{}{"phase": "discuss"}))
+	errFrame = readUntilType(ws1, "error", 2*time.Second)
+	if assert.NotNil(t, errFrame) {
+		assert.Equal(t, "invalid_transition", errFrame["payload"].(map[string]interface{})["code"])
+	}
 
 	// Verify DB update
 	var updatedBoard models.Board
 	db.First(&updatedBoard, boardID)
 	assert.Equal(t, "voting", updatedBoard.Phase)
 }
+
+func TestWebSocketBoardRooms(t *testing.T) {
+	db, r := setupWSTest(t)
+
+	boardA := uuid.New()
+	boardB := uuid.New()
+	db.Create(&models.Board{ID: boardA, Name: "Room A", Status: "active", AllowGuests: true})
+	db.Create(&models.Board{ID: boardB, Name: "Room B", Status: "active", AllowGuests: true})
+
+	server := httptest.NewServer(r)
+	defer server.Close()
+	wsURL := "ws" + strings.TrimPrefix(server.URL, "http") + "/ws"
+
+	wsA, _, err := websocket.DefaultDialer.Dial(wsURL+"?guest=alice", nil)
+	assert.NoError(t, err)
+	defer wsA.Close()
+	wsB, _, err := websocket.DefaultDialer.Dial(wsURL+"?guest=bob", nil)
+	assert.NoError(t, err)
+	defer wsB.Close()
+
+	wsA.WriteJSON(envelope("join_board", boardA, map[string]interface{}{"username": "alice"}))
+	wsB.WriteJSON(envelope("join_board", boardB, map[string]interface{}{"username": "bob"}))
+
+	// Wait for both joins to be processed by the hub
+	time.Sleep(200 * time.Millisecond)
+
+	handlers.BroadcastBoardUpdate(boardA)
+
+	// Client A receives the update for its board
+	foundUpdate := false
+	wsA.SetReadDeadline(time.Now().Add(2 * time.Second))
+	for {
+		_, msg, err := wsA.ReadMessage()
+		if err != nil {
+			break
+		}
+		var resp map[string]interface{}
+		json.Unmarshal(msg, &resp)
+		if resp["type"] == "board_update" {
+			foundUpdate = true
+			break
+		}
+	}
+	assert.True(t, foundUpdate, "Client in room A should receive board A updates")
+
+	// Client B never sees traffic from board A
+	wsB.SetReadDeadline(time.Now().Add(500 * time.Millisecond))
+	for {
+		_, msg, err := wsB.ReadMessage()
+		if err != nil {
+			break
+		}
+		var resp map[string]interface{}
+		json.Unmarshal(msg, &resp)
+		assert.NotEqual(t, boardA.String(), resp["board_id"], "Client in room B should not receive board A traffic")
+	}
+}
+
+func TestWebSocketBoardListChanged(t *testing.T) {
+	_, r := setupWSTest(t)
+	server := httptest.NewServer(r)
+	defer server.Close()
+	wsURL := "ws" + strings.TrimPrefix(server.URL, "http") + "/ws"
+
+	// The dashboard connects without joining any board
+	dashboard, _, err := websocket.DefaultDialer.Dial(wsURL+"?guest=dana", nil)
+	assert.NoError(t, err)
+	defer dashboard.Close()
+	time.Sleep(100 * time.Millisecond)
+
+	boardID := uuid.New()
+	handlers.BroadcastBoardListChanged(boardID, "board_created")
+	frame := readUntilType(dashboard, "board_list_changed", 2*time.Second)
+	if assert.NotNil(t, frame) {
+		payload := frame["payload"].(map[string]interface{})
+		assert.Equal(t, boardID.String(), payload["board_id"])
+		assert.Equal(t, "board_created", payload["action"])
+	}
+}
+
+// TestGuestConnections follows a guest through the UI: the owner allows guests
+// in the board settings, and the guest connects with the name they chose.
+func TestGuestConnections(t *testing.T) {
+	db, r := setupWSTest(t)
+	r.PUT("/boards/:id", handlers.AuthMiddleware(), handlers.UpdateBoard)
+	r.GET("/boards/:id/events", handlers.HandleBoardEvents)
+	owner := wsAuthHeader(t, db, r, "olga")
+	boardID := uuid.New()
+	db.Create(&models.Board{ID: boardID, Name: "Guest Board", Owner: "olga", Status: "active"})
+
+	server := httptest.NewServer(r)
+	defer server.Close()
+	// The name is encoded like encodeURIComponent does in web/js/api.js
+	guestName := "Zé Visitor"
+	guestQuery := "guest=" + url.PathEscape(guestName)
+	wsURL := "ws" + strings.TrimPrefix(server.URL, "http") + "/ws?" + guestQuery
+	join := envelope("join_board", boardID, map[string]interface{}{"username": guestName, "avatar": "🦊"})
+
+	guest, _, err := websocket.DefaultDialer.Dial(wsURL, nil)
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer guest.Close()
+	guest.WriteJSON(join)
+	assert.NotNil(t, readUntilType(guest, "error", 2*time.Second), "Guests wait for the owner to allow them")
+
+	body, _ := json.Marshal(map[string]bool{"allow_guests": true})
+	req := httptest.NewRequest("PUT", "/boards/"+boardID.String(), bytes.NewBuffer(body))
+	req.Header = owner.Clone()
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	guest.WriteJSON(join)
+	update := readUntilType(guest, "participants_update", 2*time.Second)
+	if assert.NotNil(t, update) {
+		parts := update["payload"].(map[string]interface{})["participants"].([]interface{})
+		assert.Equal(t, guestName, parts[0].(map[string]interface{})["username"])
+		assert.Equal(t, true, parts[0].(map[string]interface{})["is_guest"])
+	}
+
+	// The Server-Sent Events fallback carries the same guest name
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	resp, reader := openEventStream(ctx, t, server.URL+"/boards/"+boardID.String()+"/events?last_event_id=0&"+guestQuery, "")
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.NotNil(t, readSSEUntilType(reader, "synced"))
+}
+
+func TestWebSocketAuthentication(t *testing.T) {
+	db, r := setupWSTest(t)
+
+	membersOnly := uuid.New()
+	guestBoard := uuid.New()
+	db.Create(&models.Board{ID: membersOnly, Name: "Members Only", Status: "active"})
+	db.Create(&models.Board{ID: guestBoard, Name: "Open Board", Status: "active", AllowGuests: true})
+
+	server := httptest.NewServer(r)
+	defer server.Close()
+	wsURL := "ws" + strings.TrimPrefix(server.URL, "http") + "/ws"
+
+	// Anonymous connections without guest mode are rejected at the handshake
+	_, resp, err := websocket.DefaultDialer.Dial(wsURL, nil)
+	assert.Error(t, err)
+	if assert.NotNil(t, resp) {
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	}
+
+	// Guests cannot borrow a registered user's name
+	header := wsAuthHeader(t, db, r, "carol")
+	_, resp, err = websocket.DefaultDialer.Dial(wsURL+"?guest=carol", nil)
+	assert.Error(t, err)
+	if assert.NotNil(t, resp) {
+		assert.Equal(t, http.StatusConflict, resp.StatusCode)
+	}
+
+	// Authenticated user spoofing another username or admin is rejected
+	ws, _, err := websocket.DefaultDialer.Dial(wsURL, header)
+	assert.NoError(t, err)
+	defer ws.Close()
+
+	ws.WriteJSON(envelope("join_board", membersOnly, map[string]interface{}{"username": "admin"}))
+	assert.NotNil(t, readUntilType(ws, "error", 2*time.Second), "Spoofed username should be rejected")
+
+	ws.WriteJSON(envelope("join_board", membersOnly, map[string]interface{}{"username": "carol", "is_admin": true}))
+	assert.NotNil(t, readUntilType(ws, "error", 2*time.Second), "Claimed admin flag should be rejected")
+
+	// Username is derived server-side when joining
+	ws.WriteJSON(envelope("join_board", membersOnly, nil))
+	update := readUntilType(ws, "participants_update", 2*time.Second)
+	if assert.NotNil(t, update) {
+		parts := update["payload"].(map[string]interface{})["participants"].([]interface{})
+		assert.Equal(t, "carol", parts[0].(map[string]interface{})["username"])
+	}
+
+	// Guests may only join boards that allow them
+	guest, _, err := websocket.DefaultDialer.Dial(wsURL+"?guest=visitor", nil)
+	assert.NoError(t, err)
+	defer guest.Close()
+
+	guest.WriteJSON(envelope("join_board", membersOnly, nil))
+	assert.NotNil(t, readUntilType(guest, "error", 2*time.Second), "Guest should not join a members-only board")
+
+	guest.WriteJSON(envelope("join_board", guestBoard, nil))
+	update = readUntilType(guest, "participants_update", 2*time.Second)
+	if assert.NotNil(t, update) {
+		parts := update["payload"].(map[string]interface{})["participants"].([]interface{})
+		assert.Equal(t, true, parts[0].(map[string]interface{})["is_guest"])
+	}
+}
+
+func TestWebSocketProtocol(t *testing.T) {
+	db, r := setupWSTest(t)
+
+	boardID := uuid.New()
+	db.Create(&models.Board{ID: boardID, Name: "Protocol Board", Status: "active", AllowGuests: true})
+
+	server := httptest.NewServer(r)
+	defer server.Close()
+	wsURL := "ws" + strings.TrimPrefix(server.URL, "http") + "/ws"
+
+	ws, _, err := websocket.DefaultDialer.Dial(wsURL+"?guest=dana", nil)
+	assert.NoError(t, err)
+	defer ws.Close()
+	peer, _, err := websocket.DefaultDialer.Dial(wsURL+"?guest=eve", nil)
+	assert.NoError(t, err)
+	defer peer.Close()
+
+	errorCode := func(msg interface{}) string {
+		ws.WriteJSON(msg)
+		frame := readUntilType(ws, "error", 2*time.Second)
+		if frame == nil {
+			return ""
+		}
+		code, _ := frame["payload"].(map[string]interface{})["code"].(string)
+		return code
+	}
+
+	// Malformed frames are answered with an error, not dropped
+	assert.Equal(t, "invalid_message", errorCode("not an envelope"))
+	assert.Equal(t, "unsupported_version", errorCode(map[string]interface{}{"v": 99, "type": "join_board"}))
+	assert.Equal(t, "unknown_type", errorCode(envelope("self_destruct", boardID, nil)))
+	assert.Equal(t, "forbidden_type", errorCode(envelope("board_update", boardID, nil)))
+	assert.Equal(t, "invalid_payload", errorCode(envelope("timer_start", boardID, map[string]interface{}{"seconds": "soon"})))
+	assert.Equal(t, "invalid_payload", errorCode(envelope("timer_start", boardID, map[string]interface{}{"seconds": 0})))
+	assert.Equal(t, "invalid_payload", errorCode(envelope("phase_change", boardID, map[string]interface{}{"phase": "party"})))
+
+	// Board messages require joining the board first
+	assert.Equal(t, "not_joined", errorCode(envelope("cursor_move", boardID, map[string]interface{}{"x": 0.5, "y": 0.5})))
+
+	ws.WriteJSON(envelope("join_board", boardID, nil))
+	assert.NotNil(t, readUntilType(ws, "participants_update", 2*time.Second))
+	peer.WriteJSON(envelope("join_board", boardID, nil))
+	assert.NotNil(t, readUntilType(peer, "participants_update", 2*time.Second))
+
+	// Relayed events are rebuilt by the server with the sender's identity
+	ws.WriteJSON(envelope("cursor_move", boardID, map[string]interface{}{"x": 0.25, "y": 0.75, "user": "mallory"}))
+	cursor := readUntilType(peer, "cursor_move", 2*time.Second)
+	if assert.NotNil(t, cursor) {
+		assert.Equal(t, float64(handlers.ProtocolVersion), cursor["v"])
+		assert.Equal(t, boardID.String(), cursor["board_id"])
+		payload := cursor["payload"].(map[string]interface{})
+		assert.Equal(t, "dana", payload["user"])
+		assert.Equal(t, 0.25, payload["x"])
+	}
+
+	// Guests cannot change the phase
+	assert.Equal(t, "forbidden", errorCode(envelope("phase_change", boardID, map[string]interface{}{"phase": "voting"})))
+}
+
+func TestWebSocketResume(t *testing.T) {
+	db, r := setupWSTest(t)
+
+	boardID := uuid.New()
+	db.Create(&models.Board{ID: boardID, Name: "Resume Board", Status: "active", AllowGuests: true})
+
+	server := httptest.NewServer(r)
+	defer server.Close()
+	wsURL := "ws" + strings.TrimPrefix(server.URL, "http") + "/ws"
+
+	// Joining reports the board's current seq
+	ws, _, err := websocket.DefaultDialer.Dial(wsURL+"?guest=frank", nil)
+	assert.NoError(t, err)
+	ws.WriteJSON(envelope("join_board", boardID, nil))
+	synced := readUntilType(ws, "synced", 2*time.Second)
+	if !assert.NotNil(t, synced) {
+		return
+	}
+	lastSeq := uint64(synced["payload"].(map[string]interface{})["seq"].(float64))
+
+	// Board events carry increasing sequence numbers
+	update := readUntilType(ws, "participants_update", 2*time.Second)
+	if assert.NotNil(t, update) {
+		lastSeq = uint64(update["seq"].(float64))
+	}
+	ws.Close()
+
+	// Events broadcast while the client is away
+	time.Sleep(100 * time.Millisecond)
+	handlers.BroadcastBoardUpdate(boardID)
+	handlers.BroadcastVoteUpdate(boardID, uuid.New(), 1, 0, nil)
+
+	// Rejoining with the last seen seq replays what was missed, in order
+	ws, _, err = websocket.DefaultDialer.Dial(wsURL+"?guest=frank", nil)
+	assert.NoError(t, err)
+	defer ws.Close()
+	ws.WriteJSON(envelope("join_board", boardID, map[string]interface{}{"last_seq": lastSeq}))
+
+	var replayed []string
+	ws.SetReadDeadline(time.Now().Add(2 * time.Second))
+	for {
+		_, msg, err := ws.ReadMessage()
+		if !assert.NoError(t, err) {
+			break
+		}
+		var frame map[string]interface{}
+		json.Unmarshal(msg, &frame)
+		if frame["type"] == "synced" {
+			break
+		}
+		seq, _ := frame["seq"].(float64)
+		assert.Equal(t, lastSeq+1, uint64(seq), "replayed events should be contiguous")
+		lastSeq = uint64(seq)
+		replayed = append(replayed, frame["type"].(string))
+	}
+	// The participants_update for frank leaving is replayed along with the board events
+	assert.Equal(t, []string{"participants_update", "board_update", "vote_update"}, replayed)
+
+	// A seq the server never issued cannot be resumed
+	ws.WriteJSON(envelope("resume", boardID, map[string]interface{}{"last_seq": lastSeq + 1000}))
+	resync := readUntilType(ws, "resync_required", 2*time.Second)
+	if assert.NotNil(t, resync) {
+		assert.Equal(t, boardID.String(), resync["board_id"])
+	}
+}
+
+func TestWebSocketDeltaEvents(t *testing.T) {
+	db, r := setupWSTest(t)
+	r.POST("/columns/:columnId/cards", handlers.AuthMiddleware(), handlers.CreateCard)
+	r.PUT("/cards/:id", handlers.AuthMiddleware(), handlers.UpdateCard)
+	r.POST("/cards/:id/merge", handlers.AuthMiddleware(), handlers.MergeCard)
+	r.DELETE("/cards/:id", handlers.AuthMiddleware(), handlers.DeleteCard)
+	r.PUT("/columns/:id", handlers.AuthMiddleware(), handlers.UpdateColumn)
+
+	boardID := uuid.New()
+	columnID := uuid.New()
+	db.Create(&models.Board{ID: boardID, Name: "Delta Board", Status: "active", Owner: "delta"})
+	db.Create(&models.Column{ID: columnID, BoardID: boardID, Name: "Went Well", Position: 0})
+
+	server := httptest.NewServer(r)
+	defer server.Close()
+	wsURL := "ws" + strings.TrimPrefix(server.URL, "http") + "/ws"
+
+	header := wsAuthHeader(t, db, r, "delta")
+	ws, _, err := websocket.DefaultDialer.Dial(wsURL, header)
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer ws.Close()
+	ws.WriteJSON(envelope("join_board", boardID, nil))
+	assert.NotNil(t, readUntilType(ws, "synced", 2*time.Second))
+
+	request := func(method, path string, body interface{}) map[string]interface{} {
+		data, _ := json.Marshal(body)
+		req := httptest.NewRequest(method, path, bytes.NewBuffer(data))
+		req.Header = header.Clone()
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		assert.Less(t, w.Code, 300, w.Body.String())
+		var resp map[string]interface{}
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		return resp
+	}
+	deltaPayload := func(msgType string) map[string]interface{} {
+		frame := readUntilType(ws, msgType, 2*time.Second)
+		if !assert.NotNil(t, frame, msgType) {
+			return map[string]interface{}{}
+		}
+		assert.NotZero(t, frame["seq"])
+		payload := frame["payload"].(map[string]interface{})
+		assert.Equal(t, boardID.String(), payload["board_id"])
+		return payload
+	}
+
+	// Card events carry the card itself
+	created := request("POST", "/columns/"+columnID.String()+"/cards", map[string]interface{}{"content": "Pairing"})
+	payload := deltaPayload("card_created")
+	if card, ok := payload["card"].(map[string]interface{}); assert.True(t, ok) {
+		assert.Equal(t, created["id"], card["id"])
+		assert.Equal(t, "Pairing", card["content"])
+	}
+
+	request("PUT", "/cards/"+created["id"].(string), map[string]interface{}{"content": "Pair programming"})
+	payload = deltaPayload("card_updated")
+	if card, ok := payload["card"].(map[string]interface{}); assert.True(t, ok) {
+		assert.Equal(t, "Pair programming", card["content"])
+	}
+
+	target := request("POST", "/columns/"+columnID.String()+"/cards", map[string]interface{}{"content": "Mobbing"})
+	deltaPayload("card_created")
+	request("POST", "/cards/"+created["id"].(string)+"/merge", map[string]interface{}{"target_card_id": target["id"]})
+	payload = deltaPayload("card_merged")
+	assert.Equal(t, target["id"], payload["target_card_id"])
+
+	request("DELETE", "/cards/"+target["id"].(string), nil)
+	payload = deltaPayload("card_deleted")
+	assert.Equal(t, target["id"], payload["card_id"])
+	assert.Equal(t, columnID.String(), payload["column_id"])
+
+	// Column renames carry the new name
+	request("PUT", "/columns/"+columnID.String(), map[string]interface{}{"name": "Kudos"})
+	payload = deltaPayload("column_renamed")
+	assert.Equal(t, "Kudos", payload["name"])
+}
@@ -0,0 +1,119 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Broker carries hub messages between the pods serving the same boards.
+// Topics are the broadcast topic and one topic per board.
+type Broker interface {
+	// Publish sends a message to every subscriber of the topic, on any pod
+	Publish(ctx context.Context, topic string, message []byte) error
+	// Subscribe calls handler for each message published to the topic until unsubscribed
+	Subscribe(ctx context.Context, topic string, handler func(message []byte)) (Subscription, error)
+	// Close releases the broker's connections
+	Close() error
+}
+
+// Subscription is an active Broker subscription
+type Subscription interface {
+	Unsubscribe() error
+}
+
+// BrokerConfig selects and configures the hub's broker
+type BrokerConfig struct {
+	Backend string // local, redis, redis-streams or nats
+	NATSURL string
+}
+
+// NewBroker creates the broker named by the configuration.
+// The redis backends use the hub's Redis client.
+func NewBroker(config BrokerConfig, client *redis.Client) (Broker, error) {
+	switch config.Backend {
+	case "", "local":
+		return NewMemoryBroker(), nil
+	case "redis":
+		if client == nil {
+			return nil, fmt.Errorf("redis broker requires a Redis connection")
+		}
+		return newRedisPubSubBroker(client), nil
+	case "redis-streams":
+		if client == nil {
+			return nil, fmt.Errorf("redis-streams broker requires a Redis connection")
+		}
+		return newRedisStreamsBroker(client), nil
+	case "nats":
+		return newNATSBroker(config.NATSURL)
+	}
+	return nil, fmt.Errorf("unknown broker %q", config.Backend)
+}
+
+// MemoryBroker delivers messages in process, synchronously and in publish order.
+// It is the broker of single-instance deployments; tests share one between
+// several hubs to simulate pods.
+type MemoryBroker struct {
+	mutex  sync.RWMutex
+	topics map[string]map[*memorySubscription]bool
+}
+
+type memorySubscription struct {
+	broker  *MemoryBroker
+	topic   string
+	handler func(message []byte)
+}
+
+// NewMemoryBroker creates an empty in-process broker
+func NewMemoryBroker() *MemoryBroker {
+	return &MemoryBroker{topics: make(map[string]map[*memorySubscription]bool)}
+}
+
+func (b *MemoryBroker) Publish(_ context.Context, topic string, message []byte) error {
+	b.mutex.RLock()
+	subscriptions := make([]*memorySubscription, 0, len(b.topics[topic]))
+	for sub := range b.topics[topic] {
+		subscriptions = append(subscriptions, sub)
+	}
+	b.mutex.RUnlock()
+
+	// Handlers run outside the lock so they may subscribe or publish themselves
+	for _, sub := range subscriptions {
+		sub.handler(message)
+	}
+	return nil
+}
+
+func (b *MemoryBroker) Subscribe(_ context.Context, topic string, handler func(message []byte)) (Subscription, error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	sub := &memorySubscription{broker: b, topic: topic, handler: handler}
+	if _, ok := b.topics[topic]; !ok {
+		b.topics[topic] = make(map[*memorySubscription]bool)
+	}
+	b.topics[topic][sub] = true
+	return sub, nil
+}
+
+func (b *MemoryBroker) Close() error {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.topics = make(map[string]map[*memorySubscription]bool)
+	return nil
+}
+
+func (s *memorySubscription) Unsubscribe() error {
+	s.broker.mutex.Lock()
+	defer s.broker.mutex.Unlock()
+
+	if subs, ok := s.broker.topics[s.topic]; ok {
+		delete(subs, s)
+		if len(subs) == 0 {
+			delete(s.broker.topics, s.topic)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,37 @@
+package handlers
+
+import (
+	"context"
+
+	"github.com/nats-io/nats.go"
+)
+
+// natsBroker relays messages over NATS core subjects named after the topics
+type natsBroker struct {
+	conn *nats.Conn
+}
+
+func newNATSBroker(url string) (*natsBroker, error) {
+	if url == "" {
+		url = nats.DefaultURL
+	}
+	conn, err := nats.Connect(url, nats.Name("bentro"), nats.MaxReconnects(-1))
+	if err != nil {
+		return nil, err
+	}
+	return &natsBroker{conn: conn}, nil
+}
+
+func (b *natsBroker) Publish(_ context.Context, topic string, message []byte) error {
+	return b.conn.Publish(topic, message)
+}
+
+func (b *natsBroker) Subscribe(_ context.Context, topic string, handler func(message []byte)) (Subscription, error) {
+	return b.conn.Subscribe(topic, func(msg *nats.Msg) {
+		handler(msg.Data)
+	})
+}
+
+func (b *natsBroker) Close() error {
+	return b.conn.Drain()
+}
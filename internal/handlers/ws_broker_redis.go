@@ -0,0 +1,259 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// Entries kept in each Redis Streams topic
+	redisStreamsBrokerMaxLen = 1000
+
+	// Redis Streams topics expire after this much inactivity
+	redisStreamsBrokerTTL = 24 * time.Hour
+
+	// How long a Redis Streams read blocks before picking up new subscriptions
+	redisStreamsBrokerBlock = time.Second
+)
+
+// topicHandlers tracks the handlers subscribed to each topic
+type topicHandlers struct {
+	mutex  sync.RWMutex
+	topics map[string]map[*topicSubscription]bool
+}
+
+type topicSubscription struct {
+	topic    string
+	handler  func(message []byte)
+	onRemove func(sub *topicSubscription) error
+}
+
+func (s *topicSubscription) Unsubscribe() error {
+	return s.onRemove(s)
+}
+
+// add registers a handler and reports whether it is the topic's first one
+func (t *topicHandlers) add(sub *topicSubscription) bool {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	subs, ok := t.topics[sub.topic]
+	if !ok {
+		subs = make(map[*topicSubscription]bool)
+		t.topics[sub.topic] = subs
+	}
+	subs[sub] = true
+	return !ok
+}
+
+// remove unregisters a handler and reports whether the topic has none left
+func (t *topicHandlers) remove(sub *topicSubscription) bool {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	subs, ok := t.topics[sub.topic]
+	if !ok || !subs[sub] {
+		return false
+	}
+	delete(subs, sub)
+	if len(subs) == 0 {
+		delete(t.topics, sub.topic)
+		return true
+	}
+	return false
+}
+
+// dispatch calls the handlers of a topic
+func (t *topicHandlers) dispatch(topic string, message []byte) {
+	t.mutex.RLock()
+	handlers := make([]func([]byte), 0, len(t.topics[topic]))
+	for sub := range t.topics[topic] {
+		handlers = append(handlers, sub.handler)
+	}
+	t.mutex.RUnlock()
+
+	for _, handler := range handlers {
+		handler(message)
+	}
+}
+
+// redisPubSubBroker relays messages over Redis Pub/Sub channels named after the topics.
+// Delivery is at most once: pods that are disconnected miss messages.
+type redisPubSubBroker struct {
+	client   *redis.Client
+	pubsub   *redis.PubSub
+	handlers topicHandlers
+}
+
+func newRedisPubSubBroker(client *redis.Client) *redisPubSubBroker {
+	b := &redisPubSubBroker{
+		client:   client,
+		pubsub:   client.Subscribe(context.Background()),
+		handlers: topicHandlers{topics: make(map[string]map[*topicSubscription]bool)},
+	}
+	go b.receive()
+	return b
+}
+
+func (b *redisPubSubBroker) receive() {
+	for msg := range b.pubsub.Channel() {
+		b.handlers.dispatch(msg.Channel, []byte(msg.Payload))
+	}
+}
+
+func (b *redisPubSubBroker) Publish(ctx context.Context, topic string, message []byte) error {
+	return b.client.Publish(ctx, topic, message).Err()
+}
+
+func (b *redisPubSubBroker) Subscribe(ctx context.Context, topic string, handler func(message []byte)) (Subscription, error) {
+	sub := &topicSubscription{topic: topic, handler: handler}
+	sub.onRemove = func(sub *topicSubscription) error {
+		if b.handlers.remove(sub) {
+			return b.pubsub.Unsubscribe(context.Background(), sub.topic)
+		}
+		return nil
+	}
+
+	if b.handlers.add(sub) {
+		if err := b.pubsub.Subscribe(ctx, topic); err != nil {
+			b.handlers.remove(sub)
+			return nil, err
+		}
+	}
+	return sub, nil
+}
+
+func (b *redisPubSubBroker) Close() error {
+	return b.pubsub.Close()
+}
+
+// redisStreamsBroker appends messages to a capped Redis Stream per topic and
+// reads them back with XREAD. A pod that briefly loses its connection resumes
+// from the last entry it read instead of dropping messages.
+type redisStreamsBroker struct {
+	client   *redis.Client
+	handlers topicHandlers
+	cancel   context.CancelFunc
+
+	mutex   sync.Mutex
+	lastIDs map[string]string // stream -> last entry read
+}
+
+func newRedisStreamsBroker(client *redis.Client) *redisStreamsBroker {
+	ctx, cancel := context.WithCancel(context.Background())
+	b := &redisStreamsBroker{
+		client:   client,
+		handlers: topicHandlers{topics: make(map[string]map[*topicSubscription]bool)},
+		cancel:   cancel,
+		lastIDs:  make(map[string]string),
+	}
+	go b.receive(ctx)
+	return b
+}
+
+func streamForTopic(topic string) string {
+	return topic + ":stream"
+}
+
+func (b *redisStreamsBroker) Publish(ctx context.Context, topic string, message []byte) error {
+	_, err := b.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.XAdd(ctx, &redis.XAddArgs{
+			Stream: streamForTopic(topic),
+			MaxLen: redisStreamsBrokerMaxLen,
+			Approx: true,
+			Values: map[string]interface{}{"message": message},
+		})
+		pipe.Expire(ctx, streamForTopic(topic), redisStreamsBrokerTTL)
+		return nil
+	})
+	return err
+}
+
+func (b *redisStreamsBroker) Subscribe(ctx context.Context, topic string, handler func(message []byte)) (Subscription, error) {
+	sub := &topicSubscription{topic: topic, handler: handler}
+	sub.onRemove = func(sub *topicSubscription) error {
+		if b.handlers.remove(sub) {
+			b.mutex.Lock()
+			delete(b.lastIDs, streamForTopic(sub.topic))
+			b.mutex.Unlock()
+		}
+		return nil
+	}
+
+	if b.handlers.add(sub) {
+		// Start after the newest entry so only messages published from now on are read
+		lastID := "0-0"
+		entries, err := b.client.XRevRangeN(ctx, streamForTopic(topic), "+", "-", 1).Result()
+		if err != nil {
+			b.handlers.remove(sub)
+			return nil, err
+		}
+		if len(entries) > 0 {
+			lastID = entries[0].ID
+		}
+		b.mutex.Lock()
+		b.lastIDs[streamForTopic(topic)] = lastID
+		b.mutex.Unlock()
+	}
+	return sub, nil
+}
+
+func (b *redisStreamsBroker) receive(ctx context.Context) {
+	for ctx.Err() == nil {
+		b.mutex.Lock()
+		streams := make([]string, 0, 2*len(b.lastIDs))
+		ids := make([]string, 0, len(b.lastIDs))
+		for stream, id := range b.lastIDs {
+			streams = append(streams, stream)
+			ids = append(ids, id)
+		}
+		b.mutex.Unlock()
+
+		if len(streams) == 0 {
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(redisStreamsBrokerBlock):
+			}
+			continue
+		}
+
+		results, err := b.client.XRead(ctx, &redis.XReadArgs{
+			Streams: append(streams, ids...),
+			Block:   redisStreamsBrokerBlock,
+			Count:   100,
+		}).Result()
+		if err != nil {
+			if !errors.Is(err, redis.Nil) && ctx.Err() == nil {
+				log.Printf("Redis Streams read error: %v", err)
+				time.Sleep(redisStreamsBrokerBlock)
+			}
+			continue
+		}
+
+		for _, result := range results {
+			topic := strings.TrimSuffix(result.Stream, ":stream")
+			for _, entry := range result.Messages {
+				b.mutex.Lock()
+				if _, subscribed := b.lastIDs[result.Stream]; subscribed {
+					b.lastIDs[result.Stream] = entry.ID
+				}
+				b.mutex.Unlock()
+
+				message, _ := entry.Values["message"].(string)
+				b.handlers.dispatch(topic, []byte(message))
+			}
+		}
+	}
+}
+
+func (b *redisStreamsBroker) Close() error {
+	b.cancel()
+	return nil
+}
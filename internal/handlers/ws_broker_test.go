@@ -0,0 +1,113 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemoryBroker(t *testing.T) {
+	ctx := context.Background()
+	broker := NewMemoryBroker()
+
+	var got []string
+	sub, err := broker.Subscribe(ctx, "topic-a", func(message []byte) {
+		got = append(got, string(message))
+	})
+	assert.NoError(t, err)
+
+	broker.Publish(ctx, "topic-a", []byte("one"))
+	broker.Publish(ctx, "topic-b", []byte("elsewhere"))
+	broker.Publish(ctx, "topic-a", []byte("two"))
+	assert.Equal(t, []string{"one", "two"}, got)
+
+	assert.NoError(t, sub.Unsubscribe())
+	broker.Publish(ctx, "topic-a", []byte("three"))
+	assert.Equal(t, []string{"one", "two"}, got)
+}
+
+func TestNewBroker(t *testing.T) {
+	broker, err := NewBroker(BrokerConfig{Backend: "local"}, nil)
+	assert.NoError(t, err)
+	assert.IsType(t, &MemoryBroker{}, broker)
+
+	_, err = NewBroker(BrokerConfig{Backend: "redis"}, nil)
+	assert.Error(t, err)
+	_, err = NewBroker(BrokerConfig{Backend: "redis-streams"}, nil)
+	assert.Error(t, err)
+	_, err = NewBroker(BrokerConfig{Backend: "carrier-pigeon"}, nil)
+	assert.Error(t, err)
+}
+
+// testClient attaches a connectionless client to a hub
+func testClient(h *Hub, username string) *Client {
+	client := &Client{hub: h, send: make(chan []byte, 64), identity: clientIdentity{Username: username}}
+	h.register <- client
+	return client
+}
+
+// joinTestBoard joins a board and waits until the hub has applied it
+func joinTestBoard(t *testing.T, client *Client, boardID string) {
+	client.hub.joinBoard <- &ParticipantMessage{
+		Type:     MsgJoinBoard,
+		BoardID:  boardID,
+		Username: client.identity.Username,
+		Client:   client,
+	}
+	assert.NotNil(t, nextFrame(client, MsgSynced), "join should be acknowledged")
+}
+
+// nextFrame reads the client's outbound frames until one of the type arrives
+func nextFrame(client *Client, messageType string) *Envelope {
+	timeout := time.After(time.Second)
+	for {
+		select {
+		case data := <-client.send:
+			var env Envelope
+			json.Unmarshal(data, &env)
+			if env.Type == messageType {
+				return &env
+			}
+		case <-timeout:
+			return nil
+		}
+	}
+}
+
+func TestHubsShareEventsThroughBroker(t *testing.T) {
+	// Two pods sharing one broker
+	broker := NewMemoryBroker()
+	podA := NewHub(broker)
+	podB := NewHub(broker)
+	go podA.Run()
+	go podB.Run()
+
+	alice := testClient(podA, "alice")
+	bob := testClient(podB, "bob")
+	carol := testClient(podB, "carol")
+	joinTestBoard(t, alice, "board-1")
+	joinTestBoard(t, bob, "board-1")
+	joinTestBoard(t, carol, "board-2")
+
+	// An event raised on pod A reaches the board's room on pod B
+	podA.broadcast("board-1", MsgBoardUpdate, map[string]interface{}{"board_id": "board-1"})
+	assert.NotNil(t, nextFrame(alice, MsgBoardUpdate))
+	assert.NotNil(t, nextFrame(bob, MsgBoardUpdate))
+	assert.Nil(t, nextFrame(carol, MsgBoardUpdate), "other boards are not affected")
+
+	// Global messages reach every pod
+	podB.broadcast("", "announcement", map[string]interface{}{})
+	assert.NotNil(t, nextFrame(alice, "announcement"))
+	assert.NotNil(t, nextFrame(carol, "announcement"))
+
+	// Once its last viewer leaves, pod B stops listening to the board
+	podB.leaveBoard <- &ParticipantMessage{Type: MsgLeaveBoard, BoardID: "board-1", Username: "bob", Client: bob}
+	assert.Eventually(t, func() bool {
+		broker.mutex.RLock()
+		defer broker.mutex.RUnlock()
+		return len(broker.topics[boardTopic("board-1")]) == 1
+	}, time.Second, 10*time.Millisecond)
+}
@@ -0,0 +1,143 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/bento-lab-ops/bentro/internal/models"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// How often a pod refreshes the presence of its local participants
+	presenceHeartbeat = 15 * time.Second
+
+	// Presence entries not refreshed within this window belong to a dead pod
+	presenceTTL = 3 * presenceHeartbeat
+)
+
+// presenceEntry is the value stored for one participant on one pod
+type presenceEntry struct {
+	Participant models.Participant `json:"participant"`
+	ExpiresAt   int64              `json:"expires_at"` // Unix seconds
+}
+
+// clusterPresence shares board participants between pods through Redis.
+// Each board has a hash (bentro:board:<id>:presence) with one field per
+// pod and username; pods refresh their fields on a heartbeat and readers
+// drop the fields of pods that stopped refreshing them.
+type clusterPresence struct {
+	client *redis.Client
+	podID  string
+}
+
+func newClusterPresence(client *redis.Client) *clusterPresence {
+	host, _ := os.Hostname()
+	return &clusterPresence{client: client, podID: host + "-" + uuid.NewString()[:8]}
+}
+
+func presenceKey(boardID string) string {
+	return redisBoardKeyPrefix + boardID + ":presence"
+}
+
+func (p *clusterPresence) field(username string) string {
+	return p.podID + "|" + username
+}
+
+// Set records (or refreshes) this pod's participants on a board
+func (p *clusterPresence) Set(ctx context.Context, boardID string, participants ...models.Participant) error {
+	if len(participants) == 0 {
+		return nil
+	}
+	expiresAt := time.Now().Add(presenceTTL).Unix()
+	values := make(map[string]interface{}, len(participants))
+	for _, participant := range participants {
+		data, err := json.Marshal(presenceEntry{Participant: participant, ExpiresAt: expiresAt})
+		if err != nil {
+			return err
+		}
+		values[p.field(participant.Username)] = data
+	}
+
+	_, err := p.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.HSet(ctx, presenceKey(boardID), values)
+		pipe.Expire(ctx, presenceKey(boardID), presenceTTL)
+		return nil
+	})
+	return err
+}
+
+// Remove drops a participant this pod recorded on a board
+func (p *clusterPresence) Remove(ctx context.Context, boardID, username string) error {
+	return p.client.HDel(ctx, presenceKey(boardID), p.field(username)).Err()
+}
+
+// List returns the participants of a board across all pods. It also deletes
+// expired entries and reports whether any were removed by this call.
+func (p *clusterPresence) List(ctx context.Context, boardID string) ([]models.Participant, bool, error) {
+	entries, err := p.client.HGetAll(ctx, presenceKey(boardID)).Result()
+	if err != nil {
+		return nil, false, err
+	}
+
+	participants, expired := mergePresence(entries, time.Now())
+	if len(expired) == 0 {
+		return participants, false, nil
+	}
+	removed, err := p.client.HDel(ctx, presenceKey(boardID), expired...).Result()
+	if err != nil {
+		return participants, false, err
+	}
+	// HDEL is atomic, so only one pod sees a given entry removed
+	return participants, removed > 0, nil
+}
+
+// mergePresence builds the cluster view of a board's presence hash.
+// A user connected through several pods is listed once. It also returns the
+// fields whose heartbeat has lapsed.
+func mergePresence(entries map[string]string, now time.Time) ([]models.Participant, []string) {
+	byUsername := make(map[string]models.Participant)
+	var expired []string
+
+	for field, value := range entries {
+		var entry presenceEntry
+		if err := json.Unmarshal([]byte(value), &entry); err != nil || entry.ExpiresAt <= now.Unix() {
+			expired = append(expired, field)
+			continue
+		}
+
+		participant := entry.Participant
+		if participant.Username == "" {
+			// Fall back to the field name (<pod>|<username>)
+			if i := strings.Index(field, "|"); i >= 0 {
+				participant.Username = field[i+1:]
+			}
+		}
+		if existing, ok := byUsername[participant.Username]; ok {
+			// Keep the earliest join and any elevated flag
+			if !existing.JoinedAt.IsZero() && (participant.JoinedAt.IsZero() || existing.JoinedAt.Before(participant.JoinedAt)) {
+				participant.JoinedAt = existing.JoinedAt
+			}
+			participant.IsAdmin = participant.IsAdmin || existing.IsAdmin
+			if participant.Avatar == "" {
+				participant.Avatar = existing.Avatar
+			}
+		}
+		byUsername[participant.Username] = participant
+	}
+
+	participants := make([]models.Participant, 0, len(byUsername))
+	for _, participant := range byUsername {
+		participants = append(participants, participant)
+	}
+	sort.Slice(participants, func(i, j int) bool {
+		return participants[i].Username < participants[j].Username
+	})
+	return participants, expired
+}
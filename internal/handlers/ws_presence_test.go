@@ -0,0 +1,49 @@
+package handlers
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/bento-lab-ops/bentro/internal/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func presenceValue(p models.Participant, expiresAt time.Time) string {
+	data, _ := json.Marshal(presenceEntry{Participant: p, ExpiresAt: expiresAt.Unix()})
+	return string(data)
+}
+
+func TestMergePresence(t *testing.T) {
+	now := time.Now()
+	alive := now.Add(presenceTTL)
+	early := now.Add(-time.Hour)
+
+	entries := map[string]string{
+		// Bob is connected through two pods
+		"pod-a|bob":   presenceValue(models.Participant{Username: "bob", JoinedAt: now}, alive),
+		"pod-b|bob":   presenceValue(models.Participant{Username: "bob", Avatar: "bob.png", JoinedAt: early}, alive),
+		"pod-a|alice": presenceValue(models.Participant{Username: "alice", IsAdmin: true}, alive),
+		// Carol's pod stopped heartbeating
+		"pod-c|carol": presenceValue(models.Participant{Username: "carol"}, now.Add(-time.Second)),
+		"pod-c|junk":  "not json",
+	}
+
+	participants, expired := mergePresence(entries, now)
+
+	assert.ElementsMatch(t, []string{"pod-c|carol", "pod-c|junk"}, expired)
+	if assert.Len(t, participants, 2) {
+		// Sorted by username, one entry per user
+		assert.Equal(t, "alice", participants[0].Username)
+		assert.True(t, participants[0].IsAdmin)
+
+		assert.Equal(t, "bob", participants[1].Username)
+		assert.Equal(t, "bob.png", participants[1].Avatar)
+		assert.True(t, participants[1].JoinedAt.Equal(early.Truncate(0)), "earliest join is kept")
+	}
+
+	participants, expired = mergePresence(map[string]string{}, now)
+	assert.Empty(t, participants)
+	assert.Empty(t, expired)
+}
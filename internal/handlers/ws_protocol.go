@@ -0,0 +1,282 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// ProtocolVersion is the version of the WebSocket envelope understood by the server
+const ProtocolVersion = 1
+
+// Message types clients may send
+const (
+	MsgJoinBoard   = "join_board"
+	MsgLeaveBoard  = "leave_board"
+	MsgPhaseChange = "phase_change"
+	MsgTimerStart  = "timer_start"
+	MsgTimerStop   = "timer_stop"
+	MsgCursorMove  = "cursor_move"
+	MsgResume      = "resume"
+)
+
+// Message types only the server may send
+const (
+	MsgBoardUpdate        = "board_update"
+	MsgBoardListChanged   = "board_list_changed"
+	MsgVoteUpdate         = "vote_update"
+	MsgCardMove           = "card_move"
+	MsgParticipantsUpdate = "participants_update"
+	MsgError              = "error"
+	MsgSynced             = "synced"
+	MsgResyncRequired     = "resync_required"
+)
+
+// Delta events describing a single change to a board and carrying the changed entity,
+// so clients can patch their copy instead of refetching the whole board
+const (
+	MsgCardCreated          = "card_created"
+	MsgCardUpdated          = "card_updated"
+	MsgCardDeleted          = "card_deleted"
+	MsgCardMerged           = "card_merged"
+	MsgColumnRenamed        = "column_renamed"
+	MsgColumnReordered      = "column_reordered"
+	MsgColumnVoteLimit      = "column_vote_limit_changed"
+	MsgBoardSettingsChanged = "board_settings_changed"
+	MsgReactionToggled      = "reaction_toggled"
+	MsgPhaseChanged         = "phase_changed"
+	MsgTimerUpdated         = "timer_updated"
+	MsgCardsRevealed        = "cards_revealed"
+	MsgResultsRevealed      = "results_revealed"
+	MsgSurveyUpdated        = "survey_updated"
+	MsgCommentCreated       = "comment_created"
+	MsgCommentUpdated       = "comment_updated"
+	MsgCommentDeleted       = "comment_deleted"
+	MsgLabelCreated         = "label_created"
+	MsgLabelUpdated         = "label_updated"
+	MsgLabelDeleted         = "label_deleted"
+	MsgCardLabeled          = "card_labeled"
+	MsgCardUnlabeled        = "card_unlabeled"
+	MsgGroupCreated         = "group_created"
+	MsgGroupUpdated         = "group_updated"
+	MsgGroupMoved           = "group_moved"
+	MsgGroupDeleted         = "group_deleted"
+)
+
+// ephemeralMessageTypes are delivered live only: they carry no sequence number
+// and are not kept for replay.
+var ephemeralMessageTypes = map[string]bool{
+	MsgCursorMove: true,
+}
+
+// Error codes carried by error frames
+const (
+	ErrCodeInvalidMessage     = "invalid_message"
+	ErrCodeUnsupportedVersion = "unsupported_version"
+	ErrCodeUnknownType        = "unknown_type"
+	ErrCodeForbiddenType      = "forbidden_type"
+	ErrCodeInvalidPayload     = "invalid_payload"
+	ErrCodeNotJoined          = "not_joined"
+	ErrCodeForbidden          = "forbidden"
+	ErrCodeInvalidTransition  = "invalid_transition"
+	ErrCodeInternal           = "internal_error"
+)
+
+// Envelope is the frame exchanged in both directions over the WebSocket.
+// Seq numbers the events of a board; it is omitted on ephemeral and per-client frames.
+type Envelope struct {
+	Version int             `json:"v"`
+	Type    string          `json:"type"`
+	BoardID string          `json:"board_id,omitempty"`
+	Seq     uint64          `json:"seq,omitempty"`
+	Payload json.RawMessage `json:"payload,omitempty"`
+}
+
+// ProtocolError is reported to the sender as an error frame
+type ProtocolError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *ProtocolError) Error() string {
+	return e.Code + ": " + e.Message
+}
+
+func protocolError(code, format string, args ...interface{}) *ProtocolError {
+	return &ProtocolError{Code: code, Message: fmt.Sprintf(format, args...)}
+}
+
+// ClientPayload is the typed body of a client message
+type ClientPayload interface {
+	Validate() error
+}
+
+// JoinBoardPayload enters the board room. Username and IsAdmin are optional
+// claims checked against the handshake identity. A client rejoining after a
+// reconnect sends the last seq it saw to receive the events it missed.
+type JoinBoardPayload struct {
+	Username string  `json:"username"`
+	Avatar   string  `json:"avatar"`
+	IsAdmin  bool    `json:"is_admin"`
+	LastSeq  *uint64 `json:"last_seq,omitempty"`
+}
+
+func (p *JoinBoardPayload) Validate() error {
+	if len(p.Avatar) > 512 {
+		return fmt.Errorf("avatar is too long")
+	}
+	return nil
+}
+
+// LeaveBoardPayload leaves the board room
+type LeaveBoardPayload struct {
+	Username string `json:"username"`
+}
+
+func (p *LeaveBoardPayload) Validate() error { return nil }
+
+// PhaseChangePayload moves the board to another phase, following the same
+// transitions as the REST endpoint
+type PhaseChangePayload struct {
+	Phase string `json:"phase"`
+}
+
+func (p *PhaseChangePayload) Validate() error {
+	if !IsValidPhase(p.Phase) {
+		return fmt.Errorf("unknown phase %q", p.Phase)
+	}
+	return nil
+}
+
+// ResumePayload asks for the events of the joined board after LastSeq,
+// e.g. when the client notices a gap in the sequence numbers.
+type ResumePayload struct {
+	LastSeq uint64 `json:"last_seq"`
+}
+
+func (p *ResumePayload) Validate() error { return nil }
+
+// TimerStartPayload starts the board timer
+type TimerStartPayload struct {
+	Seconds int `json:"seconds"`
+}
+
+func (p *TimerStartPayload) Validate() error {
+	if !validTimerSeconds(p.Seconds) {
+		return ErrTimerSeconds
+	}
+	return nil
+}
+
+// TimerStopPayload stops the board timer
+type TimerStopPayload struct{}
+
+func (p *TimerStopPayload) Validate() error { return nil }
+
+// CursorMovePayload shares the sender's pointer position, relative to the board (0-1)
+type CursorMovePayload struct {
+	X float64 `json:"x"`
+	Y float64 `json:"y"`
+}
+
+func (p *CursorMovePayload) Validate() error {
+	if p.X < 0 || p.X > 1 || p.Y < 0 || p.Y > 1 {
+		return fmt.Errorf("coordinates must be between 0 and 1")
+	}
+	return nil
+}
+
+// clientMessageTypes maps each accepted client message type to its payload
+var clientMessageTypes = map[string]func() ClientPayload{
+	MsgJoinBoard:   func() ClientPayload { return &JoinBoardPayload{} },
+	MsgLeaveBoard:  func() ClientPayload { return &LeaveBoardPayload{} },
+	MsgPhaseChange: func() ClientPayload { return &PhaseChangePayload{} },
+	MsgTimerStart:  func() ClientPayload { return &TimerStartPayload{} },
+	MsgTimerStop:   func() ClientPayload { return &TimerStopPayload{} },
+	MsgCursorMove:  func() ClientPayload { return &CursorMovePayload{} },
+	MsgResume:      func() ClientPayload { return &ResumePayload{} },
+}
+
+// serverMessageTypes are rejected when a client tries to send them
+var serverMessageTypes = map[string]bool{
+	MsgBoardUpdate:        true,
+	MsgBoardListChanged:   true,
+	MsgVoteUpdate:         true,
+	MsgCardMove:           true,
+	MsgParticipantsUpdate: true,
+	MsgError:              true,
+	MsgSynced:             true,
+	MsgResyncRequired:     true,
+
+	MsgCardCreated:          true,
+	MsgCardUpdated:          true,
+	MsgCardDeleted:          true,
+	MsgCardMerged:           true,
+	MsgColumnRenamed:        true,
+	MsgColumnReordered:      true,
+	MsgColumnVoteLimit:      true,
+	MsgBoardSettingsChanged: true,
+	MsgReactionToggled:      true,
+	MsgPhaseChanged:         true,
+	MsgTimerUpdated:         true,
+	MsgCardsRevealed:        true,
+	MsgResultsRevealed:      true,
+	MsgSurveyUpdated:        true,
+	MsgCommentCreated:       true,
+	MsgCommentUpdated:       true,
+	MsgCommentDeleted:       true,
+	MsgLabelCreated:         true,
+	MsgLabelUpdated:         true,
+	MsgLabelDeleted:         true,
+	MsgCardLabeled:          true,
+	MsgCardUnlabeled:        true,
+	MsgGroupCreated:         true,
+	MsgGroupUpdated:         true,
+	MsgGroupMoved:           true,
+	MsgGroupDeleted:         true,
+}
+
+// decodeEnvelope parses a client frame and its typed payload.
+// The returned envelope is usable for error reporting even when decoding fails.
+func decodeEnvelope(data []byte) (Envelope, ClientPayload, *ProtocolError) {
+	var env Envelope
+	if err := json.Unmarshal(data, &env); err != nil {
+		return env, nil, protocolError(ErrCodeInvalidMessage, "message is not a valid envelope")
+	}
+	if env.Version != ProtocolVersion {
+		return env, nil, protocolError(ErrCodeUnsupportedVersion, "protocol version %d is not supported", env.Version)
+	}
+	if serverMessageTypes[env.Type] {
+		return env, nil, protocolError(ErrCodeForbiddenType, "%q messages can only be sent by the server", env.Type)
+	}
+
+	newPayload, ok := clientMessageTypes[env.Type]
+	if !ok {
+		return env, nil, protocolError(ErrCodeUnknownType, "unknown message type %q", env.Type)
+	}
+
+	payload := newPayload()
+	if len(env.Payload) > 0 {
+		if err := json.Unmarshal(env.Payload, payload); err != nil {
+			return env, nil, protocolError(ErrCodeInvalidPayload, "invalid %s payload", env.Type)
+		}
+	}
+	if err := payload.Validate(); err != nil {
+		return env, nil, protocolError(ErrCodeInvalidPayload, "%v", err)
+	}
+	return env, payload, nil
+}
+
+// encodeEnvelope builds an outbound frame around a payload. A zero seq is omitted.
+func encodeEnvelope(messageType, boardID string, seq uint64, payload interface{}) ([]byte, error) {
+	raw, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(Envelope{
+		Version: ProtocolVersion,
+		Type:    messageType,
+		BoardID: boardID,
+		Seq:     seq,
+		Payload: raw,
+	})
+}
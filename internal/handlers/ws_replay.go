@@ -0,0 +1,228 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// Default number of events kept per board for reconnect replay
+	defaultEventLogSize = 500
+
+	// Largest gap replayed over the socket; bigger gaps ask the client to resync
+	// rather than risk overflowing its send buffer.
+	maxReplayFrames = 128
+
+	// Prefix of the Redis keys holding board state (bentro:board:<id>:<kind>)
+	redisBoardKeyPrefix = "bentro:board:"
+
+	// Redis keys of a board's event log expire after this much inactivity
+	redisEventLogTTL = 24 * time.Hour
+)
+
+// ErrResyncRequired means the events after a sequence number are no longer
+// available and the client must reload the board.
+var ErrResyncRequired = errors.New("events are no longer available, resync required")
+
+// EventLog assigns per-board sequence numbers and keeps a bounded history of
+// sequenced frames for clients resuming after a reconnect.
+type EventLog interface {
+	// Append assigns the board's next sequence number and records the frame built for it
+	Append(ctx context.Context, boardID string, build func(seq uint64) ([]byte, error)) ([]byte, error)
+	// Since returns the frames after a sequence number, oldest first, and the latest sequence number.
+	// It returns ErrResyncRequired when part of the range has been discarded.
+	Since(ctx context.Context, boardID string, after uint64) ([][]byte, uint64, error)
+	// Latest returns the board's last assigned sequence number
+	Latest(ctx context.Context, boardID string) (uint64, error)
+}
+
+// NewEventLog selects the event log backend.
+// "redis" keeps events in Redis Streams so sequence numbers are shared by every pod;
+// "memory" is only consistent for a single instance.
+func NewEventLog(backend string, client *redis.Client, size int) (EventLog, error) {
+	if size <= 0 {
+		size = defaultEventLogSize
+	}
+	switch backend {
+	case "memory":
+		return newMemoryEventLog(size), nil
+	case "redis":
+		if client == nil {
+			return nil, fmt.Errorf("redis event log requires a Redis connection")
+		}
+		return &redisEventLog{client: client, size: int64(size)}, nil
+	}
+	return nil, fmt.Errorf("unknown event log backend %q", backend)
+}
+
+// memoryEventLog keeps the last events of each board in process memory
+type memoryEventLog struct {
+	size   int
+	mutex  sync.Mutex
+	boards map[string]*boardEvents
+}
+
+type boardEvents struct {
+	seq    uint64   // Last assigned sequence number
+	frames [][]byte // Frames for seq-len(frames)+1 .. seq
+}
+
+func newMemoryEventLog(size int) *memoryEventLog {
+	return &memoryEventLog{size: size, boards: make(map[string]*boardEvents)}
+}
+
+func (l *memoryEventLog) Append(_ context.Context, boardID string, build func(seq uint64) ([]byte, error)) ([]byte, error) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	events, ok := l.boards[boardID]
+	if !ok {
+		events = &boardEvents{}
+		l.boards[boardID] = events
+	}
+
+	frame, err := build(events.seq + 1)
+	if err != nil {
+		return nil, err
+	}
+	events.seq++
+	events.frames = append(events.frames, frame)
+	if len(events.frames) > l.size {
+		events.frames = events.frames[len(events.frames)-l.size:]
+	}
+	return frame, nil
+}
+
+func (l *memoryEventLog) Since(_ context.Context, boardID string, after uint64) ([][]byte, uint64, error) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	events, ok := l.boards[boardID]
+	if !ok {
+		if after > 0 {
+			return nil, 0, ErrResyncRequired
+		}
+		return nil, 0, nil
+	}
+	if after > events.seq {
+		// The client saw a sequence this log never issued (e.g. before a restart)
+		return nil, events.seq, ErrResyncRequired
+	}
+
+	first := events.seq - uint64(len(events.frames)) + 1
+	if after+1 < first {
+		return nil, events.seq, ErrResyncRequired
+	}
+
+	missed := events.frames[after+1-first:]
+	frames := make([][]byte, len(missed))
+	copy(frames, missed)
+	return frames, events.seq, nil
+}
+
+func (l *memoryEventLog) Latest(_ context.Context, boardID string) (uint64, error) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	if events, ok := l.boards[boardID]; ok {
+		return events.seq, nil
+	}
+	return 0, nil
+}
+
+// redisEventLog numbers events with INCR and keeps them in a capped Redis Stream
+type redisEventLog struct {
+	client *redis.Client
+	size   int64
+}
+
+func redisSeqKey(boardID string) string {
+	return redisBoardKeyPrefix + boardID + ":seq"
+}
+
+func redisStreamKey(boardID string) string {
+	return redisBoardKeyPrefix + boardID + ":events"
+}
+
+func (l *redisEventLog) Append(ctx context.Context, boardID string, build func(seq uint64) ([]byte, error)) ([]byte, error) {
+	seq, err := l.client.Incr(ctx, redisSeqKey(boardID)).Uint64()
+	if err != nil {
+		return nil, err
+	}
+
+	frame, err := build(seq)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = l.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.XAdd(ctx, &redis.XAddArgs{
+			Stream: redisStreamKey(boardID),
+			MaxLen: l.size,
+			Approx: true,
+			Values: map[string]interface{}{"seq": seq, "frame": frame},
+		})
+		pipe.Expire(ctx, redisSeqKey(boardID), redisEventLogTTL)
+		pipe.Expire(ctx, redisStreamKey(boardID), redisEventLogTTL)
+		return nil
+	})
+	if err != nil {
+		// The event is still delivered live; resuming clients will be asked to resync
+		return frame, err
+	}
+	return frame, nil
+}
+
+func (l *redisEventLog) Since(ctx context.Context, boardID string, after uint64) ([][]byte, uint64, error) {
+	latest, err := l.Latest(ctx, boardID)
+	if err != nil {
+		return nil, 0, err
+	}
+	if after == latest {
+		return nil, latest, nil
+	}
+	if after > latest || latest-after > uint64(l.size) {
+		return nil, latest, ErrResyncRequired
+	}
+
+	entries, err := l.client.XRange(ctx, redisStreamKey(boardID), "-", "+").Result()
+	if err != nil {
+		return nil, latest, err
+	}
+
+	// Concurrent appends may land in the stream slightly out of order, so index by seq
+	bySeq := make(map[uint64][]byte, len(entries))
+	for _, entry := range entries {
+		seqValue, _ := entry.Values["seq"].(string)
+		frame, _ := entry.Values["frame"].(string)
+		seq, err := strconv.ParseUint(seqValue, 10, 64)
+		if err != nil {
+			continue
+		}
+		bySeq[seq] = []byte(frame)
+	}
+
+	frames := make([][]byte, 0, latest-after)
+	for seq := after + 1; seq <= latest; seq++ {
+		frame, ok := bySeq[seq]
+		if !ok {
+			return nil, latest, ErrResyncRequired
+		}
+		frames = append(frames, frame)
+	}
+	return frames, latest, nil
+}
+
+func (l *redisEventLog) Latest(ctx context.Context, boardID string) (uint64, error) {
+	seq, err := l.client.Get(ctx, redisSeqKey(boardID)).Uint64()
+	if errors.Is(err, redis.Nil) {
+		return 0, nil
+	}
+	return seq, err
+}
@@ -0,0 +1,75 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func appendEvents(t *testing.T, log EventLog, boardID string, n int) {
+	for i := 0; i < n; i++ {
+		_, err := log.Append(context.Background(), boardID, func(seq uint64) ([]byte, error) {
+			return []byte(fmt.Sprintf("event-%d", seq)), nil
+		})
+		assert.NoError(t, err)
+	}
+}
+
+func TestMemoryEventLog(t *testing.T) {
+	ctx := context.Background()
+	log, err := NewEventLog("memory", nil, 3)
+	assert.NoError(t, err)
+
+	// Unknown boards start at zero
+	latest, _ := log.Latest(ctx, "board-a")
+	assert.Equal(t, uint64(0), latest)
+	frames, latest, err := log.Since(ctx, "board-a", 0)
+	assert.NoError(t, err)
+	assert.Empty(t, frames)
+	assert.Equal(t, uint64(0), latest)
+
+	// Sequences are per board
+	appendEvents(t, log, "board-a", 2)
+	appendEvents(t, log, "board-b", 1)
+	latest, _ = log.Latest(ctx, "board-a")
+	assert.Equal(t, uint64(2), latest)
+	latest, _ = log.Latest(ctx, "board-b")
+	assert.Equal(t, uint64(1), latest)
+
+	// Missed events are returned oldest first
+	frames, latest, err = log.Since(ctx, "board-a", 0)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(2), latest)
+	assert.Equal(t, [][]byte{[]byte("event-1"), []byte("event-2")}, frames)
+
+	// Up to date clients get nothing
+	frames, _, err = log.Since(ctx, "board-a", 2)
+	assert.NoError(t, err)
+	assert.Empty(t, frames)
+
+	// Only the last 3 events are kept
+	appendEvents(t, log, "board-a", 3)
+	frames, latest, err = log.Since(ctx, "board-a", 2)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(5), latest)
+	assert.Equal(t, [][]byte{[]byte("event-3"), []byte("event-4"), []byte("event-5")}, frames)
+
+	_, _, err = log.Since(ctx, "board-a", 1)
+	assert.ErrorIs(t, err, ErrResyncRequired)
+
+	// A seq the log never issued (e.g. from before a restart) needs a resync
+	_, _, err = log.Since(ctx, "board-a", 42)
+	assert.ErrorIs(t, err, ErrResyncRequired)
+	_, _, err = log.Since(ctx, "board-c", 1)
+	assert.ErrorIs(t, err, ErrResyncRequired)
+}
+
+func TestNewEventLog(t *testing.T) {
+	_, err := NewEventLog("redis", nil, 0)
+	assert.Error(t, err)
+
+	_, err = NewEventLog("kafka", nil, 0)
+	assert.Error(t, err)
+}
@@ -1,6 +1,7 @@
 package models
 
 import (
+	"encoding/json"
 	"time"
 
 	"github.com/google/uuid"
@@ -12,6 +13,7 @@ type Participant struct {
 	Username string    `json:"username"`
 	Avatar   string    `json:"avatar"`
 	IsAdmin  bool      `json:"is_admin,omitempty"`
+	IsGuest  bool      `json:"is_guest,omitempty"`
 	JoinedAt time.Time `json:"joined_at"`
 }
 
@@ -45,6 +47,7 @@ type BoardMember struct {
 	Username string    `gorm:"type:text;primaryKey" json:"username"`
 	Avatar   string    `json:"avatar"`
 	JoinedAt time.Time `json:"joined_at"`
+	GuestKey string    `gorm:"type:text;default:''" json:"-"` // Hash of the guest token that joined under this name, empty for users
 }
 
 // Board represents a retrospective board
@@ -57,16 +60,43 @@ type Board struct {
 	FinishedAt *time.Time `json:"finished_at"` // Pointer to allow null (active)
 	Columns    []Column   `gorm:"foreignKey:BoardID;constraint:OnDelete:CASCADE" json:"columns,omitempty"`
 	// Participants is now computed from BoardMembers for JSON response, not stored as JSONB
-	Participants []Participant  `gorm:"-" json:"participants"`
-	Members      []BoardMember  `gorm:"foreignKey:BoardID;constraint:OnDelete:CASCADE" json:"-"`
-	Owner        string         `json:"owner"`                        // Username of board owner/manager
-	CoOwner      string         `json:"co_owner"`                     // Username of second board manager
-	Phase        string         `gorm:"default:'input'" json:"phase"` // input, voting, discuss
-	VoteLimit    int            `gorm:"default:0" json:"vote_limit"`  // 0 = unlimited
-	BlindVoting  bool           `gorm:"default:false" json:"blind_voting"`
-	TeamID       *uuid.UUID     `gorm:"type:uuid;index" json:"team_id,omitempty"` // Deprecated: Use Teams instead
-	Teams        []Team         `gorm:"many2many:board_teams;" json:"teams,omitempty"`
-	DeletedAt    gorm.DeletedAt `gorm:"index" json:"-"`
+	Participants    []Participant  `gorm:"-" json:"participants"`
+	Members         []BoardMember  `gorm:"foreignKey:BoardID;constraint:OnDelete:CASCADE" json:"-"`
+	Owner           string         `json:"owner"`                        // Username of board owner/manager
+	CoOwner         string         `json:"co_owner"`                     // Username of second board manager
+	Phase           string         `gorm:"default:'input'" json:"phase"` // check-in, input, grouping, voting, discuss, action-planning, closed
+	PhaseChangedAt  *time.Time     `json:"phase_changed_at,omitempty"`
+	PhaseChangedBy  string         `json:"phase_changed_by,omitempty"`                         // Manager who made the last phase change
+	VoteLimit       int            `gorm:"default:0" json:"vote_limit"`                        // 0 = unlimited; dots per person on dot voting boards
+	VotingMode      string         `gorm:"default:'like'" json:"voting_mode"`                  // like, dot, ranked, points
+	VotingClosedAt  *time.Time     `json:"voting_closed_at,omitempty"`                         // Votes are frozen until the board is back in voting
+	Results         *VoteResults   `gorm:"serializer:json;type:text" json:"results,omitempty"` // Snapshot taken when voting was last closed
+	SurveyQuestion  string         `json:"survey_question"`                                    // Closing survey question; empty asks the default
+	SurveyScale     int            `gorm:"default:5" json:"survey_scale"`                      // Ratings run from 1 to the scale
+	SurveyComments  bool           `gorm:"default:true" json:"survey_comments"`                // Answers may carry a comment
+	SurveyOpenedAt  *time.Time     `json:"survey_opened_at,omitempty"`                         // Set when the board is first finished
+	Survey          *SurveyResults `gorm:"serializer:json;type:text" json:"survey,omitempty"`  // Anonymous tally of the answers
+	BlindVoting     bool           `gorm:"default:false" json:"blind_voting"`
+	AllowGuests     bool           `gorm:"default:false" json:"allow_guests"`        // Unauthenticated WebSocket guests may join
+	Anonymity       string         `gorm:"default:'anonymous'" json:"anonymity"`     // anonymous, until_reveal, named
+	AuthorsRevealed bool           `gorm:"default:false" json:"authors_revealed"`    // Card authors shown on until_reveal boards
+	PrivateWriting  bool           `gorm:"default:false" json:"private_writing"`     // Cards are only visible to their author until revealed
+	CardsRevealedAt *time.Time     `json:"cards_revealed_at,omitempty"`              // When a manager revealed a private writing board
+	TeamID          *uuid.UUID     `gorm:"type:uuid;index" json:"team_id,omitempty"` // Deprecated: Use Teams instead
+	Teams           []Team         `gorm:"many2many:board_teams;" json:"teams,omitempty"`
+	Timer           BoardTimer     `gorm:"embedded;embeddedPrefix:timer_" json:"timer"`
+	Labels          []Label        `gorm:"-" json:"labels,omitempty"` // Palette of the board and its teams, loaded with the board
+	DeletedAt       gorm.DeletedAt `gorm:"index" json:"-"`
+}
+
+// BoardTimer is the shared countdown of a board, stored in the timer_* columns
+type BoardTimer struct {
+	State       string     `gorm:"default:'idle';index" json:"state"` // idle, running, paused, expired
+	Duration    int        `gorm:"default:0" json:"duration"`         // Seconds the countdown was set to, including extensions
+	Remaining   int        `gorm:"default:0" json:"remaining"`        // Seconds left when paused
+	EndsAt      *time.Time `json:"ends_at,omitempty"`                 // When a running countdown expires
+	AutoAdvance bool       `gorm:"default:false" json:"auto_advance"` // Move to the next phase on expiry
+	UpdatedBy   string     `json:"updated_by,omitempty"`
 }
 
 // BeforeCreate hook to generate UUID
@@ -83,8 +113,10 @@ type Column struct {
 	BoardID   uuid.UUID      `gorm:"type:uuid;not null" json:"board_id"`
 	Name      string         `gorm:"not null" json:"name"`
 	Position  int            `gorm:"not null" json:"position"`
+	VoteLimit int            `gorm:"default:0" json:"vote_limit"` // Dots per person in this column on dot voting boards, 0 = only the board's budget
 	CreatedAt time.Time      `json:"created_at"`
 	Cards     []Card         `gorm:"foreignKey:ColumnID;constraint:OnDelete:CASCADE" json:"cards,omitempty"`
+	Groups    []CardGroup    `gorm:"foreignKey:ColumnID;constraint:OnDelete:CASCADE" json:"groups,omitempty"`
 	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
 }
 
@@ -102,12 +134,15 @@ type Card struct {
 	ColumnID       uuid.UUID      `gorm:"type:uuid;not null" json:"column_id"`
 	Content        string         `gorm:"type:text;not null" json:"content"`
 	Position       int            `gorm:"not null" json:"position"`
-	MergedWithID   *uuid.UUID     `gorm:"type:uuid" json:"merged_with_id,omitempty"`
+	Author         string         `gorm:"index" json:"author,omitempty"` // Authenticated creator; hidden on anonymous boards
+	Hidden         bool           `gorm:"-" json:"hidden,omitempty"`     // Content withheld until a private writing board is revealed
+	GroupID        *uuid.UUID     `gorm:"type:uuid;index" json:"group_id,omitempty"`
 	CreatedAt      time.Time      `json:"created_at"`
 	UpdatedAt      time.Time      `json:"updated_at"`
 	Votes          []Vote         `gorm:"foreignKey:CardID;constraint:OnDelete:CASCADE" json:"votes,omitempty"`
 	Reactions      []Reaction     `gorm:"foreignKey:CardID;constraint:OnDelete:CASCADE" json:"reactions,omitempty"`
-	MergedCards    []Card         `gorm:"foreignKey:MergedWithID;constraint:OnDelete:SET NULL" json:"merged_cards,omitempty"`
+	Comments       []CardComment  `gorm:"foreignKey:CardID;constraint:OnDelete:CASCADE" json:"comments,omitempty"`
+	Labels         []Label        `gorm:"many2many:card_labels" json:"labels,omitempty"`
 	IsActionItem   bool           `gorm:"default:false" json:"is_action_item"`
 	Owner          string         `json:"owner,omitempty"`
 	DueDate        *time.Time     `json:"due_date,omitempty"`
@@ -126,16 +161,50 @@ func (c *Card) BeforeCreate(tx *gorm.DB) error {
 	return nil
 }
 
+// CardGroup is a named cluster of cards within a column. Groups nest: a group
+// with a ParentID sits inside another group of the same column.
+type CardGroup struct {
+	ID            uuid.UUID  `gorm:"type:uuid;primary_key" json:"id"`
+	ColumnID      uuid.UUID  `gorm:"type:uuid;not null;index" json:"column_id"`
+	ParentID      *uuid.UUID `gorm:"type:uuid;index" json:"parent_id,omitempty"`
+	Title         string     `gorm:"not null" json:"title"`
+	TitleCardID   *uuid.UUID `gorm:"type:uuid" json:"-"` // Card titling the group once a private writing board is revealed
+	Position      int        `gorm:"not null" json:"position"`
+	CardCount     int        `gorm:"-" json:"card_count"`     // Cards in the group and its subgroups
+	VoteCount     int        `gorm:"-" json:"vote_count"`     // Voters who liked those cards, each counted once
+	DislikeCount  int        `gorm:"-" json:"dislike_count"`  // Voters who disliked those cards, each counted once
+	ReactionCount int        `gorm:"-" json:"reaction_count"` // Reactions on those cards
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
+
+// BeforeCreate hook to generate UUID
+func (g *CardGroup) BeforeCreate(tx *gorm.DB) error {
+	if g.ID == uuid.Nil {
+		g.ID = uuid.New()
+	}
+	return nil
+}
+
 // Vote represents a vote on a card
 type Vote struct {
 	ID        uuid.UUID      `gorm:"type:uuid;primary_key" json:"id"`
 	CardID    uuid.UUID      `gorm:"type:uuid;not null;index:idx_card_user,unique" json:"card_id"`
 	UserName  string         `gorm:"not null;index:idx_card_user,unique" json:"user_name"`
 	VoteType  string         `gorm:"not null;check:vote_type IN ('like', 'dislike')" json:"vote_type"`
+	Weight    int            `gorm:"not null;default:1" json:"weight"` // Dots stacked on the card on dot voting boards
 	CreatedAt time.Time      `json:"created_at"`
 	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
 }
 
+// BoardVoter is a person who voted on a board. Their row is locked while
+// their votes change, so that parallel votes cannot overspend a budget.
+type BoardVoter struct {
+	BoardID   uuid.UUID `gorm:"type:uuid;primaryKey" json:"board_id"`
+	UserName  string    `gorm:"primaryKey" json:"user_name"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
 // BeforeCreate hook to generate UUID
 func (v *Vote) BeforeCreate(tx *gorm.DB) error {
 	if v.ID == uuid.Nil {
@@ -162,6 +231,79 @@ func (r *Reaction) BeforeCreate(tx *gorm.DB) error {
 	return nil
 }
 
+// CardComment is a comment in a card's discussion thread
+type CardComment struct {
+	ID        uuid.UUID      `gorm:"type:uuid;primary_key" json:"id"`
+	CardID    uuid.UUID      `gorm:"type:uuid;not null;index" json:"card_id"`
+	ParentID  *uuid.UUID     `gorm:"type:uuid;index" json:"parent_id,omitempty"` // Comment this one replies to
+	Author    string         `gorm:"not null" json:"author,omitempty"`           // Hidden on anonymous boards
+	Body      string         `gorm:"type:text;not null" json:"body"`
+	Mine      bool           `gorm:"-" json:"mine,omitempty"` // Written by the caller
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	EditedAt  *time.Time     `json:"edited_at,omitempty"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+}
+
+// BeforeCreate hook to generate UUID
+func (c *CardComment) BeforeCreate(tx *gorm.DB) error {
+	if c.ID == uuid.Nil {
+		c.ID = uuid.New()
+	}
+	return nil
+}
+
+// CardRevision records who changed a card's content or action item details, and how
+type CardRevision struct {
+	ID           uuid.UUID              `gorm:"type:uuid;primary_key" json:"id"`
+	CardID       uuid.UUID              `gorm:"type:uuid;not null;index" json:"card_id"`
+	Seq          int64                  `gorm:"not null;default:0" json:"seq"`            // Position in the card's history, 0 for older revisions
+	Actor        string                 `json:"actor,omitempty"`                          // Hidden on anonymous boards when the content changed
+	Reason       string                 `gorm:"not null" json:"reason"`                   // edit, admin or restore
+	RestoredFrom *uuid.UUID             `gorm:"type:uuid" json:"restored_from,omitempty"` // Revision a restore went back to
+	Changes      map[string]FieldChange `gorm:"serializer:json;type:text" json:"changes"` // Changed fields by JSON name
+	CreatedAt    time.Time              `json:"created_at"`
+}
+
+// FieldChange is the JSON value of a card field before and after a revision
+type FieldChange struct {
+	Before json.RawMessage `json:"before"`
+	After  json.RawMessage `json:"after"`
+}
+
+// BeforeCreate hook to generate UUID
+func (r *CardRevision) BeforeCreate(tx *gorm.DB) error {
+	if r.ID == uuid.Nil {
+		r.ID = uuid.New()
+	}
+	return nil
+}
+
+// Label is a coloured tag from the palette of a board, or of a team for all of the team's boards
+type Label struct {
+	ID        uuid.UUID  `gorm:"type:uuid;primary_key" json:"id"`
+	BoardID   *uuid.UUID `gorm:"type:uuid;index" json:"board_id,omitempty"`
+	TeamID    *uuid.UUID `gorm:"type:uuid;index" json:"team_id,omitempty"`
+	Name      string     `gorm:"not null" json:"name"`
+	Color     string     `gorm:"not null" json:"color"` // #rrggbb
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// BeforeCreate hook to generate UUID
+func (l *Label) BeforeCreate(tx *gorm.DB) error {
+	if l.ID == uuid.Nil {
+		l.ID = uuid.New()
+	}
+	return nil
+}
+
+// CardLabel tags a card with a label (the card_labels join table of Card.Labels)
+type CardLabel struct {
+	CardID    uuid.UUID `gorm:"type:uuid;primaryKey" json:"card_id"`
+	LabelID   uuid.UUID `gorm:"type:uuid;primaryKey;index" json:"label_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
 // Team represents a group of users
 type Team struct {
 	ID           uuid.UUID      `gorm:"type:uuid;primary_key" json:"id"`
@@ -194,3 +336,126 @@ type TeamMember struct {
 	User     User      `gorm:"foreignKey:UserID" json:"user,omitempty"`
 	Team     Team      `gorm:"foreignKey:TeamID" json:"-"`
 }
+
+// TeamStats holds aggregated statistics for a team
+type TeamStats struct {
+	TotalBoards       int64              `json:"total_boards"`
+	ActiveBoards      int64              `json:"active_boards"`
+	TotalParticipants int64              `json:"total_participants"`
+	TotalActionItems  int64              `json:"total_action_items"`
+	SurveyTrend       []SurveyTrendPoint `json:"survey_trend"` // Closing survey of the team's finished boards, oldest first
+}
+
+// SurveyTrendPoint is how a finished board of a team rated its meeting
+type SurveyTrendPoint struct {
+	BoardID    uuid.UUID  `json:"board_id"`
+	BoardName  string     `json:"board_name"`
+	FinishedAt *time.Time `json:"finished_at"`
+	Scale      int        `json:"scale"`
+	Average    float64    `json:"average"`
+	Responses  int        `json:"responses"`
+}
+
+// GroupVoteTotal is the vote tally of a card group, counting each voter once
+type GroupVoteTotal struct {
+	GroupID  uuid.UUID `json:"group_id"`
+	Likes    int       `json:"likes"`
+	Dislikes int       `json:"dislikes"`
+}
+
+// VoteBudget is how much of a voting budget a voter has used
+type VoteBudget struct {
+	Limit     int `json:"limit"` // 0 = unlimited
+	Used      int `json:"used"`
+	Remaining int `json:"remaining"` // -1 when unlimited
+}
+
+// ColumnVoteBudget is a voter's budget in a column with its own limit
+type ColumnVoteBudget struct {
+	ColumnID uuid.UUID `json:"column_id"`
+	VoteBudget
+}
+
+// RemainingVotes is what a voter has left to spend on a board
+type RemainingVotes struct {
+	VotingMode string             `json:"voting_mode"`
+	Board      VoteBudget         `json:"board"`
+	Columns    []ColumnVoteBudget `json:"columns"`
+}
+
+// SurveyResults is the tally of a board's closing survey. It keeps no link
+// between a person and their rating or comment.
+type SurveyResults struct {
+	Question     string   `json:"question"`
+	Scale        int      `json:"scale"`
+	Responses    int      `json:"responses"`
+	Average      float64  `json:"average"`
+	Distribution []int    `json:"distribution"` // Answers per rating, from 1 up to the scale
+	Comments     []string `json:"comments"`     // Sorted, so their order tells nothing about who wrote them
+}
+
+// SurveyRespondent records that a person answered a board's closing survey,
+// and nothing of what they answered
+type SurveyRespondent struct {
+	BoardID   uuid.UUID `gorm:"type:uuid;primaryKey" json:"board_id"`
+	UserName  string    `gorm:"primaryKey" json:"user_name"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// VoteResult is where a card or card group ended up in a board's vote
+type VoteResult struct {
+	Rank     int       `json:"rank"`
+	Kind     string    `json:"kind"` // card, group
+	ID       uuid.UUID `json:"id"`
+	ColumnID uuid.UUID `json:"column_id"`
+	Title    string    `json:"title"` // Card content or group title
+	Score    int       `json:"score"` // What the board's voting method counts
+	Voters   int       `json:"voters"`
+}
+
+// VoteResults orders the cards or card groups of a board by their votes
+type VoteResults struct {
+	VotingMode string       `json:"voting_mode"`
+	By         string       `json:"by"` // card, group
+	Results    []VoteResult `json:"results"`
+	ClosedAt   *time.Time   `json:"closed_at,omitempty"` // Set on the snapshot taken when voting closed
+	ClosedBy   string       `json:"closed_by,omitempty"`
+}
+
+// MergeSuggestion is a group of near-duplicate cards of a column that could be merged into one
+type MergeSuggestion struct {
+	ColumnID     uuid.UUID   `json:"column_id"`
+	TargetCardID uuid.UUID   `json:"target_card_id"` // Card the others would be merged into
+	CardIDs      []uuid.UUID `json:"card_ids"`
+	Similarity   float64     `json:"similarity"` // Lowest similarity between two cards of the group
+}
+
+// RecurringTheme is a cluster of similar cards raised on several of a team's boards
+type RecurringTheme struct {
+	Title                string       `json:"title"`  // Most used label, or the text of the earliest card
+	Labels               []string     `json:"labels"` // Label names carried by the theme's cards
+	Occurrences          int          `json:"occurrences"`
+	Boards               []ThemeBoard `json:"boards"` // Oldest first
+	Cards                []ThemeCard  `json:"cards"`
+	ActionItems          int          `json:"action_items"`
+	CompletedActionItems int          `json:"completed_action_items"`
+	FirstSeen            time.Time    `json:"first_seen"`
+	LastSeen             time.Time    `json:"last_seen"`
+}
+
+// ThemeBoard is a board a recurring theme appeared on
+type ThemeBoard struct {
+	ID        uuid.UUID `json:"id"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+	Cards     int       `json:"cards"`
+}
+
+// ThemeCard is a card of a recurring theme
+type ThemeCard struct {
+	ID           uuid.UUID `json:"id"`
+	BoardID      uuid.UUID `json:"board_id"`
+	Content      string    `json:"content"`
+	IsActionItem bool      `json:"is_action_item"`
+	Completed    bool      `json:"completed"`
+}
@@ -3,15 +3,49 @@ import { CONFIG } from './config.js'; // Import dependency
 
 const API_BASE = CONFIG.API_URL; // Local constant for module use
 
+// Board the socket has joined, rejoined automatically after a reconnect
+let joinedBoard = null;
+// Last event seq applied per board, used to resume after a reconnect
+const lastSeqByBoard = new Map();
+// Boards with a resume request in flight
+const resumingBoards = new Set();
+
+// Some proxies strip WebSocket upgrades. After this many connection attempts that
+// never open, board events are received over Server-Sent Events instead.
+const SSE_FALLBACK_AFTER = 2;
+let failedWebSocketAttempts = 0;
+let useEventStream = false;
+let eventSource = null;
+
+// guestQuery names the guest a client connects as when it is not signed in.
+// Signed-in clients are identified by their auth cookie instead.
+function guestQuery() {
+    const guestName = localStorage.getItem('retroUser');
+    if (window.currentUserId || !guestName) return '';
+    return `guest=${encodeURIComponent(guestName)}`;
+}
+
 // Initialize WebSocket
 export function initWebSocket() {
     const WS_PROTOCOL = window.location.protocol === 'https:' ? 'wss:' : 'ws:';
-    const WS_URL = `${WS_PROTOCOL}//${window.location.host}/ws`;
+    const guest = guestQuery();
+    const WS_URL = `${WS_PROTOCOL}//${window.location.host}/ws${guest ? '?' + guest : ''}`;
+    let opened = false;
 
     window.ws = new WebSocket(WS_URL);
     // ... (rest of initWebSocket body is identical, but we attach variables to window inside)
     window.ws.onopen = () => {
         console.log('%c🔌 WebSocket Connected', 'background: #4CAF50; color: white; padding: 2px 6px; border-radius: 3px;');
+        opened = true;
+        failedWebSocketAttempts = 0;
+        if (useEventStream) {
+            // WebSockets work again; the rejoin below resumes from the last event seen
+            useEventStream = false;
+            closeEventStream();
+        }
+        if (joinedBoard) {
+            joinBoard(joinedBoard.boardId, joinedBoard.username, joinedBoard.avatar);
+        }
     };
 
     window.ws.onmessage = (event) => {
@@ -21,6 +55,11 @@ export function initWebSocket() {
 
     window.ws.onclose = () => {
         console.log('%c🔌 WebSocket Disconnected - Reconnecting...', 'background: #FF9800; color: white; padding: 2px 6px; border-radius: 3px;');
+        if (!opened && ++failedWebSocketAttempts >= SSE_FALLBACK_AFTER && !useEventStream) {
+            console.warn('WebSocket unavailable, receiving board events over Server-Sent Events');
+            useEventStream = true;
+            if (joinedBoard) openEventStream(joinedBoard.boardId);
+        }
         setTimeout(initWebSocket, 3000);
     };
 
@@ -29,64 +68,134 @@ export function initWebSocket() {
     };
 }
 
+// openEventStream receives a board's events over Server-Sent Events, resuming
+// after the last event seen. The stream carries the same envelopes as the socket.
+function openEventStream(boardId) {
+    closeEventStream();
+    const params = [];
+    if (lastSeqByBoard.has(boardId)) params.push(`last_event_id=${lastSeqByBoard.get(boardId)}`);
+    const guest = guestQuery();
+    if (guest) params.push(guest);
+    const query = params.length > 0 ? `?${params.join('&')}` : '';
+    eventSource = new EventSource(`${API_BASE}/boards/${boardId}/events${query}`);
+    eventSource.onmessage = (event) => handleWebSocketMessage(JSON.parse(event.data));
+    eventSource.onerror = () => {
+        // EventSource reconnects by itself, sending Last-Event-ID
+        console.warn('Event stream interrupted - reconnecting...');
+    };
+}
+
+function closeEventStream() {
+    if (eventSource) {
+        eventSource.close();
+        eventSource = null;
+    }
+}
+
 export function handleWebSocketMessage(message) {
     // ... (rest of function)
     // Event-driven WebSocket Handling
     const eventName = message.type.replace('_', ':'); // e.g., 'board_update' -> 'board:update'
+    // Frames are versioned envelopes: { v, type, board_id, seq, payload }
+    const data = message.payload || {};
+
+    if (message.type === 'error') {
+        console.warn(`WebSocket error (${data.code}): ${data.message}`);
+    }
+
+    if (!trackSequence(message)) return;
 
     // Dispatch generic event
-    window.dispatchEvent(new CustomEvent(eventName, { detail: message.data }));
+    window.dispatchEvent(new CustomEvent(eventName, { detail: data }));
 
     // TODO: Remove Legacy shims once fully migrated
     // Legacy support for parts not yet coupled (like Timer UI in Header if not in Controller)
     switch (message.type) {
-        case 'timer_update':
-            // BoardController or TimerComponent should listen to 'timer:update'
-            if (window.updateTimerDisplay) window.updateTimerDisplay(message.data.seconds);
-            break;
-        case 'timer_start':
-            if (window.startTimerUI) window.startTimerUI(message.data.seconds);
+        case 'phase_changed':
+            // BoardController listens to 'phase:changed'
+            if (window.updatePhase) window.updatePhase(data.phase);
             break;
-        case 'timer_stop':
-            if (window.stopTimerUI) window.stopTimerUI();
-            break;
-        case 'phase_change':
-            // BoardController listens to 'phase:change'
-            if (window.updatePhase) window.updatePhase(message.data.phase);
-            break;
-        case 'board_update':
-            // DashboardController listens to 'board:update' (global)
-            // BoardController listens to 'board:update' (specific)
+        case 'board_list_changed': {
+            // Sent to every client; board_update only reaches the board's room
             const dashboardEl = document.getElementById('dashboardView');
             if (dashboardEl && dashboardEl.style.display !== 'none' && window.loadBoards) {
                 window.loadBoards();
             }
-            // window.loadBoard calls removed here, BoardController must handle it
             break;
+        }
         case 'participants_update':
             // BoardController listens to 'participants:update'
-            if (window.updateParticipantsDisplay && window.currentBoard && window.currentBoard.id === message.data.board_id) {
-                window.updateParticipantsDisplay(message.data.participants);
+            if (window.updateParticipantsDisplay && window.currentBoard && window.currentBoard.id === data.board_id) {
+                window.updateParticipantsDisplay(data.participants);
             }
             break;
     }
 }
 
-export function sendWebSocketMessage(type, data) {
+// trackSequence applies the board's event ordering. It returns false for
+// frames that were already applied or that arrive after a gap (the server
+// replays the gap in order after a resume).
+function trackSequence(message) {
+    const data = message.payload || {};
+    switch (message.type) {
+        case 'synced':
+            resumingBoards.delete(data.board_id);
+            lastSeqByBoard.set(data.board_id, data.seq);
+            return true;
+        case 'resync_required':
+            // Missed events are gone; reload the board from the API
+            resumingBoards.delete(data.board_id);
+            lastSeqByBoard.set(data.board_id, data.seq);
+            window.dispatchEvent(new CustomEvent('board:update', { detail: { board_id: data.board_id, action: 'refresh_board' } }));
+            return true;
+    }
+
+    if (!message.seq || !message.board_id || !lastSeqByBoard.has(message.board_id)) return true;
+
+    const lastSeq = lastSeqByBoard.get(message.board_id);
+    if (message.seq <= lastSeq) return false;
+    if (message.seq > lastSeq + 1) {
+        if (!resumingBoards.has(message.board_id)) {
+            resumingBoards.add(message.board_id);
+            if (useEventStream) {
+                // Reopening the stream replays from the last event applied
+                openEventStream(message.board_id);
+            } else {
+                sendWebSocketMessage('resume', { last_seq: lastSeq }, message.board_id);
+            }
+        }
+        return false;
+    }
+    lastSeqByBoard.set(message.board_id, message.seq);
+    return true;
+}
+
+export const WS_PROTOCOL_VERSION = 1;
+
+// Board-scoped messages default to the board currently open
+export function sendWebSocketMessage(type, payload = {}, boardId = window.currentBoard?.id) {
     if (window.ws && window.ws.readyState === WebSocket.OPEN) {
-        window.ws.send(JSON.stringify({ type, data }));
+        window.ws.send(JSON.stringify({ v: WS_PROTOCOL_VERSION, type, board_id: boardId, payload }));
     }
 }
 
 export function joinBoard(boardId, username, avatar) {
+    joinedBoard = { boardId, username, avatar };
+    if (useEventStream) {
+        openEventStream(boardId);
+        return;
+    }
     if (window.ws && window.ws.readyState === WebSocket.OPEN) {
-        window.ws.send(JSON.stringify({
-            type: 'join_board',
-            board_id: boardId,
+        const payload = {
             username: username,
-            avatar: avatar || (window.getUserAvatar ? window.getUserAvatar() : ''),
-            is_admin: !!localStorage.getItem('adminToken')
-        }));
+            avatar: avatar || (window.getUserAvatar ? window.getUserAvatar() : '')
+            // Admin status is derived server-side from the auth token
+        };
+        // Rejoining a board resumes from the last event seen
+        if (lastSeqByBoard.has(boardId)) {
+            payload.last_seq = lastSeqByBoard.get(boardId);
+        }
+        sendWebSocketMessage('join_board', payload, boardId);
     }
 }
 
@@ -98,12 +207,17 @@ export function toggleReaction(cardId, reactionType) {
 }
 
 export function leaveBoard(boardId, username) {
+    if (joinedBoard && joinedBoard.boardId === boardId) {
+        joinedBoard = null;
+    }
+    lastSeqByBoard.delete(boardId);
+    resumingBoards.delete(boardId);
+    if (useEventStream) {
+        closeEventStream();
+        return;
+    }
     if (window.ws && window.ws.readyState === WebSocket.OPEN) {
-        window.ws.send(JSON.stringify({
-            type: 'leave_board',
-            board_id: boardId,
-            username: username
-        }));
+        sendWebSocketMessage('leave_board', { username: username }, boardId);
     }
 }
 
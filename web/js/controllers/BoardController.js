@@ -1,10 +1,11 @@
 import { Controller } from '../lib/Controller.js';
 // import { loadBoard } from '../board.js'; // LEGACY REMOVED
 import { i18n } from '../i18n.js';
-import { boardService } from '../services/BoardService.js';
+import { boardService, nextPhase } from '../services/BoardService.js';
 import { router } from '../lib/Router.js';
 import { BoardView } from '../views/BoardView.js';
 import { apiCall } from '../api.js';
+import { escapeHtml } from '../utils.js';
 import { playTimerSound } from '../timer.js';
 import { CursorController } from './CursorController.js';
 
@@ -18,6 +19,7 @@ export class BoardController extends Controller {
         this.view = new BoardView('columnsContainer');
         this.eventsBound = false;
         this.sortOption = localStorage.getItem('bentro_board_sort') || 'position';
+        this.labelFilter = '';
 
         // Bind handlers once in constructor
         this.handleClick = this.handleClick.bind(this);
@@ -37,7 +39,9 @@ export class BoardController extends Controller {
 
         this.boardId = params.id;
         this.board = null;
+        this.setLabelFilter('', false);
 
+        await this.joinAsGuest();
         await this.loadBoardData();
         this.showView();
 
@@ -55,24 +59,24 @@ export class BoardController extends Controller {
                     this.loadBoardData();
                 }
             },
-            onPhaseChange: (e) => {
-                if (e.detail.board_id === this.boardId) {
-                    console.log('BoardController: Phase Changed', e.detail.phase);
-                    this.loadBoardData();
-                }
-            },
+            onPhaseChanged: (e) => this.applyDelta(e.detail, () => {
+                this.board.phase = e.detail.phase;
+                this.board.phase_changed_at = e.detail.changed_at;
+                this.board.phase_changed_by = e.detail.changed_by;
+                window.currentPhase = e.detail.phase;
+                this.loadRemainingVotes();
+                return true;
+            }),
             onParticipantsUpdate: (e) => {
                 if (e.detail.board_id === this.boardId) {
                     // Update participants UI
                 }
             },
-            onTimerStart: (e) => {
-                console.log('Timer Start Event', e.detail);
-                if (this.startTimerUI) this.startTimerUI(e.detail.seconds);
-            },
-            onTimerStop: (e) => {
-                console.log('Timer Stop Event');
-                if (this.stopTimerUI) this.stopTimerUI();
+            onTimerUpdated: (e) => {
+                if (e.detail.board_id !== this.boardId) return;
+                if (this.board) this.board.timer = e.detail.timer;
+                this.syncTimer(e.detail.timer, e.detail.server_time);
+                if (e.detail.action === 'timer_expired') playTimerSound();
             },
             onVoteUpdate: (e) => {
                 if (e.detail.board_id === this.boardId) {
@@ -84,16 +88,86 @@ export class BoardController extends Controller {
                     console.log('BoardController: Received Card Move Event', e.detail);
                     this.handleCardMove(e.detail);
                 }
-            }
+            },
+            // Delta events patch the loaded board instead of refetching it
+            onCardCreated: (e) => this.applyDelta(e.detail, () => this.handleCardCreated(e.detail)),
+            onCardUpdated: (e) => this.applyDelta(e.detail, () => this.handleCardUpdated(e.detail)),
+            onCardDeleted: (e) => this.applyDelta(e.detail, () => this.handleCardDeleted(e.detail)),
+            onCardMerged: (e) => this.applyDelta(e.detail, () => this.handleCardMerged(e.detail)),
+            onGroupChanged: (e) => this.applyDelta(e.detail, () => this.handleGroupChanged(e.detail)),
+            onGroupDeleted: (e) => this.applyDelta(e.detail, () => this.handleGroupDeleted(e.detail)),
+            // Moved groups carry their cards and subgroups along, so the board is refetched
+            onGroupMoved: (e) => this.applyDelta(e.detail, () => false),
+            onColumnRenamed: (e) => this.applyDelta(e.detail, () => this.handleColumnChanged(e.detail, { name: e.detail.name })),
+            onColumnReordered: (e) => this.applyDelta(e.detail, () => this.handleColumnChanged(e.detail, { position: e.detail.position })),
+            onColumnVoteLimit: (e) => this.applyDelta(e.detail, () => {
+                this.loadRemainingVotes();
+                return this.handleColumnChanged(e.detail, { vote_limit: e.detail.vote_limit });
+            }),
+            onBoardSettingsChanged: (e) => this.applyDelta(e.detail, () => {
+                const { anonymity, authors_revealed, private_writing } = e.detail.settings;
+                const visibilityChanged = anonymity !== this.board.anonymity ||
+                    authors_revealed !== this.board.authors_revealed ||
+                    private_writing !== this.board.private_writing;
+                const surveyOpened = e.detail.settings.survey_opened_at && !this.board.survey_opened_at;
+                Object.assign(this.board, e.detail.settings);
+                this.loadRemainingVotes();
+                // Finishing the board asks everyone who has not answered yet
+                if (surveyOpened) this.handleOpenSurvey(true);
+                // Card authors and hidden content only arrive with the board, so reload when their visibility changes
+                return !visibilityChanged;
+            }),
+            onCardsRevealed: (e) => this.applyDelta(e.detail, () => this.handleCardsRevealed(e.detail)),
+            onResultsRevealed: (e) => this.applyDelta(e.detail, () => {
+                this.board.results = e.detail.results;
+                this.board.voting_closed_at = e.detail.results.closed_at;
+                this.showVoteResults(e.detail.results);
+                return true;
+            }),
+            onSurveyUpdated: (e) => this.applyDelta(e.detail, () => {
+                this.board.survey = e.detail.survey;
+                if (this.surveyOpen()) this.renderSurveyResults(e.detail.survey);
+                return true;
+            }),
+            onReactionToggled: (e) => this.applyDelta(e.detail, () => this.handleReactionToggled(e.detail)),
+            onCommentChanged: (e) => this.applyDelta(e.detail, () => this.handleCommentChanged(e.detail)),
+            onLabelChanged: (e) => this.applyDelta(e.detail, () => this.handleLabelChanged(e.detail)),
+            onCardLabeled: (e) => this.applyDelta(e.detail, () => this.handleCardLabeled(e.detail))
         };
 
-        window.addEventListener('board:update', this.wsHandlers.onBoardUpdate);
-        window.addEventListener('phase:change', this.wsHandlers.onPhaseChange);
-        window.addEventListener('participants:update', this.wsHandlers.onParticipantsUpdate);
-        window.addEventListener('timer:start', this.wsHandlers.onTimerStart);
-        window.addEventListener('timer:stop', this.wsHandlers.onTimerStop);
-        window.addEventListener('vote:update', this.wsHandlers.onVoteUpdate);
-        window.addEventListener('card:move', this.wsHandlers.onCardMove);
+        this.wsEvents = {
+            'board:update': this.wsHandlers.onBoardUpdate,
+            'phase:changed': this.wsHandlers.onPhaseChanged,
+            'participants:update': this.wsHandlers.onParticipantsUpdate,
+            'timer:updated': this.wsHandlers.onTimerUpdated,
+            'vote:update': this.wsHandlers.onVoteUpdate,
+            'card:move': this.wsHandlers.onCardMove,
+            'card:created': this.wsHandlers.onCardCreated,
+            'card:updated': this.wsHandlers.onCardUpdated,
+            'card:deleted': this.wsHandlers.onCardDeleted,
+            'card:merged': this.wsHandlers.onCardMerged,
+            'group:created': this.wsHandlers.onGroupChanged,
+            'group:updated': this.wsHandlers.onGroupChanged,
+            'group:moved': this.wsHandlers.onGroupMoved,
+            'group:deleted': this.wsHandlers.onGroupDeleted,
+            'column:renamed': this.wsHandlers.onColumnRenamed,
+            'column:reordered': this.wsHandlers.onColumnReordered,
+            'column:vote_limit_changed': this.wsHandlers.onColumnVoteLimit,
+            'board:settings_changed': this.wsHandlers.onBoardSettingsChanged,
+            'reaction:toggled': this.wsHandlers.onReactionToggled,
+            'cards:revealed': this.wsHandlers.onCardsRevealed,
+            'results:revealed': this.wsHandlers.onResultsRevealed,
+            'survey:updated': this.wsHandlers.onSurveyUpdated,
+            'comment:created': this.wsHandlers.onCommentChanged,
+            'comment:updated': this.wsHandlers.onCommentChanged,
+            'comment:deleted': this.wsHandlers.onCommentChanged,
+            'label:created': this.wsHandlers.onLabelChanged,
+            'label:updated': this.wsHandlers.onLabelChanged,
+            'label:deleted': this.wsHandlers.onLabelChanged,
+            'card:labeled': this.wsHandlers.onCardLabeled,
+            'card:unlabeled': this.wsHandlers.onCardLabeled
+        };
+        Object.entries(this.wsEvents).forEach(([name, handler]) => window.addEventListener(name, handler));
         // Cursor events handled directly by CursorController, no need to bind here unless we want validatino
     }
 
@@ -110,13 +184,8 @@ export class BoardController extends Controller {
         super.destroy();
         console.log('BoardController destroyed');
 
-        if (this.wsHandlers) {
-            window.removeEventListener('board:update', this.wsHandlers.onBoardUpdate);
-            window.removeEventListener('phase:change', this.wsHandlers.onPhaseChange);
-            window.removeEventListener('participants:update', this.wsHandlers.onParticipantsUpdate);
-            window.removeEventListener('timer:start', this.wsHandlers.onTimerStart);
-            window.removeEventListener('timer:stop', this.wsHandlers.onTimerStop);
-            window.removeEventListener('vote:update', this.wsHandlers.onVoteUpdate);
+        if (this.wsEvents) {
+            Object.entries(this.wsEvents).forEach(([name, handler]) => window.removeEventListener(name, handler));
         }
 
         if (this.cleanup) this.cleanup();
@@ -127,6 +196,24 @@ export class BoardController extends Controller {
         }
     }
 
+    // Guests vote and react under the name they joined with. Joining issues the
+    // token the server ties that name to, so guests join whenever they open a board.
+    async joinAsGuest() {
+        if (window.currentUserId || !window.currentUser) return;
+        try {
+            await boardService.join(this.boardId, {
+                username: window.currentUser,
+                avatar: window.currentUserAvatar
+            });
+        } catch (error) {
+            // Boards closed to guests report themselves when loading
+            console.warn('BoardController: Guest could not join', error);
+            if (error.message.includes('under this name') && window.showAlert) {
+                window.showAlert('Error', error.message);
+            }
+        }
+    }
+
     async loadBoardData() {
         try {
             this.board = await boardService.getById(this.boardId);
@@ -138,9 +225,11 @@ export class BoardController extends Controller {
 
             // Render View
             this.view.render(this.board, window.currentUser, this.selectedCardId, this.sortOption);
+            this.syncTimer(this.board.timer);
 
             // Initialize Sortable always
             this.initSortable();
+            this.loadRemainingVotes();
 
             // Initialize Real-time Cursors
             if (!this.cursorController) {
@@ -222,6 +311,15 @@ export class BoardController extends Controller {
             });
         }
 
+        // Label Filter (outside the board container, bound once per page)
+        const labelSelect = document.getElementById('labelFilterSelect');
+        if (labelSelect && !labelSelect.dataset.bound) {
+            labelSelect.dataset.bound = 'true';
+            labelSelect.addEventListener('change', (e) => {
+                window.boardController.setLabelFilter(e.target.value);
+            });
+        }
+
         this.eventsBound = true;
         console.log('BoardController: Events bound');
     }
@@ -240,6 +338,16 @@ export class BoardController extends Controller {
         }
     }
 
+    setLabelFilter(labelId, render = true) {
+        this.labelFilter = labelId || '';
+        this.view.labelFilter = this.labelFilter;
+
+        if (render && this.board) {
+            this.view.render(this.board, window.currentUser, this.selectedCardId, this.sortOption);
+            this.initSortable();
+        }
+    }
+
     async handleClick(e) {
         const target = e.target.closest('[data-action]');
         if (!target) return;
@@ -271,6 +379,24 @@ export class BoardController extends Controller {
             case 'columnEdit':
                 this.handleEditColumn(target.dataset.columnId);
                 break;
+            case 'columnVoteLimit':
+                this.handleColumnVoteLimit(target.dataset.columnId);
+                break;
+            case 'removeDot':
+                this.handleRemoveDot(target.dataset.voteId);
+                break;
+            case 'ballot':
+                this.handleBallot(target.dataset.cardId);
+                break;
+            case 'addGroup':
+                this.handleAddGroup(target.dataset.columnId);
+                break;
+            case 'renameGroup':
+                this.handleRenameGroup(target.dataset.groupId);
+                break;
+            case 'ungroup':
+                this.handleUngroup(target.dataset.groupId);
+                break;
             case 'columnDelete':
                 this.handleDeleteColumn(target.dataset.columnId);
                 break;
@@ -288,6 +414,9 @@ export class BoardController extends Controller {
             case 'switchPhase':
                 this.handleSwitchPhase();
                 break;
+            case 'revealCards':
+                this.handleRevealCards();
+                break;
             case 'startTimer':
                 this.handleStartTimer();
                 break;
@@ -313,6 +442,15 @@ export class BoardController extends Controller {
             case 'openActionModal':
                 this.handleOpenActionModal(target.dataset.cardId);
                 break;
+            case 'openHistory':
+                this.handleOpenHistory(target.dataset.cardId);
+                break;
+            case 'openLabels':
+                this.handleOpenLabels(target.dataset.cardId);
+                break;
+            case 'openComments':
+                this.handleOpenComments(target.dataset.cardId);
+                break;
             case 'unmerge':
                 this.handleUnmerge(target.dataset.cardId);
                 break;
@@ -334,6 +472,18 @@ export class BoardController extends Controller {
             case 'exportBoard':
                 this.handleExportBoard();
                 break;
+            case 'voteResults':
+                this.handleVoteResults();
+                break;
+            case 'closeVoting':
+                this.handleCloseVoting();
+                break;
+            case 'openSurvey':
+                this.handleOpenSurvey();
+                break;
+            case 'suggestMerges':
+                this.handleSuggestMerges();
+                break;
             case 'leaveBoard':
                 if (typeof window.leaveBoardPersistent === 'function') {
                     window.leaveBoardPersistent();
@@ -437,62 +587,476 @@ export class BoardController extends Controller {
         }
     }
 
-    async handleUnmerge(parentId) {
-        const parent = this.findCard(parentId);
-        if (!parent || !parent.merged_cards || parent.merged_cards.length === 0) {
-            window.toast.info("No merged cards to unmerge.");
+    canManageBoard() {
+        const board = this.board || {};
+        return board.owner === window.currentUser || board.co_owner === window.currentUser ||
+            (Array.isArray(board.managers) && board.managers.includes(window.currentUser));
+    }
+
+    // Comments
+    // The modal lives outside the board container, so it gets its own listeners
+    bindCommentsModal() {
+        if (this.commentsModalBound) return;
+        const form = document.getElementById('cardCommentForm');
+        const list = document.getElementById('cardCommentsList');
+        if (!form || !list) return;
+        this.commentsModalBound = true;
+
+        form.addEventListener('submit', (e) => {
+            e.preventDefault();
+            this.handleSubmitComment();
+        });
+        list.addEventListener('click', (e) => {
+            const target = e.target.closest('[data-comment-action]');
+            if (!target) return;
+            const commentId = target.dataset.commentId;
+            switch (target.dataset.commentAction) {
+                case 'reply':
+                    this.setCommentReply(commentId);
+                    break;
+                case 'edit':
+                    this.handleEditComment(commentId);
+                    break;
+                case 'delete':
+                    this.handleDeleteComment(commentId);
+                    break;
+            }
+        });
+    }
+
+    async handleOpenComments(cardId) {
+        const modal = document.getElementById('cardCommentsModal');
+        if (!modal) return;
+        this.bindCommentsModal();
+        this.commentsCardId = cardId;
+        this.setCommentReply(null);
+        document.getElementById('cardCommentBody').value = '';
+        modal.style.display = 'block';
+        await this.loadComments();
+    }
+
+    commentsOpen() {
+        const modal = document.getElementById('cardCommentsModal');
+        return this.commentsCardId && modal && modal.style.display === 'block';
+    }
+
+    async loadComments() {
+        const cardId = this.commentsCardId;
+        try {
+            const comments = await boardService.listComments(cardId);
+            if (cardId !== this.commentsCardId) return;
+            this.comments = comments || [];
+            this.renderComments();
+        } catch (e) {
+            console.error('[Controller] Loading comments failed:', e);
+            window.toast.error(e.message);
+        }
+    }
+
+    renderComments() {
+        const list = document.getElementById('cardCommentsList');
+        if (!list) return;
+        if (this.comments.length === 0) {
+            list.innerHTML = `<p class="empty-state">${i18n.t('comments.empty')}</p>`;
             return;
         }
 
-        // Unmerge the last one (LIFO)
-        const lastChild = parent.merged_cards[parent.merged_cards.length - 1];
-        if (!await window.showConfirm("Unmerge?", `Unmerge card "${lastChild.content}"?`)) return;
+        const canManage = this.canManageBoard();
+        const ids = new Set(this.comments.map(c => c.id));
+        // Replies whose parent was deleted stay in the thread as top-level comments
+        const roots = this.comments.filter(c => !c.parent_id || !ids.has(c.parent_id));
+        const renderComment = (comment, isReply) => {
+            const replies = this.comments.filter(c => c.parent_id === comment.id);
+            return `
+                <div class="comment${isReply ? ' reply' : ''}">
+                    <div class="comment-meta">
+                        ${escapeHtml(comment.author || i18n.t('comments.anonymous'))} · ${new Date(comment.created_at).toLocaleString()}
+                        ${comment.edited_at ? ` · ${i18n.t('comments.edited')}` : ''}
+                    </div>
+                    <div class="comment-body">${escapeHtml(comment.body)}</div>
+                    <div class="comment-actions">
+                        <button type="button" data-comment-action="reply" data-comment-id="${comment.id}">${i18n.t('btn.reply')}</button>
+                        ${comment.mine ? `<button type="button" data-comment-action="edit" data-comment-id="${comment.id}">${i18n.t('btn.edit')}</button>` : ''}
+                        ${comment.mine || canManage ? `<button type="button" data-comment-action="delete" data-comment-id="${comment.id}">${i18n.t('btn.delete')}</button>` : ''}
+                    </div>
+                    ${replies.map(reply => renderComment(reply, true)).join('')}
+                </div>
+            `;
+        };
+        list.innerHTML = roots.map(c => renderComment(c, false)).join('');
+    }
+
+    setCommentReply(commentId) {
+        this.commentReplyTo = commentId;
+        const indicator = document.getElementById('cardCommentReplyTo');
+        if (!indicator) return;
+        indicator.textContent = i18n.t('comments.replying');
+        indicator.style.display = commentId ? 'block' : 'none';
+    }
 
+    async handleSubmitComment() {
+        const input = document.getElementById('cardCommentBody');
+        const body = input.value.trim();
+        if (!body) return;
         try {
-            console.log(`[Controller] Unmerging child ${lastChild.id} from parent ${parentId}`);
-            await boardService.unmergeCard(lastChild.id);
-            await this.loadBoardData();
+            await boardService.addComment(this.commentsCardId, body, this.commentReplyTo);
+            input.value = '';
+            this.setCommentReply(null);
+            await this.loadComments();
+        } catch (e) {
+            console.error('[Controller] Adding comment failed:', e);
+            window.toast.error(e.message);
+        }
+    }
+
+    async handleEditComment(commentId) {
+        const comment = (this.comments || []).find(c => c.id === commentId);
+        if (!comment) return;
+        const body = prompt(i18n.t('btn.edit'), comment.body);
+        if (body === null || !body.trim()) return;
+        try {
+            await boardService.updateComment(this.commentsCardId, commentId, body.trim());
+            await this.loadComments();
+        } catch (e) {
+            console.error('[Controller] Editing comment failed:', e);
+            window.toast.error(e.message);
+        }
+    }
+
+    async handleDeleteComment(commentId) {
+        if (!confirm(i18n.t('confirm.delete_comment'))) return;
+        try {
+            await boardService.deleteComment(this.commentsCardId, commentId);
+            await this.loadComments();
+        } catch (e) {
+            console.error('[Controller] Deleting comment failed:', e);
+            window.toast.error(e.message);
+        }
+    }
+
+    // Labels
+    async handleOpenLabels(cardId) {
+        const modal = document.getElementById('cardLabelsModal');
+        const list = document.getElementById('cardLabelsList');
+        const form = document.getElementById('newLabelForm');
+        if (!modal || !list || !form) return;
+        if (!this.labelsModalBound) {
+            this.labelsModalBound = true;
+            list.addEventListener('change', (e) => {
+                if (e.target.dataset.labelId) this.handleToggleLabel(e.target.dataset.labelId, e.target.checked);
+            });
+            form.addEventListener('submit', (e) => {
+                e.preventDefault();
+                this.handleCreateLabel();
+            });
+        }
+        this.labelsCardId = cardId;
+        form.style.display = this.canManageBoard() ? 'block' : 'none';
+        modal.style.display = 'block';
+        this.renderLabelPicker();
+    }
+
+    renderLabelPicker() {
+        const list = document.getElementById('cardLabelsList');
+        const card = this.findCard(this.labelsCardId);
+        if (!list || !card) return;
+
+        const labels = this.board.labels || [];
+        if (labels.length === 0) {
+            list.innerHTML = `<p class="empty-state">${i18n.t('labels.empty')}</p>`;
+            return;
+        }
+        const tagged = new Set((card.labels || []).map(l => l.id));
+        list.innerHTML = labels.map(label => `
+            <label>
+                <input type="checkbox" data-label-id="${label.id}" ${tagged.has(label.id) ? 'checked' : ''}>
+                <span class="label-chip" style="background: ${escapeHtml(label.color)}">${escapeHtml(label.name)}</span>
+            </label>
+        `).join('');
+    }
+
+    async handleToggleLabel(labelId, checked) {
+        try {
+            // The server broadcasts card_labeled / card_unlabeled, which updates the board
+            if (checked) {
+                await boardService.tagCard(this.labelsCardId, labelId);
+            } else {
+                await boardService.untagCard(this.labelsCardId, labelId);
+            }
+        } catch (e) {
+            console.error('[Controller] Tagging failed:', e);
+            window.toast.error(e.message);
+            this.renderLabelPicker();
+        }
+    }
+
+    async handleCreateLabel() {
+        const nameInput = document.getElementById('newLabelName');
+        const colorInput = document.getElementById('newLabelColor');
+        const name = nameInput.value.trim();
+        if (!name) return;
+        try {
+            await boardService.createLabel(this.boardId, { name, color: colorInput.value });
+            nameInput.value = '';
+        } catch (e) {
+            console.error('[Controller] Creating label failed:', e);
+            window.toast.error(e.message);
+        }
+    }
+
+    labelsOpen() {
+        const modal = document.getElementById('cardLabelsModal');
+        return this.labelsCardId && modal && modal.style.display === 'block';
+    }
+
+    // Card History
+    async handleOpenHistory(cardId) {
+        const modal = document.getElementById('cardHistoryModal');
+        const list = document.getElementById('cardHistoryList');
+        if (!modal || !list) return;
+        if (!this.historyModalBound) {
+            this.historyModalBound = true;
+            list.addEventListener('click', (e) => {
+                const target = e.target.closest('[data-revision-id]');
+                if (target) this.handleRestoreRevision(target.dataset.revisionId);
+            });
+        }
+        this.historyCardId = cardId;
+        modal.style.display = 'block';
+        await this.loadHistory();
+    }
+
+    async loadHistory() {
+        const list = document.getElementById('cardHistoryList');
+        try {
+            const revisions = await boardService.getCardHistory(this.historyCardId) || [];
+            if (revisions.length === 0) {
+                list.innerHTML = `<p class="empty-state">${i18n.t('history.empty')}</p>`;
+                return;
+            }
+            const canRestore = this.canManageBoard();
+            const formatValue = (value) => (value === null || value === '' ? '—' : escapeHtml(String(value)));
+            list.innerHTML = revisions.map(revision => `
+                <div class="comment">
+                    <div class="comment-meta">
+                        ${escapeHtml(revision.actor || i18n.t('comments.anonymous'))} · ${new Date(revision.created_at).toLocaleString()}
+                        ${revision.reason === 'restore' ? ` · ${i18n.t('history.restored')}` : ''}
+                    </div>
+                    ${Object.entries(revision.changes || {}).map(([field, change]) => `
+                        <div class="revision-change">
+                            <strong>${escapeHtml(field)}</strong>: <del>${formatValue(change.before)}</del> → ${formatValue(change.after)}
+                        </div>
+                    `).join('')}
+                    ${canRestore ? `
+                    <div class="comment-actions">
+                        <button type="button" data-revision-id="${revision.id}">${i18n.t('btn.restore')}</button>
+                    </div>
+                    ` : ''}
+                </div>
+            `).join('');
+        } catch (e) {
+            console.error('[Controller] Loading history failed:', e);
+            window.toast.error(e.message);
+        }
+    }
+
+    async handleRestoreRevision(revisionId) {
+        if (!confirm(i18n.t('confirm.restore_revision'))) return;
+        try {
+            // The server broadcasts card_updated with the restored card
+            await boardService.restoreCardRevision(this.historyCardId, revisionId);
+            await this.loadHistory();
+        } catch (e) {
+            console.error('[Controller] Restore failed:', e);
+            window.toast.error(e.message);
+        }
+    }
+
+    // Merge Suggestions
+    async handleSuggestMerges() {
+        const modal = document.getElementById('mergeSuggestionsModal');
+        const list = document.getElementById('mergeSuggestionsList');
+        if (!modal || !list) return;
+        if (!this.mergeSuggestionsBound) {
+            this.mergeSuggestionsBound = true;
+            list.addEventListener('click', (e) => {
+                const target = e.target.closest('[data-suggestion]');
+                if (target) this.handleAcceptSuggestion(Number(target.dataset.suggestion));
+            });
+        }
+        modal.style.display = 'block';
+        await this.loadMergeSuggestions();
+    }
+
+    async loadMergeSuggestions() {
+        const list = document.getElementById('mergeSuggestionsList');
+        try {
+            const result = await boardService.getMergeSuggestions(this.boardId);
+            this.mergeSuggestions = (result && result.suggestions) || [];
+            if (this.mergeSuggestions.length === 0) {
+                list.innerHTML = `<p class="empty-state">${i18n.t('merge.no_suggestions')}</p>`;
+                return;
+            }
+            const content = (id) => {
+                const card = this.findCard(id);
+                return escapeHtml(card ? card.content : '');
+            };
+            list.innerHTML = this.mergeSuggestions.map((suggestion, index) => `
+                <div class="comment">
+                    <div class="comment-meta">
+                        ${i18n.t('merge.similarity')}: ${Math.round(suggestion.similarity * 100)}%
+                    </div>
+                    <div><strong>${content(suggestion.target_card_id)}</strong></div>
+                    ${suggestion.card_ids.map(id => `<div class="comment-reply-to">${content(id)}</div>`).join('')}
+                    <div class="comment-actions">
+                        <button type="button" data-suggestion="${index}">${i18n.t('btn.merge')}</button>
+                    </div>
+                </div>
+            `).join('');
+        } catch (e) {
+            console.error('[Controller] Loading merge suggestions failed:', e);
+            window.toast.error(e.message);
+        }
+    }
+
+    async handleAcceptSuggestion(index) {
+        const suggestion = (this.mergeSuggestions || [])[index];
+        if (!suggestion) return;
+        try {
+            // The server broadcasts card_merged for every card it merged
+            await boardService.bulkMerge(this.boardId, suggestion.target_card_id, suggestion.card_ids);
+            await this.loadMergeSuggestions();
+        } catch (e) {
+            console.error('[Controller] Bulk merge failed:', e);
+            window.toast.error(e.message);
+        }
+    }
+
+    async handleUnmerge(cardId) {
+        // The server broadcasts card_updated, and group_deleted when the group empties
+        try {
+            await boardService.unmergeCard(cardId);
         } catch (e) {
             console.error('[Controller] Unmerge failed:', e);
             window.toast.error(e.message);
         }
     }
 
+    async handleAddGroup(columnId) {
+        const title = prompt(i18n.t('prompt.group_title'));
+        if (!title || !title.trim()) return;
+
+        // Cards selected for merging in this column start the group
+        const selected = this.selectedCardId ? this.findCard(this.selectedCardId) : null;
+        const cardIds = selected && selected.column_id === columnId ? [selected.id] : [];
+        try {
+            await boardService.createGroup(columnId, title.trim(), cardIds);
+            this.selectedCardId = null;
+        } catch (e) {
+            console.error('[Controller] Create group failed:', e);
+            window.toast.error(e.message);
+        }
+    }
+
+    async handleRenameGroup(groupId) {
+        const group = this.findGroup(groupId);
+        if (!group) return;
+        const title = prompt(i18n.t('prompt.group_title'), group.title);
+        if (!title || !title.trim() || title.trim() === group.title) return;
+
+        try {
+            await boardService.updateGroup(groupId, title.trim());
+        } catch (e) {
+            console.error('[Controller] Rename group failed:', e);
+            window.toast.error(e.message);
+        }
+    }
+
+    async handleUngroup(groupId) {
+        if (!await window.showConfirm(i18n.t('btn.ungroup'), i18n.t('confirm.ungroup'))) return;
+
+        try {
+            await boardService.deleteGroup(groupId);
+        } catch (e) {
+            console.error('[Controller] Ungroup failed:', e);
+            window.toast.error(e.message);
+        }
+    }
+
     async handleSwitchPhase() {
-        const newPhase = this.board.phase === 'input' ? 'voting' : 'input';
-
-        // Use Hybrid WebSocket send:
-        // 1. Top-level fields for Backend Handler (persists to DB)
-        // 2. 'data' wrapper for Frontend api.js Handler (dispatches event)
-        if (window.ws && window.ws.readyState === WebSocket.OPEN) {
-            window.ws.send(JSON.stringify({
-                type: 'phase_change',
-                phase: newPhase,
-                board_id: this.boardId,
-                data: {
-                    phase: newPhase,
-                    board_id: this.boardId
-                }
-            }));
-        } else {
-            console.warn('WebSocket not connected, cannot switch phase');
-            window.toast.error('Connection lost. Please refresh.');
+        const newPhase = nextPhase(this.board.phase);
+        if (!newPhase) return;
+
+        // The server validates the transition and broadcasts phase_changed to the board
+        try {
+            const updated = await boardService.updatePhase(this.boardId, newPhase);
+            this.board.phase = updated.phase;
+            this.board.phase_changed_at = updated.phase_changed_at;
+            this.board.phase_changed_by = updated.phase_changed_by;
+            window.currentPhase = updated.phase;
+            this.view.render(this.board, window.currentUser, this.selectedCardId, this.sortOption);
+            this.initSortable();
+            this.loadRemainingVotes();
+        } catch (e) {
+            console.error('[Controller] Phase change failed:', e);
+            window.toast.error(e.message);
+        }
+    }
+
+    async handleRevealCards() {
+        // The server broadcasts cards_revealed with every card of the board
+        try {
+            const updated = await boardService.revealCards(this.boardId);
+            this.board.cards_revealed_at = updated.cards_revealed_at;
+        } catch (e) {
+            console.error('[Controller] Reveal failed:', e);
+            window.toast.error(e.message);
         }
     }
 
     async handleStartTimer() {
-        // Need to get minutes from UI
+        // A paused timer picks up where it left off
+        if (this.board?.timer?.state === 'paused') {
+            return this.handleTimerAction('resume');
+        }
+
         const minutesInput = document.getElementById('timerMinutes');
         const minutes = minutesInput ? parseInt(minutesInput.value) || 5 : 5;
-        const seconds = minutes * 60;
-
-        const { sendWebSocketMessage } = await import('../api.js');
-        sendWebSocketMessage('timer_start', { seconds });
+        await this.handleTimerAction('start', { seconds: minutes * 60 });
     }
 
     async handleStopTimer() {
-        const { sendWebSocketMessage } = await import('../api.js');
-        sendWebSocketMessage('timer_stop', {});
+        await this.handleTimerAction('reset');
+    }
+
+    // The server persists the timer and broadcasts timer_updated to the board
+    async handleTimerAction(action, data = {}) {
+        try {
+            const result = await boardService.updateTimer(this.boardId, action, data);
+            this.board.timer = result.timer;
+            this.syncTimer(result.timer, result.server_time);
+        } catch (e) {
+            console.error(`[Controller] Timer ${action} failed:`, e);
+            window.toast.error(e.message);
+        }
+    }
+
+    // syncTimer shows the board timer. Countdowns run against the server's
+    // ends_at, shifted by the offset between the server and local clocks.
+    syncTimer(timer, serverTime) {
+        if (!timer) return;
+        const offset = serverTime ? Date.parse(serverTime) - Date.now() : 0;
+
+        switch (timer.state) {
+            case 'running':
+                this.startTimerUI(Date.parse(timer.ends_at) - offset);
+                break;
+            case 'paused':
+                this.stopTimerUI(timer.remaining);
+                break;
+            default:
+                this.stopTimerUI(0);
+        }
     }
 
     updateTimerDisplay(seconds) {
@@ -504,48 +1068,26 @@ export class BoardController extends Controller {
         }
     }
 
-    startTimerUI(seconds) {
-        // Show/Hide buttons handled in View or here?
-        // Simplest is here for now.
-        this.updateTimerDisplay(seconds);
-
+    startTimerUI(endsAt) {
         if (document.getElementById('startTimerBtn')) document.getElementById('startTimerBtn').style.display = 'none';
         if (document.getElementById('stopTimerBtn')) document.getElementById('stopTimerBtn').style.display = 'inline-block';
 
-        // Start local countdown for smoothness (optional, syncs with server anyway)
+        // Tick locally for smoothness; the server announces expiry with timer_updated
         if (this.timerInterval) clearInterval(this.timerInterval);
-        this.timerSeconds = seconds;
-        this.timerInterval = setInterval(() => {
-            this.timerSeconds--;
-            if (this.timerSeconds <= 0) {
-                this.timerSeconds = 0;
-                clearInterval(this.timerInterval);
-                playTimerSound();
-                // Optionally stop timer via API if this was the leader?
-                // For now, let the backend/WS stop it or let the user click stop.
-                // Actually, original `timer.js` called `stopTimer()` (which sends WS stop) when it hit 0.
-                // We should probably rely on the leader to stop it, OR just stop local UI.
-                // If we send 'timer_stop', it stops for everyone.
-                // Let's assume the client running the timer (leader) sends the stop.
-                // Check if manager?
-                // For now, just play sound and clear interval locally.
-                // The backend/other clients will sync via WS 'timer:stop' if sent.
-            }
+        const tick = () => {
+            this.timerSeconds = Math.max(0, Math.ceil((endsAt - Date.now()) / 1000));
             this.updateTimerDisplay(this.timerSeconds);
-        }, 1000);
-
-        // Play sound handled by WS event? 
-        // Legacy used local counter to trigger sound.
+            if (this.timerSeconds <= 0) clearInterval(this.timerInterval);
+        };
+        tick();
+        this.timerInterval = setInterval(tick, 1000);
     }
 
-    stopTimerUI() {
+    stopTimerUI(seconds = 0) {
         if (this.timerInterval) clearInterval(this.timerInterval);
         if (document.getElementById('startTimerBtn')) document.getElementById('startTimerBtn').style.display = 'inline-block';
         if (document.getElementById('stopTimerBtn')) document.getElementById('stopTimerBtn').style.display = 'none';
-        this.updateTimerDisplay(0);
-
-        // Play Sound?
-        // Legacy timer.js played sound on finish.
+        this.updateTimerDisplay(seconds);
     }
 
     handleAddColumn() {
@@ -603,6 +1145,230 @@ export class BoardController extends Controller {
         }
     }
 
+    // loadRemainingVotes shows what the current user has left to vote with
+    async loadRemainingVotes() {
+        const card = document.getElementById('remainingVotesCard');
+        const label = document.getElementById('remainingVotes');
+        if (!card || !label) return;
+        if (!this.board || this.board.phase !== 'voting' || this.board.status === 'finished') {
+            card.style.display = 'none';
+            return;
+        }
+        try {
+            const remaining = await boardService.getRemainingVotes(this.boardId, window.currentUser);
+            const budgets = [remaining.board, ...remaining.columns].filter(b => b.limit > 0);
+            if (budgets.length === 0) {
+                card.style.display = 'none';
+                return;
+            }
+            // The tightest budget is what can still be spent anywhere
+            const left = Math.min(...budgets.map(b => b.remaining));
+            const keys = { dot: 'dots.remaining', ranked: 'ranks.remaining', points: 'points.remaining' };
+            const key = keys[remaining.voting_mode] || 'votes.remaining';
+            label.textContent = `${left} ${i18n.t(key)}`;
+            card.title = remaining.columns.map(b => {
+                const column = (this.board.columns || []).find(c => c.id === b.column_id);
+                return `${column ? column.name : ''}: ${b.remaining}`;
+            }).join('\n');
+            card.style.display = '';
+        } catch (e) {
+            console.error('[Controller] Loading remaining votes failed:', e);
+            card.style.display = 'none';
+        }
+    }
+
+    async handleRemoveDot(voteId) {
+        try {
+            await boardService.removeVote(voteId);
+            await this.loadBoardData();
+        } catch (e) {
+            console.error('[Controller] Removing dot failed:', e);
+            window.toast.error(e.message);
+        }
+    }
+
+    // handleBallot sets the rank or points of a card on the current user's
+    // ballot, and hands the whole ballot in again
+    async handleBallot(cardId) {
+        const isRanked = this.board.voting_mode === 'ranked';
+        const user = window.currentUser;
+        const mine = (card) => (card.votes || []).find(v => v.user_name === user);
+        const ballot = [];
+        for (const col of this.board.columns || []) {
+            for (const card of col.cards || []) {
+                const vote = mine(card);
+                if (vote && card.id !== cardId) ballot.push({ card_id: card.id, weight: vote.weight });
+            }
+        }
+        const current = this.findCard(cardId);
+        const vote = current && mine(current);
+        const input = prompt(i18n.t(isRanked ? 'prompt.ballot_rank' : 'prompt.ballot_points'), vote ? vote.weight : 0);
+        if (input === null) return;
+        const weight = parseInt(input, 10);
+        if (isNaN(weight) || weight < 0) return;
+
+        let votes = ballot;
+        if (isRanked) {
+            // Slot the card in at its rank and renumber the cards behind it
+            const order = ballot.sort((a, b) => a.weight - b.weight).map(v => v.card_id);
+            if (weight > 0) order.splice(Math.min(weight, order.length + 1) - 1, 0, cardId);
+            votes = order.map((id, i) => ({ card_id: id, weight: i + 1 }));
+        } else if (weight > 0) {
+            votes.push({ card_id: cardId, weight });
+        }
+
+        try {
+            await boardService.submitBallot(this.boardId, user, votes);
+            await this.loadBoardData();
+        } catch (e) {
+            console.error('[Controller] Submitting ballot failed:', e);
+            window.toast.error(e.message);
+        }
+    }
+
+    // handleVoteResults lists the cards, or groups when the board has any, by
+    // their votes. Once voting is closed the server returns its snapshot.
+    async handleVoteResults() {
+        const hasGroups = (this.board.columns || []).some(c => (c.groups || []).length > 0);
+        try {
+            const results = await boardService.getVoteResults(this.boardId, hasGroups ? 'group' : 'card');
+            await this.showVoteResults(results);
+        } catch (e) {
+            console.error('[Controller] Loading vote results failed:', e);
+            window.toast.error(e.message);
+        }
+    }
+
+    async showVoteResults(results) {
+        const voted = (results.results || []).filter(r => r.voters > 0);
+        const html = voted.length === 0
+            ? escapeHtml(i18n.t('results.empty'))
+            : `<ol class="vote-results">${voted.map(r => `
+                <li><strong>${escapeHtml(r.title || '…')}</strong> · ${r.score} (${r.voters} ${escapeHtml(i18n.t('results.voters'))})</li>
+            `).join('')}</ol>`;
+        await window.showAlert(i18n.t('btn.vote_results'), html);
+    }
+
+    // handleCloseVoting freezes the votes; everyone gets the results through results:revealed
+    async handleCloseVoting() {
+        if (!await window.showConfirm(i18n.t('btn.close_voting'), i18n.t('confirm.close_voting'), { isDanger: false, confirmText: i18n.t('btn.close_voting') })) return;
+        try {
+            await boardService.closeVoting(this.boardId);
+        } catch (e) {
+            console.error('[Controller] Closing voting failed:', e);
+            window.toast.error(e.message);
+        }
+    }
+
+    // Closing survey
+    // The modal lives outside the board container, so it gets its own listeners
+    bindSurveyModal() {
+        if (this.surveyModalBound) return;
+        const form = document.getElementById('surveyForm');
+        const ratings = document.getElementById('surveyRatings');
+        if (!form || !ratings) return;
+        this.surveyModalBound = true;
+
+        form.addEventListener('submit', (e) => {
+            e.preventDefault();
+            this.handleAnswerSurvey();
+        });
+        ratings.addEventListener('click', (e) => {
+            const target = e.target.closest('[data-rating]');
+            if (!target) return;
+            this.surveyRating = parseInt(target.dataset.rating, 10);
+            ratings.querySelectorAll('[data-rating]').forEach(b => b.classList.toggle('selected', b === target));
+        });
+    }
+
+    surveyOpen() {
+        const modal = document.getElementById('surveyModal');
+        return modal && modal.style.display === 'block';
+    }
+
+    // handleOpenSurvey shows the survey form, or its results to those who
+    // answered. Opened on its own, it leaves people who answered alone.
+    async handleOpenSurvey(auto = false) {
+        const modal = document.getElementById('surveyModal');
+        if (!modal) return;
+        this.bindSurveyModal();
+        let survey;
+        try {
+            survey = await boardService.getSurvey(this.boardId);
+        } catch (e) {
+            console.error('[Controller] Loading survey failed:', e);
+            if (!auto) window.toast.error(e.message);
+            return;
+        }
+        if (auto && survey.answered) return;
+
+        this.surveyRating = null;
+        document.getElementById('surveyQuestion').textContent = survey.question;
+        document.getElementById('surveyRatings').innerHTML = Array.from({ length: survey.scale }, (_, i) => `
+            <button type="button" class="btn btn-glass survey-rating" data-rating="${i + 1}">${i + 1}</button>
+        `).join('');
+        document.getElementById('surveyComment').value = '';
+        document.getElementById('surveyCommentGroup').style.display = survey.comments ? '' : 'none';
+        document.getElementById('surveyForm').style.display = survey.answered ? 'none' : '';
+        this.renderSurveyResults(survey.answered ? survey.results : null);
+        modal.style.display = 'block';
+    }
+
+    // renderSurveyResults shows the anonymous tally to people who answered
+    renderSurveyResults(results) {
+        const container = document.getElementById('surveyResults');
+        if (!container) return;
+        if (!results || document.getElementById('surveyForm').style.display !== 'none') {
+            container.innerHTML = '';
+            return;
+        }
+        const most = Math.max(1, ...results.distribution);
+        container.innerHTML = `
+            <p><strong>${results.average.toFixed(1)} / ${results.scale}</strong> · ${results.responses} ${escapeHtml(i18n.t('survey.responses'))}</p>
+            ${results.distribution.map((count, i) => `
+                <div style="display: flex; align-items: center; gap: 0.5rem;">
+                    <span style="width: 1.5rem;">${i + 1}</span>
+                    <div class="survey-bar" style="flex: 1;"><div class="survey-bar-fill" style="width: ${Math.round(count / most * 100)}%;"></div></div>
+                    <span style="width: 1.5rem; text-align: right;">${count}</span>
+                </div>
+            `).join('')}
+            ${(results.comments || []).length > 0 ? `<ul class="vote-results">${results.comments.map(c => `<li>${escapeHtml(c)}</li>`).join('')}</ul>` : ''}
+        `;
+    }
+
+    async handleAnswerSurvey() {
+        if (!this.surveyRating) {
+            window.toast.error(i18n.t('survey.pick_rating'));
+            return;
+        }
+        const comment = document.getElementById('surveyComment').value.trim();
+        try {
+            const results = await boardService.answerSurvey(this.boardId, window.currentUser, this.surveyRating, comment);
+            document.getElementById('surveyForm').style.display = 'none';
+            this.renderSurveyResults(results);
+            window.toast.success(i18n.t('survey.thanks'));
+        } catch (e) {
+            console.error('[Controller] Answering survey failed:', e);
+            window.toast.error(e.message);
+        }
+    }
+
+    async handleColumnVoteLimit(columnId) {
+        const column = (this.board.columns || []).find(c => c.id === columnId);
+        if (!column) return;
+        const input = prompt(i18n.t('prompt.column_vote_limit'), column.vote_limit || 0);
+        if (input === null) return;
+        const limit = parseInt(input, 10);
+        if (isNaN(limit) || limit < 0) return;
+
+        try {
+            await boardService.setColumnVoteLimit(columnId, limit);
+        } catch (e) {
+            console.error('[Controller] Setting column budget failed:', e);
+            window.toast.error(e.message);
+        }
+    }
+
     async handleVote(cardId, type) {
         if (!cardId || !type) return;
 
@@ -685,6 +1451,7 @@ export class BoardController extends Controller {
         try {
             await apiCall(`/boards/${this.boardId}/status`, 'PUT', { status: 'finished' });
             await this.loadBoardData();
+            await this.handleOpenSurvey(true);
         } catch (e) {
             await window.showAlert('Error', e.message);
         }
@@ -828,6 +1595,14 @@ export class BoardController extends Controller {
         }
     }
 
+    findGroup(groupId) {
+        for (const col of (this.board && this.board.columns) || []) {
+            const group = (col.groups || []).find(g => g.id === groupId);
+            if (group) return group;
+        }
+        return null;
+    }
+
     findCard(cardId) {
         if (!this.board || !this.board.columns) return null;
         for (const col of this.board.columns) {
@@ -838,6 +1613,188 @@ export class BoardController extends Controller {
         return null;
     }
 
+    // Delta Events
+    // Each handler patches this.board and returns false when the board it holds
+    // is missing the entity, in which case the whole board is reloaded.
+    applyDelta(data, patch) {
+        if (data.board_id !== this.boardId || !this.board) return;
+        if (!patch()) {
+            this.loadBoardData();
+            return;
+        }
+        this.view.render(this.board, window.currentUser, this.selectedCardId, this.sortOption);
+        this.initSortable();
+    }
+
+    handleCardCreated(data) {
+        const column = (this.board.columns || []).find(c => c.id === data.card.column_id);
+        if (!column) return false;
+        column.cards = column.cards || [];
+        if (!column.cards.some(c => c.id === data.card.id)) {
+            column.cards.push(data.card);
+        }
+        return true;
+    }
+
+    handleCardUpdated(data) {
+        const card = this.findCard(data.card.id);
+        if (!card) return false;
+
+        const previousColumnId = card.column_id;
+        // Votes, reactions and comments are not part of the event; keep the ones we have.
+        // Events never carry hidden content, so authors keep the content of their own cards.
+        const update = { ...data.card };
+        if (update.hidden && !card.hidden) {
+            delete update.content;
+            delete update.hidden;
+        }
+        Object.assign(card, update);
+        card.group_id = data.card.group_id || null;
+        return this.moveCardToColumn(card, previousColumnId);
+    }
+
+    // moveCardToColumn moves a patched card into the list of its new column
+    moveCardToColumn(card, previousColumnId) {
+        if (card.column_id === previousColumnId) return true;
+        const from = (this.board.columns || []).find(c => c.id === previousColumnId);
+        const to = (this.board.columns || []).find(c => c.id === card.column_id);
+        if (!from || !to) return false;
+        from.cards = (from.cards || []).filter(c => c.id !== card.id);
+        to.cards = [...(to.cards || []), card];
+        return true;
+    }
+
+    upsertGroup(group) {
+        const column = (this.board.columns || []).find(c => c.id === group.column_id);
+        if (!column) return false;
+        column.groups = [...(column.groups || []).filter(g => g.id !== group.id), group];
+        return true;
+    }
+
+    handleGroupChanged(data) {
+        return this.upsertGroup(data.group);
+    }
+
+    handleGroupDeleted(data) {
+        // Cards and subgroups of the group move up to its parent
+        const parentId = data.parent_id || null;
+        for (const column of this.board.columns || []) {
+            column.groups = (column.groups || []).filter(g => g.id !== data.group_id);
+            column.groups.forEach(g => {
+                if (g.parent_id === data.group_id) g.parent_id = parentId;
+            });
+            (column.cards || []).forEach(c => {
+                if (c.group_id === data.group_id) c.group_id = parentId;
+            });
+        }
+        return true;
+    }
+
+    handleCardsRevealed(data) {
+        const revealed = new Map((data.cards || []).map(c => [c.id, c]));
+        for (const column of this.board.columns || []) {
+            for (const card of column.cards || []) {
+                const update = revealed.get(card.id);
+                if (!update) continue;
+                card.content = update.content;
+                card.author = update.author;
+                card.labels = update.labels;
+                card.hidden = false;
+            }
+        }
+        this.board.cards_revealed_at = data.revealed_at;
+        return true;
+    }
+
+    handleCardDeleted(data) {
+        for (const column of this.board.columns || []) {
+            if (!column.cards) continue;
+            column.cards = column.cards.filter(c => c.id !== data.card_id);
+        }
+        if (this.selectedCardId === data.card_id) this.selectedCardId = null;
+        return true;
+    }
+
+    handleCardMerged(data) {
+        const card = this.findCard(data.card.id);
+        const target = this.findCard(data.target_card_id);
+        if (!card || !target) return false;
+
+        // The card joins the group of its target, which may have just been created
+        if (!this.upsertGroup(data.group)) return false;
+        const previousColumnId = card.column_id;
+        Object.assign(card, data.card);
+        card.group_id = data.group.id;
+        target.group_id = data.group.id;
+        if (this.selectedCardId === card.id) this.selectedCardId = null;
+        return this.moveCardToColumn(card, previousColumnId);
+    }
+
+    handleColumnChanged(data, changes) {
+        const column = (this.board.columns || []).find(c => c.id === data.column_id);
+        if (!column) return false;
+        Object.assign(column, changes);
+        return true;
+    }
+
+    handleCommentChanged(data) {
+        const card = this.findCard(data.card_id);
+        if (!card) return false;
+
+        // Events carry the comment as the whole board sees it; the open thread
+        // is reloaded so it knows which comments are the viewer's own
+        const comments = (card.comments || []).filter(c => c.id !== (data.comment_id || data.comment.id));
+        if (data.comment) comments.push(data.comment);
+        card.comments = comments;
+        if (this.commentsOpen() && this.commentsCardId === data.card_id) this.loadComments();
+        return true;
+    }
+
+    handleLabelChanged(data) {
+        const labelId = data.label_id || data.label.id;
+        const sameLabel = (l) => l.id === labelId;
+        const replace = (labels) => (labels || []).map(l => (sameLabel(l) ? data.label : l));
+        const drop = (labels) => (labels || []).filter(l => !sameLabel(l));
+
+        if (data.action === 'label_created') {
+            this.board.labels = [...drop(this.board.labels), data.label]
+                .sort((a, b) => a.name.localeCompare(b.name));
+        } else {
+            const patch = data.action === 'label_deleted' ? drop : replace;
+            this.board.labels = patch(this.board.labels);
+            for (const column of this.board.columns || []) {
+                for (const card of column.cards || []) {
+                    if (card.labels) card.labels = patch(card.labels);
+                }
+            }
+            if (data.action === 'label_deleted' && this.labelFilter === labelId) this.setLabelFilter('', false);
+        }
+        if (this.labelsOpen()) this.renderLabelPicker();
+        return true;
+    }
+
+    handleCardLabeled(data) {
+        const card = this.findCard(data.card_id);
+        if (!card) return false;
+
+        const labels = (card.labels || []).filter(l => l.id !== data.label.id);
+        if (data.action === 'card_labeled') labels.push(data.label);
+        card.labels = labels.sort((a, b) => a.name.localeCompare(b.name));
+        if (this.labelsOpen() && this.labelsCardId === data.card_id) this.renderLabelPicker();
+        return true;
+    }
+
+    handleReactionToggled(data) {
+        const card = this.findCard(data.card_id);
+        if (!card) return false;
+
+        const { user_name: userName, reaction_type: reactionType } = data.reaction;
+        const reactions = (card.reactions || []).filter(r => !(r.user_name === userName && r.reaction_type === reactionType));
+        if (data.active) reactions.push(data.reaction);
+        card.reactions = reactions;
+        return true;
+    }
+
     // SortableJS Logic
     initSortable() {
         if (typeof Sortable === 'undefined') {
@@ -845,7 +1802,8 @@ export class BoardController extends Controller {
             return;
         }
 
-        const containers = document.querySelectorAll('.cards-container');
+        // Groups nest, so their card lists are drop targets too
+        const containers = document.querySelectorAll('.cards-container, .group-cards');
         containers.forEach(container => {
             // Check if already initialized to avoid duplicates? 
             // Better to destroy previous instance or rely on fresh render.
@@ -876,8 +1834,13 @@ export class BoardController extends Controller {
                 onEnd: async (evt) => {
                     const itemEl = evt.item;
                     const cardId = itemEl.dataset.id;
+                    const groupId = itemEl.dataset.groupId;
                     const toColumnEl = evt.to.closest('.column');
-                    const fromColumnEl = evt.from.closest('.column');
+                    const toGroupId = evt.to.dataset.groupId || null;
+                    // Groups and cards keep their own order within a list
+                    const indexAmong = (selector) => Array.from(evt.to.children)
+                        .filter(el => el.matches(selector))
+                        .indexOf(itemEl);
 
                     // If dropped in same list
                     if (evt.to === evt.from) {
@@ -893,11 +1856,37 @@ export class BoardController extends Controller {
                         }
                     }
 
+                    if (groupId && toColumnEl && toColumnEl.dataset.columnId) {
+                        try {
+                            await boardService.moveGroup(groupId, toColumnEl.dataset.columnId, indexAmong('.card-group'), toGroupId);
+                        } catch (error) {
+                            console.error('Move group failed:', error);
+                            window.toast.error(error.message);
+                        }
+                        this.loadBoardData();
+                        return;
+                    }
+
+                    if (cardId && toGroupId) {
+                        // Dropped into a group: the card joins it, moving columns if needed
+                        try {
+                            await boardService.setCardGroup(cardId, toGroupId);
+                        } catch (error) {
+                            console.error('Group card failed:', error);
+                            window.toast.error(error.message);
+                        }
+                        this.loadBoardData();
+                        return;
+                    }
+
                     if (cardId && toColumnEl && toColumnEl.dataset.columnId) {
                         const columnId = toColumnEl.dataset.columnId;
-                        const newIndex = evt.newIndex; // Get new index 0-based
+                        const newIndex = indexAmong('.retro-card'); // Get new index 0-based
                         console.log(`[Sortable] Moved ${cardId} to Column ${columnId} at index ${newIndex}`);
                         try {
+                            // Dragged out of its group onto the column
+                            const card = this.findCard(cardId);
+                            if (card && card.group_id) await boardService.setCardGroup(cardId, null);
                             await boardService.moveCard(cardId, columnId, newIndex);
                             // No need to reload, the WS update will trigger reload
                             this.loadBoardData();
@@ -980,6 +1969,11 @@ export class BoardController extends Controller {
 
     handleVoteUpdate(data) {
         const cardId = data.card_id;
+        // The groups the card is nested in get their voter totals along
+        for (const total of data.groups || []) {
+            const likesEl = document.querySelector(`.card-group[data-group-id="${total.group_id}"] .card-group-stats [data-section="likes"]`);
+            if (likesEl && total.likes !== -1) likesEl.innerHTML = `<i class="fas fa-thumbs-up"></i> ${total.likes}`;
+        }
         const cardEl = document.querySelector(`.retro-card[data-id="${cardId}"]`);
         if (!cardEl) {
             console.warn(`Card ${cardId} not found in DOM for vote update`);
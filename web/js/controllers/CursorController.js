@@ -45,12 +45,11 @@ export class CursorController {
         // Broad boundary check
         if (x < 0 || x > 1 || y < 0 || y > 1) return;
 
+        // The server stamps the sender's name on the relayed event
         sendWebSocketMessage('cursor_move', {
-            board_id: this.boardId,
-            user: window.currentUser,
-            x: x.toFixed(4), // Optimize payload
-            y: y.toFixed(4)
-        });
+            x: Number(x.toFixed(4)), // Optimize payload
+            y: Number(y.toFixed(4))
+        }, this.boardId);
     }
 
     onCursorMove(e) {
@@ -435,8 +435,7 @@ export class DashboardController {
         try {
             const payload = {
                 name,
-                columns,
-                owner: window.currentUser
+                columns
             };
             if (teamId) {
                 payload.team_id = teamId;
@@ -29,19 +29,66 @@ export class TeamAnalyticsController {
 
         try {
             // Fetch Team Details and Stats in parallel
-            const [team, stats] = await Promise.all([
+            const [team, stats, themes] = await Promise.all([
                 apiCall(`/teams/${teamId}`),
-                apiCall(`/teams/${teamId}/analytics`)
+                apiCall(`/teams/${teamId}/analytics`),
+                apiCall(`/teams/${teamId}/themes`)
             ]);
 
-            this.renderAnalytics(team, stats);
+            this.renderAnalytics(team, stats, themes);
         } catch (error) {
             console.error('Failed to load analytics:', error);
             container.innerHTML = `<div class="text-danger">Failed to load analytics: ${error.message}</div>`;
         }
     }
 
-    renderAnalytics(team, stats) {
+    renderThemes(report) {
+        const themes = report.themes || [];
+        if (themes.length === 0) {
+            return `<p class="text-secondary">No theme came up on more than one of the last ${report.boards_analyzed} boards.</p>`;
+        }
+
+        return themes.map(theme => `
+            <div class="theme-item" style="padding: 1rem 0; border-bottom: 1px solid rgba(255,255,255,0.1);">
+                <div style="display: flex; justify-content: space-between; gap: 1rem;">
+                    <strong>${escapeHtml(theme.title)}</strong>
+                    <span class="text-secondary">${theme.occurrences} cards on ${theme.boards.length} boards</span>
+                </div>
+                <div class="text-secondary" style="font-size: 0.85rem; margin-top: 0.25rem;">
+                    ${theme.boards.map(b => escapeHtml(b.name)).join(' · ')}
+                </div>
+                ${theme.action_items > 0 ? `
+                <div style="font-size: 0.85rem; margin-top: 0.25rem;">
+                    <i class="fas fa-tasks"></i> ${theme.completed_action_items}/${theme.action_items} action items completed
+                </div>
+                ` : `
+                <div style="font-size: 0.85rem; margin-top: 0.25rem; color: var(--warning);">
+                    <i class="fas fa-exclamation-triangle"></i> No action item yet
+                </div>
+                `}
+            </div>
+        `).join('');
+    }
+
+    // renderSurveyTrend charts how the team rated its last retrospectives,
+    // each average shown against the scale it was given on
+    renderSurveyTrend(trend) {
+        if (!trend || trend.length === 0) {
+            return `<p class="text-secondary">No closing survey was answered on the team's boards yet.</p>`;
+        }
+
+        return trend.map(point => `
+            <div class="survey-trend-item" style="padding: 0.5rem 0;">
+                <div style="display: flex; justify-content: space-between; gap: 1rem;">
+                    <span>${escapeHtml(point.board_name)}</span>
+                    <span class="text-secondary">${point.average.toFixed(1)} / ${point.scale} · ${point.responses} answers</span>
+                </div>
+                <div class="survey-bar"><div class="survey-bar-fill" style="width: ${Math.round(point.average / point.scale * 100)}%;"></div></div>
+            </div>
+        `).join('');
+    }
+
+    renderAnalytics(team, stats, themes) {
         const container = document.getElementById('teamAnalyticsContent');
 
         container.innerHTML = `
@@ -81,6 +128,20 @@ export class TeamAnalyticsController {
                     </div>
                 </div>
 
+                <!-- Recurring Themes -->
+                <div class="card" style="margin-bottom: 2rem;">
+                    <h3>Recurring Themes</h3>
+                    <p class="text-secondary">Problems raised again and again across the team's boards, grouped by label and wording.</p>
+                    ${this.renderThemes(themes)}
+                </div>
+
+                <!-- Closing Survey Trend -->
+                <div class="card" style="margin-bottom: 2rem;">
+                    <h3>Time Well Spent</h3>
+                    <p class="text-secondary">Average closing survey rating of the team's recent boards, oldest first.</p>
+                    ${this.renderSurveyTrend(stats.survey_trend)}
+                </div>
+
                 <!-- Charts Placeholders -->
                 <div class="card">
                     <h3>Activity Over Time (Coming Soon)</h3>
@@ -123,6 +123,7 @@ const translations = {
         'confirm.finish_retro': 'Are you sure you want to finish this retrospective? This will disable adding new cards and voting.',
         'confirm.reopen_retro': 'Re-open this retrospective?',
         'confirm.delete_card': 'Are you sure you want to delete this card?',
+        'confirm.delete_comment': 'Are you sure you want to delete this comment?',
         'confirm.delete_column': 'Are you sure you want to delete this column? All cards will be deleted.',
         'confirm.claim_board': 'Claim this board? You will be responsible for its settings.',
         'confirm.unclaim_board': 'Are you sure you want to relinquish your host role?',
@@ -281,6 +282,69 @@ const translations = {
         'phase.voting': 'Voting Phase',
         'phase.completed': 'Completed',
         'phase.discuss': 'Discuss Phase',
+        'phase.check-in': 'Check-in',
+        'phase.grouping': 'Grouping',
+        'phase.action-planning': 'Action Planning',
+        'phase.closed': 'Closed',
+        'btn.next_phase': 'Next Phase',
+        'btn.reveal_cards': 'Reveal Cards',
+        'card.hidden': 'Hidden until the facilitator reveals the cards',
+        'heading.comments': '💬 Comments',
+        'btn.comment': 'Comment',
+        'btn.reply': 'Reply',
+        'comments.placeholder': 'Add a comment...',
+        'comments.empty': 'No comments yet',
+        'comments.edited': 'edited',
+        'comments.replying': 'Replying to a comment',
+        'comments.anonymous': 'Anonymous',
+        'heading.labels': '🏷️ Labels',
+        'btn.add_label': 'Add Label',
+        'labels.all': 'All labels',
+        'labels.empty': 'This board has no labels yet',
+        'labels.name_placeholder': 'New label',
+        'btn.history': 'History',
+        'btn.restore': 'Restore',
+        'heading.card_history': '🕓 Card History',
+        'history.empty': 'No changes yet',
+        'history.restored': 'Restored an earlier version',
+        'confirm.restore_revision': 'Restore the card as it was before this change?',
+        'btn.suggest_merges': 'Suggest Merges',
+        'btn.merge': 'Merge',
+        'heading.merge_suggestions': '🧩 Merge Suggestions',
+        'merge.no_suggestions': 'No near-duplicate cards found',
+        'merge.similarity': 'Similarity',
+        'btn.add_group': 'Add Group',
+        'btn.rename_group': 'Rename Group',
+        'btn.ungroup': 'Ungroup',
+        'btn.remove_from_group': 'Remove from Group',
+        'group.untitled': 'Untitled group',
+        'group.cards': 'Cards',
+        'prompt.group_title': 'Group name:',
+        'confirm.ungroup': 'Ungroup these cards? They stay in the column.',
+        'dots.add': 'Add a dot',
+        'dots.remove': 'Take a dot off',
+        'dots.remaining': 'dots left',
+        'dots.column_budget': 'Dots per person in this column',
+        'votes.remaining': 'votes left',
+        'prompt.column_vote_limit': 'Dots each person may place in this column (0 = no column limit):',
+        'ballot.rank': 'Rank this card',
+        'ballot.points': 'Give this card points',
+        'prompt.ballot_rank': 'Rank of this card (1 = first choice, 0 = unranked):',
+        'prompt.ballot_points': 'Points for this card (0 = none, 100 to share):',
+        'ranks.remaining': 'cards left to rank',
+        'points.remaining': 'points left',
+        'btn.vote_results': 'Vote Results',
+        'results.empty': 'No votes yet.',
+        'results.voters': 'voters',
+        'btn.close_voting': 'Close Voting',
+        'confirm.close_voting': 'Close voting? Votes are frozen, the results are revealed to everyone and the board moves on to discussion.',
+        'btn.survey': 'Closing Survey',
+        'heading.survey': '⏱️ Closing Survey',
+        'btn.answer_survey': 'Send',
+        'survey.comment_placeholder': 'Anything to add? (optional, anonymous)',
+        'survey.pick_rating': 'Pick a rating first.',
+        'survey.thanks': 'Thanks for your feedback!',
+        'survey.responses': 'answers',
 
         // New Admin Features (v0.9.4)
         'admin.manage_boards': 'Manage Boards',
@@ -450,6 +514,7 @@ const translations = {
         'confirm.finish_retro': 'Tem certeza que deseja finalizar esta retrospectiva? Isso impedirá a criação de novos cards e votos.',
         'confirm.reopen_retro': 'Reabrir esta retrospectiva?',
         'confirm.delete_card': 'Tem certeza que deseja excluir este card?',
+        'confirm.delete_comment': 'Tem certeza que deseja excluir este comentário?',
         'confirm.delete_column': 'Tem certeza que deseja excluir esta coluna? Todos os cards serão excluídos.',
         'confirm.claim_board': 'Assumir esta retro? Você será responsável por suas configurações.',
         'confirm.unclaim_board': 'Tem certeza que deseja deixar a gestão?',
@@ -601,6 +666,69 @@ const translations = {
         'phase.voting': 'Fase de Votação',
         'phase.completed': 'Finalizada',
         'phase.discuss': 'Fase de Discussão',
+        'phase.check-in': 'Check-in',
+        'phase.grouping': 'Agrupamento',
+        'phase.action-planning': 'Plano de Ação',
+        'phase.closed': 'Encerrada',
+        'btn.next_phase': 'Próxima Fase',
+        'btn.reveal_cards': 'Revelar Cards',
+        'card.hidden': 'Oculto até o facilitador revelar os cards',
+        'heading.comments': '💬 Comentários',
+        'btn.comment': 'Comentar',
+        'btn.reply': 'Responder',
+        'comments.placeholder': 'Adicione um comentário...',
+        'comments.empty': 'Nenhum comentário ainda',
+        'comments.edited': 'editado',
+        'comments.replying': 'Respondendo a um comentário',
+        'comments.anonymous': 'Anônimo',
+        'heading.labels': '🏷️ Etiquetas',
+        'btn.add_label': 'Adicionar Etiqueta',
+        'labels.all': 'Todas as etiquetas',
+        'labels.empty': 'Este quadro ainda não tem etiquetas',
+        'labels.name_placeholder': 'Nova etiqueta',
+        'btn.history': 'Histórico',
+        'btn.restore': 'Restaurar',
+        'heading.card_history': '🕓 Histórico do Card',
+        'history.empty': 'Nenhuma alteração ainda',
+        'btn.suggest_merges': 'Sugerir Agrupamentos',
+        'btn.merge': 'Agrupar',
+        'heading.merge_suggestions': '🧩 Sugestões de Agrupamento',
+        'merge.no_suggestions': 'Nenhum card quase duplicado encontrado',
+        'merge.similarity': 'Similaridade',
+        'btn.add_group': 'Novo Grupo',
+        'btn.rename_group': 'Renomear Grupo',
+        'btn.ungroup': 'Desagrupar',
+        'btn.remove_from_group': 'Remover do Grupo',
+        'group.untitled': 'Grupo sem nome',
+        'group.cards': 'Cards',
+        'prompt.group_title': 'Nome do grupo:',
+        'confirm.ungroup': 'Desagrupar estes cards? Eles continuam na coluna.',
+        'dots.add': 'Adicionar um ponto',
+        'dots.remove': 'Remover um ponto',
+        'dots.remaining': 'pontos restantes',
+        'dots.column_budget': 'Pontos por pessoa nesta coluna',
+        'votes.remaining': 'votos restantes',
+        'prompt.column_vote_limit': 'Pontos que cada pessoa pode colocar nesta coluna (0 = sem limite na coluna):',
+        'ballot.rank': 'Classificar este card',
+        'ballot.points': 'Dar pontos a este card',
+        'prompt.ballot_rank': 'Posição deste card (1 = primeira escolha, 0 = sem posição):',
+        'prompt.ballot_points': 'Pontos para este card (0 = nenhum, 100 a distribuir):',
+        'ranks.remaining': 'cards restantes para classificar',
+        'points.remaining': 'pontos restantes',
+        'btn.vote_results': 'Resultado da Votação',
+        'results.empty': 'Nenhum voto ainda.',
+        'results.voters': 'votantes',
+        'btn.close_voting': 'Encerrar Votação',
+        'confirm.close_voting': 'Encerrar a votação? Os votos são congelados, o resultado é revelado a todos e o quadro segue para a discussão.',
+        'btn.survey': 'Pesquisa de Encerramento',
+        'heading.survey': '⏱️ Pesquisa de Encerramento',
+        'btn.answer_survey': 'Enviar',
+        'survey.comment_placeholder': 'Algo a acrescentar? (opcional, anônimo)',
+        'survey.pick_rating': 'Escolha uma nota primeiro.',
+        'survey.thanks': 'Obrigado pelo feedback!',
+        'survey.responses': 'respostas',
+        'history.restored': 'Restaurou uma versão anterior',
+        'confirm.restore_revision': 'Restaurar o card como estava antes desta alteração?',
         'label.team_optional': 'Time (Opcional)',
         'option.no_team': 'Sem Time (Pessoal)',
         'btn.manage_teams': 'Gerenciar Times',
@@ -758,6 +886,7 @@ const translations = {
         'confirm.finish_retro': 'Tem certeza que deseja finalizar esta retrospectiva? Isso impedirá a criação de novos cards e votos.',
         'confirm.reopen_retro': 'Reabrir esta retrospectiva?',
         'confirm.delete_card': 'Tem certeza que deseja excluir este card?',
+        'confirm.delete_comment': 'Tem certeza que deseja excluir este comentário?',
         'confirm.delete_column': 'Tem certeza que deseja excluir esta coluna? Todos os cards serão excluídos.',
         'confirm.claim_board': 'Assumir esta retro? Você será responsável por suas configurações.',
         'confirm.unclaim_board': 'Tem certeza que deseja deixar a gestão?',
@@ -893,6 +1022,69 @@ const translations = {
         'phase.voting': 'Fase de Votação',
         'phase.completed': 'Finalizada',
         'phase.discuss': 'Fase de Discussão',
+        'phase.check-in': 'Check-in',
+        'phase.grouping': 'Agrupamento',
+        'phase.action-planning': 'Plano de Ação',
+        'phase.closed': 'Encerrada',
+        'btn.next_phase': 'Próxima Fase',
+        'btn.reveal_cards': 'Revelar Cards',
+        'card.hidden': 'Oculto até o facilitador revelar os cards',
+        'heading.comments': '💬 Comentários',
+        'btn.comment': 'Comentar',
+        'btn.reply': 'Responder',
+        'comments.placeholder': 'Adicione um comentário...',
+        'comments.empty': 'Nenhum comentário ainda',
+        'comments.edited': 'editado',
+        'comments.replying': 'Respondendo a um comentário',
+        'comments.anonymous': 'Anônimo',
+        'heading.labels': '🏷️ Etiquetas',
+        'btn.add_label': 'Adicionar Etiqueta',
+        'labels.all': 'Todas as etiquetas',
+        'labels.empty': 'Este quadro ainda não tem etiquetas',
+        'labels.name_placeholder': 'Nova etiqueta',
+        'btn.history': 'Histórico',
+        'btn.restore': 'Restaurar',
+        'heading.card_history': '🕓 Histórico do Card',
+        'history.empty': 'Nenhuma alteração ainda',
+        'btn.suggest_merges': 'Sugerir Agrupamentos',
+        'btn.merge': 'Agrupar',
+        'heading.merge_suggestions': '🧩 Sugestões de Agrupamento',
+        'merge.no_suggestions': 'Nenhum card quase duplicado encontrado',
+        'merge.similarity': 'Similaridade',
+        'btn.add_group': 'Novo Grupo',
+        'btn.rename_group': 'Renomear Grupo',
+        'btn.ungroup': 'Desagrupar',
+        'btn.remove_from_group': 'Remover do Grupo',
+        'group.untitled': 'Grupo sem nome',
+        'group.cards': 'Cards',
+        'prompt.group_title': 'Nome do grupo:',
+        'confirm.ungroup': 'Desagrupar estes cards? Eles continuam na coluna.',
+        'dots.add': 'Adicionar um ponto',
+        'dots.remove': 'Remover um ponto',
+        'dots.remaining': 'pontos restantes',
+        'dots.column_budget': 'Pontos por pessoa nesta coluna',
+        'votes.remaining': 'votos restantes',
+        'prompt.column_vote_limit': 'Pontos que cada pessoa pode colocar nesta coluna (0 = sem limite na coluna):',
+        'ballot.rank': 'Classificar este card',
+        'ballot.points': 'Dar pontos a este card',
+        'prompt.ballot_rank': 'Posição deste card (1 = primeira escolha, 0 = sem posição):',
+        'prompt.ballot_points': 'Pontos para este card (0 = nenhum, 100 a distribuir):',
+        'ranks.remaining': 'cards restantes para classificar',
+        'points.remaining': 'pontos restantes',
+        'btn.vote_results': 'Resultado da Votação',
+        'results.empty': 'Nenhum voto ainda.',
+        'results.voters': 'votantes',
+        'btn.close_voting': 'Encerrar Votação',
+        'confirm.close_voting': 'Encerrar a votação? Os votos são congelados, o resultado é revelado a todos e o quadro segue para a discussão.',
+        'btn.survey': 'Pesquisa de Encerramento',
+        'heading.survey': '⏱️ Pesquisa de Encerramento',
+        'btn.answer_survey': 'Enviar',
+        'survey.comment_placeholder': 'Algo a acrescentar? (opcional, anônimo)',
+        'survey.pick_rating': 'Escolha uma nota primeiro.',
+        'survey.thanks': 'Obrigado pelo feedback!',
+        'survey.responses': 'respostas',
+        'history.restored': 'Restaurou uma versão anterior',
+        'confirm.restore_revision': 'Restaurar o card como estava antes desta alteração?',
         'label.team_optional': 'Time (Opcional)',
         'option.no_team': 'Sem Time (Pessoal)',
         'btn.manage_teams': 'Gerenciar Times',
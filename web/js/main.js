@@ -205,13 +205,12 @@ function setupEventListeners() {
 
         if (boardController && boardController.boardId) {
             const { apiCall } = await import('./api.js');
-            const { sendWebSocketMessage } = await import('./api.js');
+            // The server broadcasts board_update to other participants
             await apiCall(`/columns/${columnId}/cards`, 'POST', {
                 column_id: columnId,
                 content: content,
                 owner: window.currentUser
             });
-            sendWebSocketMessage('board_update', { board_id: boardController.boardId });
             boardController.loadBoardData();
         }
         closeModals();
@@ -223,9 +222,8 @@ function setupEventListeners() {
         const name = document.getElementById('columnNameEdit').value;
 
         if (boardController && boardController.boardId) {
-            const { apiCall, sendWebSocketMessage } = await import('./api.js');
+            const { apiCall } = await import('./api.js');
             await apiCall(`/columns/${columnId}`, 'PUT', { name });
-            sendWebSocketMessage('board_update', { board_id: boardController.boardId });
             boardController.loadBoardData();
         }
         closeModals();
@@ -531,7 +529,15 @@ function openBoardSettings(boardId) {
         const board = window.currentBoard || (boardController && boardController.board);
         if (board) {
             document.getElementById('settingVoteLimit').value = board.vote_limit || 0;
+            document.getElementById('settingVotingMode').value = board.voting_mode || 'like';
             document.getElementById('settingBlindVoting').checked = !!board.blind_voting;
+            document.getElementById('settingAllowGuests').checked = !!board.allow_guests;
+            document.getElementById('settingAnonymity').value = board.anonymity || 'anonymous';
+            document.getElementById('settingAuthorsRevealed').checked = !!board.authors_revealed;
+            document.getElementById('settingPrivateWriting').checked = !!board.private_writing;
+            document.getElementById('settingSurveyQuestion').value = board.survey_question || '';
+            document.getElementById('settingSurveyScale').value = board.survey_scale || 5;
+            document.getElementById('settingSurveyComments').checked = !!board.survey_comments;
         }
         modal.style.display = 'block';
     }
@@ -544,13 +550,29 @@ function closeBoardSettingsModal() {
 
 window.saveBoardSettings = async function () {
     const limit = parseInt(document.getElementById('settingVoteLimit').value) || 0;
+    const votingMode = document.getElementById('settingVotingMode').value;
     const blind = document.getElementById('settingBlindVoting').checked;
+    const allowGuests = document.getElementById('settingAllowGuests').checked;
+    const anonymity = document.getElementById('settingAnonymity').value;
+    const authorsRevealed = document.getElementById('settingAuthorsRevealed').checked;
+    const privateWriting = document.getElementById('settingPrivateWriting').checked;
+    const surveyQuestion = document.getElementById('settingSurveyQuestion').value.trim();
+    const surveyScale = parseInt(document.getElementById('settingSurveyScale').value) || 5;
+    const surveyComments = document.getElementById('settingSurveyComments').checked;
 
     if (window.currentBoard) {
         try {
             await boardService.update(window.currentBoard.id, {
                 vote_limit: limit,
-                blind_voting: blind
+                voting_mode: votingMode,
+                blind_voting: blind,
+                allow_guests: allowGuests,
+                anonymity,
+                authors_revealed: authorsRevealed,
+                private_writing: privateWriting,
+                survey_question: surveyQuestion,
+                survey_scale: surveyScale,
+                survey_comments: surveyComments
             });
             closeBoardSettingsModal();
             // Reload board
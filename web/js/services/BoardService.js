@@ -1,5 +1,13 @@
 import { apiCall } from '../api.js';
 
+// Board phases in facilitation order; the server decides which moves are allowed
+export const BOARD_PHASES = ['check-in', 'input', 'grouping', 'voting', 'discuss', 'action-planning', 'closed'];
+
+export function nextPhase(phase) {
+    const index = BOARD_PHASES.indexOf(phase || 'input');
+    return BOARD_PHASES[index + 1] || null;
+}
+
 export class BoardService {
     async getAll() {
         return await apiCall('/boards');
@@ -26,6 +34,94 @@ export class BoardService {
         return await apiCall(`/boards/${boardId}/status`, 'PUT', { status });
     }
 
+    async updatePhase(boardId, phase) {
+        return await apiCall(`/boards/${boardId}/phase`, 'PUT', { phase });
+    }
+
+    async listComments(cardId) {
+        return await apiCall(`/cards/${cardId}/comments`);
+    }
+
+    async addComment(cardId, body, parentId = null) {
+        return await apiCall(`/cards/${cardId}/comments`, 'POST', { body, parent_id: parentId });
+    }
+
+    async updateComment(cardId, commentId, body) {
+        return await apiCall(`/cards/${cardId}/comments/${commentId}`, 'PUT', { body });
+    }
+
+    async deleteComment(cardId, commentId) {
+        return await apiCall(`/cards/${cardId}/comments/${commentId}`, 'DELETE');
+    }
+
+    async getCardHistory(cardId) {
+        return await apiCall(`/cards/${cardId}/history`);
+    }
+
+    async restoreCardRevision(cardId, revisionId) {
+        return await apiCall(`/cards/${cardId}/history/${revisionId}/restore`, 'POST');
+    }
+
+    async listLabels(boardId) {
+        return await apiCall(`/boards/${boardId}/labels`);
+    }
+
+    async createLabel(boardId, data) {
+        return await apiCall(`/boards/${boardId}/labels`, 'POST', data);
+    }
+
+    async deleteLabel(boardId, labelId) {
+        return await apiCall(`/boards/${boardId}/labels/${labelId}`, 'DELETE');
+    }
+
+    async tagCard(cardId, labelId) {
+        return await apiCall(`/cards/${cardId}/labels/${labelId}`, 'POST');
+    }
+
+    async untagCard(cardId, labelId) {
+        return await apiCall(`/cards/${cardId}/labels/${labelId}`, 'DELETE');
+    }
+
+    async getRemainingVotes(boardId, userName) {
+        return await apiCall(`/boards/${boardId}/votes/remaining?user_name=${encodeURIComponent(userName)}`);
+    }
+
+    async submitBallot(boardId, userName, votes) {
+        return await apiCall(`/boards/${boardId}/ballot`, 'PUT', { user_name: userName, votes });
+    }
+
+    async getVoteResults(boardId, by = 'card') {
+        return await apiCall(`/boards/${boardId}/votes/results?by=${by}`);
+    }
+
+    async closeVoting(boardId) {
+        return await apiCall(`/boards/${boardId}/votes/close`, 'POST');
+    }
+
+    async getSurvey(boardId) {
+        return await apiCall(`/boards/${boardId}/survey`);
+    }
+
+    async answerSurvey(boardId, userName, rating, comment) {
+        return await apiCall(`/boards/${boardId}/survey`, 'POST', { user_name: userName, rating, comment });
+    }
+
+    async removeVote(voteId) {
+        return await apiCall(`/votes/${voteId}`, 'DELETE');
+    }
+
+    async setColumnVoteLimit(columnId, voteLimit) {
+        return await apiCall(`/columns/${columnId}/vote-limit`, 'PUT', { vote_limit: voteLimit });
+    }
+
+    async revealCards(boardId) {
+        return await apiCall(`/boards/${boardId}/reveal`, 'POST');
+    }
+
+    async updateTimer(boardId, action, data = {}) {
+        return await apiCall(`/boards/${boardId}/timer/${action}`, 'POST', data);
+    }
+
     async update(boardId, data) {
         return await apiCall(`/boards/${boardId}`, 'PUT', data);
     }
@@ -65,9 +161,37 @@ export class BoardService {
         return await apiCall(`/cards/${cardId}/unmerge`, 'POST');
     }
 
+    async getMergeSuggestions(boardId) {
+        return await apiCall(`/boards/${boardId}/merge-suggestions`);
+    }
+
+    async bulkMerge(boardId, targetCardId, cardIds) {
+        return await apiCall(`/boards/${boardId}/merge`, 'POST', { target_card_id: targetCardId, card_ids: cardIds });
+    }
+
     async moveCard(cardId, columnId, position) {
         return await apiCall(`/cards/${cardId}/move`, 'PUT', { column_id: columnId, position: position });
     }
+
+    async createGroup(columnId, title, cardIds = [], parentId = null) {
+        return await apiCall(`/columns/${columnId}/groups`, 'POST', { title, card_ids: cardIds, parent_id: parentId });
+    }
+
+    async updateGroup(groupId, title) {
+        return await apiCall(`/groups/${groupId}`, 'PUT', { title });
+    }
+
+    async moveGroup(groupId, columnId, position, parentId = null) {
+        return await apiCall(`/groups/${groupId}/move`, 'PUT', { column_id: columnId, parent_id: parentId, position });
+    }
+
+    async deleteGroup(groupId) {
+        return await apiCall(`/groups/${groupId}`, 'DELETE');
+    }
+
+    async setCardGroup(cardId, groupId) {
+        return await apiCall(`/cards/${cardId}/group`, 'PUT', { group_id: groupId });
+    }
 }
 
 export const boardService = new BoardService();
@@ -1,9 +1,30 @@
 import { escapeHtml } from '../utils.js';
 import { i18n } from '../i18n.js';
+import { nextPhase, BOARD_PHASES } from '../services/BoardService.js';
+
+// Dots and points are spent on purpose and add up; a like or a rank is one voter
+const isWeighted = (board) => board.voting_mode === 'dot' || board.voting_mode === 'points';
+
+// Ranked and points boards take whole ballots instead of votes card by card
+const isBallotVoting = (board) => board.voting_mode === 'ranked' || board.voting_mode === 'points';
+
+// Likes on a card; dots and points count one by one
+const cardLikes = (card, board) => (card.votes || [])
+    .filter(v => v.vote_type === 'like')
+    .reduce((sum, v) => sum + (isWeighted(board) ? (v.weight || 1) : 1), 0);
+
+// Groups count each voter once, however many of their cards they liked.
+// Dots and points add up over the group instead.
+const groupLikes = (cards, board) => isWeighted(board)
+    ? cards.reduce((sum, c) => sum + cardLikes(c, board), 0)
+    : new Set(cards.flatMap(c => (c.votes || [])
+        .filter(v => v.vote_type === 'like')
+        .map(v => v.user_name))).size;
 
 export class BoardView {
     constructor(containerId) {
         this.containerId = containerId;
+        this.labelFilter = ''; // Label ID the cards are filtered by, set by the controller
     }
 
     render(board, currentUser, selectedCardId = null, sortOption = 'position') {
@@ -109,6 +130,16 @@ export class BoardView {
             }
         };
 
+        // Label Filter - options follow the board's palette
+        const labelSelect = document.getElementById('labelFilterSelect');
+        if (labelSelect) {
+            const labels = board.labels || [];
+            labelSelect.style.display = labels.length > 0 ? '' : 'none';
+            labelSelect.innerHTML = `<option value="">${i18n.t('labels.all')}</option>` + labels
+                .map(l => `<option value="${l.id}" ${this.labelFilter === l.id ? 'selected' : ''}>${escapeHtml(l.name)}</option>`)
+                .join('');
+        }
+
         // Sort Controls - Label Update
         const sortLabel = document.getElementById('currentSortLabel');
         if (sortLabel) {
@@ -123,11 +154,20 @@ export class BoardView {
         // Phase Switch (Only if not finished)
         const switchBtn = document.getElementById('switchPhaseBtn');
         if (switchBtn) {
-            const isVoting = board.phase === 'voting';
-            switchBtn.innerHTML = isVoting ? `<i class="fas fa-gavel"></i> ${i18n.t('btn.end_voting') || 'End Voting'}` : `<i class="fas fa-vote-yea"></i> ${i18n.t('btn.start_voting') || 'Start Voting'}`;
-            toggle('switchPhaseBtn', canControl && !isFinished);
+            const next = nextPhase(board.phase);
+            if (board.phase === 'voting') {
+                switchBtn.innerHTML = `<i class="fas fa-gavel"></i> ${i18n.t('btn.end_voting') || 'End Voting'}`;
+            } else if (next === 'voting') {
+                switchBtn.innerHTML = `<i class="fas fa-vote-yea"></i> ${i18n.t('btn.start_voting') || 'Start Voting'}`;
+            } else if (next) {
+                switchBtn.innerHTML = `<i class="fas fa-forward"></i> ${i18n.t('btn.next_phase') || 'Next Phase'}: ${i18n.t('phase.' + next) || next}`;
+            }
+            toggle('switchPhaseBtn', canControl && !isFinished && !!next);
         }
 
+        // Private Writing Reveal
+        toggle('revealCardsBtn', canControl && !isFinished && board.private_writing && !board.cards_revealed_at);
+
         // Timer Controls
         // Allow Start if authorized and not finished. 
         // Stop is toggled by Controller usually, but we ensure Start is at least initially visible if idle.
@@ -152,6 +192,15 @@ export class BoardView {
         toggle('finishRetroBtn', isOwner && !isFinished);
         toggle('reopenRetroBtn', isOwner && isFinished);
         toggle('exportBoardBtn', true); // Everyone
+        // Closing Survey - opens when the board is first finished
+        toggle('surveyBtn', !!board.survey_opened_at);
+        // Vote Results - once voting started, and blind votes only after it
+        const votingStarted = BOARD_PHASES.indexOf(board.phase) >= BOARD_PHASES.indexOf('voting');
+        toggle('voteResultsBtn', votingStarted && !(board.phase === 'voting' && board.blind_voting && !board.voting_closed_at));
+        toggle('closeVotingBtn', canControl && !isFinished && board.phase === 'voting' && !board.voting_closed_at);
+
+        // Merge Suggestions - while cards can still be merged
+        toggle('suggestMergesBtn', !isFinished && (board.phase === 'input' || board.phase === 'grouping'));
 
         // Settings
         toggle('adminSettingsBtn', canControl && !isFinished);
@@ -226,25 +275,50 @@ export class BoardView {
     }
 
     createColumnHTML(column, board, currentUser, selectedCardId, sortOption = 'position') {
-        const visibleCards = (column.cards || []).filter(c => !c.merged_with_id);
+        const matchesFilter = (card) => !this.labelFilter || (card.labels || []).some(l => l.id === this.labelFilter);
+        const visibleCards = (column.cards || []).filter(matchesFilter);
+        const groups = column.groups || [];
 
         // Sorting Logic
-        if (sortOption === 'votes') {
-            visibleCards.sort((a, b) => {
-                const votesA = (a.votes || []).filter(v => v.vote_type === 'like').length;
-                const votesB = (b.votes || []).filter(v => v.vote_type === 'like').length;
-                return votesB - votesA; // Descending
-            });
-        } else if (sortOption === 'az') {
-            visibleCards.sort((a, b) => a.content.localeCompare(b.content));
-        } else {
-            // Position (Default)
-            visibleCards.sort((a, b) => a.position - b.position);
-        }
+        const sortCards = (cards) => {
+            if (sortOption === 'votes') {
+                cards.sort((a, b) => cardLikes(b, board) - cardLikes(a, board)); // Descending
+            } else if (sortOption === 'az') {
+                cards.sort((a, b) => a.content.localeCompare(b.content));
+            } else {
+                // Position (Default)
+                cards.sort((a, b) => a.position - b.position);
+            }
+            return cards;
+        };
 
-        const cardsHtml = visibleCards
+        // Groups nest; each one counts the cards of the groups inside it
+        const childGroups = (parentId) => groups.filter(g => (g.parent_id || null) === parentId);
+        const groupCards = (group) => visibleCards.filter(c => c.group_id === group.id)
+            .concat(...childGroups(group.id).map(groupCards));
+        const sortGroups = (list) => {
+            if (sortOption === 'votes') {
+                return list.sort((a, b) => groupLikes(groupCards(b), board) - groupLikes(groupCards(a), board));
+            }
+            if (sortOption === 'az') return list.sort((a, b) => (a.title || '').localeCompare(b.title || ''));
+            return list.sort((a, b) => a.position - b.position);
+        };
+        const renderGroups = (parentId) => sortGroups(childGroups(parentId))
+            // While filtering, groups without a matching card are left out
+            .filter(g => !this.labelFilter || groupCards(g).length > 0)
+            .map(g => this.createGroupHTML(g, board, groupCards(g), renderGroups(g.id) +
+                sortCards(visibleCards.filter(c => c.group_id === g.id))
+                    .map(card => this.createCardHTML(card, board, currentUser, selectedCardId))
+                    .join('')))
+            .join('');
+
+        // Cards whose group is unknown are shown ungrouped rather than lost
+        const groupIds = new Set(groups.map(g => g.id));
+        const cardsHtml = renderGroups(null) + sortCards(visibleCards.filter(c => !c.group_id || !groupIds.has(c.group_id)))
             .map(card => this.createCardHTML(card, board, currentUser, selectedCardId))
             .join('');
+        const isFinished = board.status === 'finished';
+        const isDotVoting = board.voting_mode === 'dot';
 
         return `
             <div class="column" data-column-id="${column.id}">
@@ -254,9 +328,16 @@ export class BoardView {
                         <span class="badge" style="margin-left:8px; font-size:0.8rem; opacity:0.8; background:rgba(255,255,255,0.1); padding:2px 6px; border-radius:10px;">
                             ${visibleCards.length}
                         </span>
+                        ${isDotVoting && column.vote_limit > 0 ? `
+                        <span class="badge" title="${i18n.t('dots.column_budget')}" style="margin-left:4px; font-size:0.8rem; opacity:0.8; background:rgba(255,255,255,0.1); padding:2px 6px; border-radius:10px;">
+                            <i class="fas fa-circle"></i> ${column.vote_limit}
+                        </span>
+                        ` : ''}
                     </h3>
                     <div class="column-actions">
                          <!-- Use data-action for delegation -->
+                         ${isDotVoting && !isFinished ? `<button class="btn-icon" data-action="columnVoteLimit" data-column-id="${column.id}" title="${i18n.t('dots.column_budget')}"><i class="fas fa-circle"></i></button>` : ''}
+                         ${!isFinished ? `<button class="btn-icon" data-action="addGroup" data-column-id="${column.id}" title="${i18n.t('btn.add_group')}"><i class="fas fa-object-group"></i></button>` : ''}
                          <button class="btn-icon" data-action="columnEdit" data-column-id="${column.id}"><i class="fas fa-pen"></i></button>
                          <button class="btn-icon" data-action="columnDelete" data-column-id="${column.id}"><i class="fas fa-trash"></i></button>
                     </div>
@@ -271,6 +352,33 @@ export class BoardView {
         `;
     }
 
+    createGroupHTML(group, board, cards, contentHtml) {
+        const isFinished = board.status === 'finished';
+        const showStats = !(board.phase === 'voting' && board.blind_voting && !board.voting_closed_at && !isFinished);
+        const likes = groupLikes(cards, board);
+
+        return `
+            <div class="card-group" data-group-id="${group.id}">
+                <div class="card-group-header">
+                    <span class="card-group-title">${group.title ? escapeHtml(group.title) : i18n.t('group.untitled')}</span>
+                    <span class="card-group-stats">
+                        ${showStats ? `<span data-section="likes"><i class="fas fa-thumbs-up"></i> ${likes}</span>` : `<span><i class="fas fa-eye-slash"></i> ???</span>`}
+                        <span title="${i18n.t('group.cards')}"><i class="fas fa-layer-group"></i> ${cards.length}</span>
+                    </span>
+                    ${!isFinished ? `
+                    <span class="card-group-actions">
+                        <button class="btn-icon" data-action="renameGroup" data-group-id="${group.id}" title="${i18n.t('btn.rename_group')}"><i class="fas fa-pen"></i></button>
+                        <button class="btn-icon" data-action="ungroup" data-group-id="${group.id}" title="${i18n.t('btn.ungroup')}"><i class="fas fa-object-ungroup"></i></button>
+                    </span>
+                    ` : ''}
+                </div>
+                <div class="group-cards" data-group-id="${group.id}">
+                    ${contentHtml}
+                </div>
+            </div>
+        `;
+    }
+
     createCardHTML(card, board, currentUser, selectedCardId) {
         const isOwner = card.owner === currentUser;
         const isBoardOwner = board.owner === currentUser;
@@ -281,19 +389,17 @@ export class BoardView {
         const isSelected = selectedCardId === card.id;
         const isMergeTarget = selectedCardId && !isSelected;
 
-        // Voting Logic - Aggregation
-        let allVotes = [...(card.votes || [])];
-        if (card.merged_cards) {
-            card.merged_cards.forEach(mc => {
-                if (mc.votes) allVotes = allVotes.concat(mc.votes);
-            });
-        }
+        // Voting Logic
+        const allVotes = card.votes || [];
+        const isDotVoting = board.voting_mode === 'dot';
 
-        const likes = allVotes.filter(v => v.vote_type === 'like').length;
+        const likes = cardLikes(card, board);
         // const dislikes = allVotes.filter(v => v.vote_type === 'dislike').length;
-        const userVotedLike = allVotes.some(v => v.user_name === currentUser && v.vote_type === 'like');
+        const myVote = allVotes.find(v => v.user_name === currentUser && v.vote_type === 'like');
+        const userVotedLike = !!myVote;
 
-        const isVotingPhase = board.phase === 'voting';
+        // Closed voting freezes the votes until the board is back in voting
+        const isVotingPhase = board.phase === 'voting' && !board.voting_closed_at;
         const isBlindVoting = isVotingPhase && board.blind_voting;
         const showStats = !(isBlindVoting && !isFinished);
 
@@ -313,9 +419,14 @@ export class BoardView {
                         <button class="dropdown-item" data-action="toggleActionItem" data-card-id="${card.id}">
                             <i class="fas ${isActionItem ? 'fa-square' : 'fa-check-square'}"></i> ${isActionItem ? i18n.t('btn.unmark_action') : i18n.t('btn.mark_action')}
                         </button>
-                        ${card.merged_cards && card.merged_cards.length > 0 ? `
+                        ${!card.hidden ? `
+                        <button class="dropdown-item" data-action="openHistory" data-card-id="${card.id}">
+                            <i class="fas fa-history"></i> ${i18n.t('btn.history')}
+                        </button>
+                        ` : ''}
+                        ${card.group_id ? `
                         <button class="dropdown-item" data-action="unmerge" data-card-id="${card.id}">
-                            <i class="fas fa-undo"></i> ${i18n.t('btn.unmerge')}
+                            <i class="fas fa-undo"></i> ${i18n.t('btn.remove_from_group')}
                         </button>
                         ` : ''}
                         <button class="dropdown-item danger" data-action="itemDelete" data-card-id="${card.id}">
@@ -330,8 +441,26 @@ export class BoardView {
         // Vote, Merge, Reactions
         let actionsHtml = '';
 
-        // 1. Voting
-        if (isVotingPhase && !isFinished) {
+        // 1. Voting - ballots set a rank or points, dots stack and come off one at a time
+        if (isVotingPhase && !isFinished && isBallotVoting(board)) {
+            const isRanked = board.voting_mode === 'ranked';
+            actionsHtml += `
+                <button class="btn-glass-icon compact ${myVote ? 'active' : ''}" data-action="ballot" data-card-id="${card.id}" title="${i18n.t(isRanked ? 'ballot.rank' : 'ballot.points')}">
+                    <i class="fas ${isRanked ? 'fa-list-ol' : 'fa-coins'}"></i>${myVote ? ` ${isRanked ? '#' : ''}${myVote.weight}` : ''}
+                </button>
+            `;
+        } else if (isVotingPhase && !isFinished && isDotVoting) {
+            actionsHtml += `
+                <button class="btn-glass-icon compact ${userVotedLike ? 'active' : ''}" data-action="vote" data-vote-type="like" data-card-id="${card.id}" title="${i18n.t('dots.add')}">
+                    <i class="fas fa-plus-circle"></i>${myVote ? ` ${myVote.weight || 1}` : ''}
+                </button>
+                ${myVote ? `
+                <button class="btn-glass-icon compact" data-action="removeDot" data-vote-id="${myVote.id}" title="${i18n.t('dots.remove')}">
+                    <i class="fas fa-minus-circle"></i>
+                </button>
+                ` : ''}
+            `;
+        } else if (isVotingPhase && !isFinished) {
             actionsHtml += `
                 <button class="btn-glass-icon compact ${userVotedLike ? 'active' : ''}" data-action="vote" data-vote-type="like" data-card-id="${card.id}" title="Upvote">
                     <i class="fas fa-thumbs-up"></i>
@@ -362,19 +491,35 @@ export class BoardView {
             }
         }
 
-        // 3. Reactions (Emoji Picker Trigger?)
+        // 3. Labels
+        if (!card.hidden && !isFinished) {
+            actionsHtml += `
+                <button class="btn-glass-icon compact" data-action="openLabels" data-card-id="${card.id}" title="${i18n.t('heading.labels')}">
+                    <i class="fas fa-tag"></i>
+                </button>
+            `;
+        }
+
+        // 4. Comments (the thread opens once the card is visible)
+        if (!card.hidden) {
+            const commentCount = (card.comments || []).length;
+            actionsHtml += `
+                <button class="btn-glass-icon compact" data-action="openComments" data-card-id="${card.id}" title="${i18n.t('heading.comments')}">
+                    <i class="fas fa-comment"></i>${commentCount > 0 ? ` ${commentCount}` : ''}
+                </button>
+            `;
+        }
+
+        // 5. Reactions (Emoji Picker Trigger?)
         // TODO: Add reaction trigger here if we want standard reactions
 
         // Footer Stats
-        const mergedCount = (card.merged_cards && card.merged_cards.length) || (card.merged_count || 0);
-
         const footerHtml = `
             <div class="card-stats ${!showStats ? 'blind' : ''}">
                 ${showStats ?
                 `<span class="vote-count likes" data-section="likes"><i class="fas fa-thumbs-up"></i> ${likes}</span>` :
                 `<span><i class="fas fa-eye-slash"></i> ???</span>`
             }
-                ${mergedCount > 0 ? `<span title="Merged Cards"><i class="fas fa-layer-group"></i> ${mergedCount}</span>` : ''}
                 ${card.is_action_item ? `<span class="badge warning" style="font-size:0.7em">Action Item</span>` : ''}
             </div>
             
@@ -390,12 +535,13 @@ export class BoardView {
                 ${menuHtml} <!-- Absolute positioned top-right -->
                 
                 <div class="card-content">
-                    ${escapeHtml(card.content)}
-                    ${(card.merged_cards || []).map(mc => `
-                        <div class="merged-content-item">
-                            ${escapeHtml(mc.content)}
+                    ${card.hidden ? `<span class="card-hidden"><i class="fas fa-lock"></i> ${i18n.t('card.hidden')}</span>` : escapeHtml(card.content)}
+                    ${card.author ? `<div class="card-author">— ${escapeHtml(card.author)}</div>` : ''}
+                    ${(card.labels || []).length > 0 ? `
+                        <div class="card-labels">
+                            ${card.labels.map(l => `<span class="label-chip" style="background: ${escapeHtml(l.color)}">${escapeHtml(l.name)}</span>`).join('')}
                         </div>
-                    `).join('')}
+                    ` : ''}
                 </div>
 
                 <div class="card-footer">